@@ -0,0 +1,112 @@
+// Command migrate applies, reverts and lists the schema migrations in pkg/migrations.
+//
+//	migrate up              apply every pending migration
+//	migrate down [steps]    revert the latest migration, or the latest steps
+//	migrate to <version>    migrate up or down to version (0 reverts everything)
+//	migrate status          list migrations and whether they are applied
+//
+// It connects to DATABASE_URL, like the services.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/migrations"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down [steps] | to <version> | status")
+	os.Exit(2)
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found or error loading, using environment variables")
+	}
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL environment variable is not set.")
+	}
+	appDB, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer appDB.Close()
+
+	migrator, err := migrations.New(appDB)
+	if err != nil {
+		log.Fatalf("Error loading migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	var changed []migrations.Migration
+	switch command := os.Args[1]; command {
+	case "up":
+		changed, err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			if steps, err = strconv.Atoi(os.Args[2]); err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %q", os.Args[2])
+			}
+		}
+		changed, err = migrator.Down(ctx, steps)
+	case "to":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		version, convErr := strconv.Atoi(os.Args[2])
+		if convErr != nil || version < 0 {
+			log.Fatalf("Invalid version %q", os.Args[2])
+		}
+		changed, err = migrator.To(ctx, version)
+	case "status":
+		printStatus(ctx, migrator)
+		return
+	default:
+		usage()
+	}
+
+	// Report what changed even on failure: earlier migrations are committed.
+	for _, migration := range changed {
+		log.Printf("Migrated %s", migration)
+	}
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
+	}
+	if len(changed) == 0 {
+		log.Println("Nothing to migrate.")
+	}
+}
+
+func printStatus(ctx context.Context, migrator *migrations.Migrator) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		log.Fatalf("Error reading migration status: %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "-"
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+	}
+	w.Flush()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -10,19 +11,48 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 
 	// Adjust the import path based on your go.mod module name
-	// "github.com/yourusername/social-network/pkg/models" // Models are used in handler
 	"github.com/yourusername/social-network/internal/userservice/handler"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/authmw"
+	"github.com/yourusername/social-network/pkg/jwks"
+	"github.com/yourusername/social-network/pkg/migrations"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
 )
 
 // var db *sql.DB // DB connection will be managed in main and passed to handler
 // var jwtSecretKey []byte // JWT key will be managed in main and passed to handler
 
+// bootstrapAdmin grants the admin role to the user named in BOOTSTRAP_ADMIN_USERNAME, so the
+// first administrator can be created without editing the database by hand.
+func bootstrapAdmin(repos *repository.Repositories) {
+	username := os.Getenv("BOOTSTRAP_ADMIN_USERNAME")
+	if username == "" {
+		return
+	}
+	ctx := context.Background()
+	user, err := repos.Users.GetByUsername(ctx, username)
+	if err == repository.ErrNotFound {
+		log.Printf("Warning: could not grant admin role to %q: no such user", username)
+		return
+	}
+	if err != nil {
+		log.Printf("Warning: could not grant admin role to %q: %v", username, err)
+		return
+	}
+	granted, err := repos.Roles.Grant(ctx, user.ID, models.RoleAdmin)
+	if err != nil {
+		log.Printf("Warning: could not grant admin role to %q: %v", username, err)
+		return
+	}
+	if granted {
+		log.Printf("Granted admin role to %q", username)
+	}
+}
 
 func main() {
 	if err := godotenv.Load(); err != nil {
@@ -49,44 +79,110 @@ func main() {
 	}
 	log.Println("User Service: Successfully connected to the database!")
 
-	// JWT Secret Key (must be the same as in auth-service for token verification)
-	secret := os.Getenv("JWT_SECRET_KEY")
-	if secret == "" {
-		log.Println("Warning: JWT_SECRET_KEY environment variable is not set for User Service. Using a default insecure key.")
-		secret = "temp-default-insecure-jwt-secret-key-please-change" // Fallback for dev
+	if err := migrations.CheckCurrent(appDB, os.Getenv("MIGRATE_ON_START") == "true"); err != nil {
+		log.Fatalf("Refusing to start: %v. Run `migrate up` or set MIGRATE_ON_START=true.", err)
+	}
+	repos := repository.NewPostgresRepositories(appDB)
+	bootstrapAdmin(repos)
+
+	// Access tokens are verified against the public keys auth-service publishes,
+	// so user-service never holds a key that could mint tokens.
+	jwksURL := os.Getenv("JWKS_URL")
+	if jwksURL == "" {
+		jwksURL = "http://localhost:8080/.well-known/jwks.json" // Default auth-service address for local dev
+	}
+	keyCache := jwks.NewCache(jwksURL, 5*time.Minute)
+	if err := keyCache.Refresh(); err != nil {
+		// Not fatal: the cache retries when the first token arrives.
+		log.Printf("Warning: could not fetch JWKS from %s: %v", jwksURL, err)
 	}
-	jwtKey := []byte(secret)
 
 	// Initialize Gin router
 	router := gin.New()
+
+	// Only the gateway may set X-Forwarded-For, otherwise clients could pick the IP address the
+	// login throttle and session list see. TRUSTED_PROXIES is a comma-separated list of IPs or
+	// CIDRs; by default no proxy is trusted and the connection's address is used.
+	var trustedProxies []string
+	if value := os.Getenv("TRUSTED_PROXIES"); value != "" {
+		trustedProxies = strings.Split(value, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
+		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\" %s\n",
 			param.ClientIP, param.TimeStamp.Format(time.RFC1123), param.Method, param.Path, param.Request.Proto,
 			param.StatusCode, param.Latency, param.Request.UserAgent(), param.ErrorMessage,
+			param.Keys[apierror.ContextRequestID],
 		)
 	}))
-	router.Use(gin.Recovery())
-	
+	router.Use(apierror.Recovery())
+	router.Use(apierror.Middleware())
+
 	// Initialize UserHandler
 	// Ensure correct module path for handler import
-	userHandler := handler.NewUserHandler(appDB, jwtKey)
+	userHandler := handler.NewUserHandler(repos, keyCache)
+	userHandler.Issuer = os.Getenv("JWT_ISSUER")     // e.g. "auth-service"
+	userHandler.Audience = os.Getenv("JWT_AUDIENCE") // e.g. "social-network"
+	if linkURL := os.Getenv("QR_LINK_URL"); linkURL != "" {
+		userHandler.QRLinkURL = linkURL
+	}
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		if err := appDB.Ping(); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN", "db_error": err.Error()})
+			log.Printf("Health check failed, database unreachable: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "DOWN"})
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"status": "UP", "message": "User service is healthy"})
 	})
-	
+
 	// API Routes - Removing /api/v1 prefix from service itself
 	userRoutes := router.Group("/users") // Routes will be /users/me, /users/:userId
 	userRoutes.Use(userHandler.AuthMiddleware())
 	{
-		userRoutes.GET("/me", userHandler.GetCurrentUserProfile)
-		userRoutes.PUT("/me", userHandler.UpdateCurrentUserProfile)
-		userRoutes.GET("/:userId", userHandler.GetUserProfile)
+		userRoutes.GET("/me", authmw.RequireUser(), authmw.RequireScope(models.ScopeProfileRead), userHandler.GetCurrentUserProfile)
+		userRoutes.PATCH("/me", authmw.RequireUser(), authmw.RequireScope(models.ScopeProfileWrite), userHandler.UpdateCurrentUserProfile)
+		// PUT is kept for existing clients and has the same merge patch semantics.
+		userRoutes.PUT("/me", authmw.RequireUser(), authmw.RequireScope(models.ScopeProfileWrite), userHandler.UpdateCurrentUserProfile)
+		userRoutes.GET("/me/follow-requests", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsRead), userHandler.ListFollowRequests)
+		userRoutes.POST("/me/follow-requests/:userId/approve", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.ApproveFollowRequest)
+		userRoutes.POST("/me/follow-requests/:userId/reject", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.RejectFollowRequest)
+		userRoutes.GET("/me/blocks", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsRead), userHandler.ListBlocks)
+		userRoutes.GET("/me/mutes", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsRead), userHandler.ListMutes)
+		userRoutes.GET("/me/qr", authmw.RequireUser(), authmw.RequireScope(models.ScopeProfileRead), userHandler.GetCurrentUserQR)
+		userRoutes.POST("/me/qr/rotate", authmw.RequireUser(), authmw.RequireScope(models.ScopeProfileWrite), userHandler.RotateQRCode)
+		userRoutes.POST("/me/qr/invites", authmw.RequireUser(), authmw.RequireScope(models.ScopeProfileWrite), userHandler.CreateQRInvite)
+		userRoutes.GET("/by-qr/:identifier", authmw.RequireScope(models.ScopeProfileRead), userHandler.GetUserByQR)
+		userRoutes.POST("/by-qr/:identifier/follow", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.FollowUserByQR)
+		userRoutes.GET("/search", authmw.RequireScope(models.ScopeProfileRead), userHandler.SearchUsers)
+		userRoutes.GET("/by-username/:username", authmw.RequireScope(models.ScopeProfileRead), userHandler.GetUserByUsername)
+		userRoutes.GET("/:userId", authmw.RequireScope(models.ScopeProfileRead), userHandler.GetUserProfile)
+		userRoutes.POST("/:userId/follow", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.FollowUser)
+		userRoutes.DELETE("/:userId/follow", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.UnfollowUser)
+		userRoutes.GET("/:userId/followers", authmw.RequireScope(models.ScopeFollowsRead), userHandler.ListFollowers)
+		userRoutes.GET("/:userId/following", authmw.RequireScope(models.ScopeFollowsRead), userHandler.ListFollowing)
+		userRoutes.POST("/:userId/block", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.BlockUser)
+		userRoutes.DELETE("/:userId/block", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.UnblockUser)
+		userRoutes.POST("/:userId/mute", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.MuteUser)
+		userRoutes.DELETE("/:userId/mute", authmw.RequireUser(), authmw.RequireScope(models.ScopeFollowsWrite), userHandler.UnmuteUser)
+	}
+
+	// Admin API - /admin/users, /admin/roles, /admin/audit-log
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(userHandler.AuthMiddleware(), authmw.RequireRole(models.RoleAdmin, models.RoleSupport))
+	{
+		adminRoutes.GET("/users", userHandler.RequirePermission(models.PermUsersRead), userHandler.AdminListUsers)
+		adminRoutes.GET("/users/:userId", userHandler.RequirePermission(models.PermUsersRead), userHandler.AdminGetUser)
+		adminRoutes.POST("/users/:userId/deactivate", userHandler.RequirePermission(models.PermUsersDeactivate), userHandler.AdminDeactivateUser)
+		adminRoutes.POST("/users/:userId/reactivate", userHandler.RequirePermission(models.PermUsersDeactivate), userHandler.AdminReactivateUser)
+		adminRoutes.POST("/users/:userId/force-password-reset", userHandler.RequirePermission(models.PermUsersResetPassword), userHandler.AdminForcePasswordReset)
+		adminRoutes.POST("/users/:userId/unlock", userHandler.RequirePermission(models.PermUsersUnlock), userHandler.AdminUnlockUser)
+		adminRoutes.PUT("/users/:userId/roles", userHandler.RequirePermission(models.PermRolesAssign), userHandler.AdminAssignRoles)
+		adminRoutes.GET("/roles", userHandler.RequirePermission(models.PermUsersRead), userHandler.AdminListRoles)
+		adminRoutes.GET("/audit-log", userHandler.RequirePermission(models.PermAuditRead), userHandler.AdminListAuditLog)
 	}
 
 	servicePort := os.Getenv("USER_SERVICE_PORT")
@@ -1,29 +0,0 @@
-package db
-
-// This file will contain database interaction logic for auth-service.
-// For now, it's a placeholder.
-
-import (
-	"database/sql"
-	"fmt"
-
-	_ "github.com/lib/pq" // PostgreSQL driver
-)
-
-// DBStore structure can be defined here if needed, e.g.
-// type DBStore struct {
-//   DB *sql.DB
-// }
-
-// Example function (placeholder)
-func ConnectAuthDB(dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", dataSourceName)
-	if err != nil {
-		return nil, fmt.Errorf("auth db: could not open database connection: %w", err)
-	}
-	if err = db.Ping(); err != nil {
-		return nil, fmt.Errorf("auth db: could not ping database: %w", err)
-	}
-	fmt.Println("Auth DB connected successfully (placeholder)")
-	return db, nil
-}
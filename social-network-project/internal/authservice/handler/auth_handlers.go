@@ -1,69 +1,160 @@
 package handler
 
 import (
-	"database/sql"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
 	// Adjust the import path based on your go.mod module name
+	"github.com/yourusername/social-network/internal/authservice/keys"
+	"github.com/yourusername/social-network/internal/authservice/notify"
+	"github.com/yourusername/social-network/internal/authservice/passwordpolicy"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/mailer"
 	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/throttle"
+	"github.com/yourusername/social-network/pkg/validation"
 )
 
 // AuthHandler struct holds dependencies for authentication handlers.
 type AuthHandler struct {
-	DB           *sql.DB
-	JwtSecretKey []byte
+	Tx            repository.Transactor             // Begins transactions spanning the repositories below
+	Users         repository.UserRepository         // Accounts, shared with user-service
+	Sessions      repository.SessionRepository      // Session registry checked by both services
+	Roles         repository.RoleRepository         // Roles put in first-party access tokens
+	MFA           repository.MFARepository          // TOTP secrets, recovery codes and login challenges
+	Passkeys      repository.PasskeyRepository      // Passkeys and WebAuthn ceremonies
+	Identities    repository.IdentityRepository     // External sign-in providers linked to accounts
+	OAuth         repository.OAuthRepository        // Third-party clients, consents and authorization codes
+	AccountTokens repository.AccountTokenRepository // Email verification and password reset tokens
+
+	Keys            *keys.KeySet  // Asymmetric keys used to sign access tokens
+	AccessTokenTTL  time.Duration // Lifetime of issued access JWTs
+	RefreshTokenTTL time.Duration // Lifetime of issued refresh tokens
+	Issuer          string        // "iss" claim of issued tokens
+	Audience        string        // "aud" claim of issued tokens
+
+	PasswordPolicy *passwordpolicy.Policy // Rules new passwords must satisfy at registration, change and reset
+
+	Notifier             notify.Notifier // Delivers verification and password reset links
+	RequireEmail         bool            // Reject registrations without an email address
+	EmailVerificationURL string          // Link target in verification emails; the token is appended as ?token=
+	EmailVerificationTTL time.Duration   // Lifetime of email verification tokens
+	PasswordResetURL     string          // Link target in password reset emails; the token is appended as ?token=
+	PasswordResetTTL     time.Duration   // Lifetime of password reset tokens
+
+	MFAChallengeTTL time.Duration // Lifetime of the challenge token returned by Login when a second factor is needed
+	TOTPIssuer      string        // Issuer label shown in authenticator apps
+
+	WebAuthn            *webauthn.WebAuthn // Passkey relying party; the passkey endpoints answer 503 when nil
+	WebAuthnCeremonyTTL time.Duration      // Lifetime of a begun passkey registration or login
+
+	Throttle              *throttle.Limiter // Failed-login backoff and lockout per username and IP
+	PasswordResetThrottle *throttle.Limiter // Backoff for password reset requests per email address and IP
+
+	OIDCProviders map[string]*OIDCProvider // External sign-in providers by name, as used in /auth/oidc/:provider
+	OIDCStateTTL  time.Duration            // Time allowed to finish signing in at a provider
+
+	OAuthCodeTTL time.Duration // Lifetime of authorization codes issued to third-party clients
 }
 
 // NewAuthHandler creates a new AuthHandler with necessary dependencies.
-func NewAuthHandler(db *sql.DB, jwtKey []byte) *AuthHandler {
+func NewAuthHandler(repos *repository.Repositories, keySet *keys.KeySet) *AuthHandler {
 	return &AuthHandler{
-		DB:           db,
-		JwtSecretKey: jwtKey,
+		Tx:            repos.Tx,
+		Users:         repos.Users,
+		Sessions:      repos.Sessions,
+		Roles:         repos.Roles,
+		MFA:           repos.MFA,
+		Passkeys:      repos.Passkeys,
+		Identities:    repos.Identities,
+		OAuth:         repos.OAuth,
+		AccountTokens: repos.AccountTokens,
+
+		Keys:            keySet,
+		AccessTokenTTL:  DefaultAccessTokenTTL,
+		RefreshTokenTTL: DefaultRefreshTokenTTL,
+		Issuer:          DefaultIssuer,
+		Audience:        DefaultAudience,
+
+		PasswordPolicy: passwordpolicy.Default(),
+
+		Notifier:             &notify.EmailNotifier{Mailer: mailer.LogMailer{}},
+		EmailVerificationURL: "http://localhost/verify-email",
+		EmailVerificationTTL: DefaultEmailVerificationTTL,
+		PasswordResetURL:     "http://localhost/reset-password",
+		PasswordResetTTL:     DefaultPasswordResetTTL,
+
+		MFAChallengeTTL: DefaultMFAChallengeTTL,
+		TOTPIssuer:      DefaultTOTPIssuer,
+
+		WebAuthnCeremonyTTL: DefaultWebAuthnCeremonyTTL,
+
+		Throttle:              throttle.NewLimiter(repos.LoginThrottle),
+		PasswordResetThrottle: newPasswordResetLimiter(repos.LoginThrottle),
+
+		OIDCProviders: map[string]*OIDCProvider{},
+		OIDCStateTTL:  DefaultOIDCStateTTL,
+
+		OAuthCodeTTL: DefaultOAuthCodeTTL,
 	}
 }
 
+// registrationPendingMessage answers every registration with an email address.
+const registrationPendingMessage = "Registration received. Check your email to verify your address"
+
 // Register handles user registration.
-// Corresponds to the previous registerHandler function.
+// Corresponds to the previous registerHandler function. Registrations with an email address are
+// answered with 202 and no account details, the same as when the address already belongs to an
+// account, so registering can't be used to find out which addresses are in use.
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req models.RegistrationRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
-	// Check if username already exists
-	var existingUserID uuid.UUID
-	err := h.DB.QueryRow("SELECT id FROM users WHERE username = $1", req.Username).Scan(&existingUserID)
-	if err != sql.ErrNoRows { // Username exists or another error occurred
-		if err == nil { // err is nil means user was found
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
+	// Usernames are case-insensitive. Whether the username or email is taken isn't checked up front:
+	// the unique constraints decide when the user is inserted, so concurrent registrations can't race.
+	username := models.NormalizeUsername(req.Username)
+
+	// Email is optional unless the service is configured to require it.
+	var (
+		email string
+		err   error
+	)
+	if req.Email != "" {
+		if email, err = normalizeEmail(req.Email); err != nil {
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidEmail, "Invalid email address"))
 			return
 		}
-		// Another DB error occurred
-		log.Printf("Error checking existing username: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process registration (db check)"})
+	} else if h.RequireEmail {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeEmailRequired, "Email address is required"))
+		return
+	}
+
+	if !h.checkPasswordPolicy(c, req.Password, passwordpolicy.Account{Username: username, Email: email}) {
 		return
 	}
-	// If err is sql.ErrNoRows, username does not exist, proceed.
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process registration (hash)"})
+		apierror.Abort(c, apierror.Internal("Failed to process registration (hash)"))
 		return
 	}
 
 	now := time.Now().UTC()
 	newUser := models.User{
 		ID:               uuid.New(),
-		Username:         req.Username,
+		Username:         username,
+		Email:            email,
 		PasswordHash:     string(hashedPassword),
 		DisplayName:      req.DisplayName,
 		CreatedAt:        now,
@@ -73,14 +164,32 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Bio:              "", // Default Bio
 	}
 	if newUser.DisplayName == "" {
-		newUser.DisplayName = newUser.Username
+		newUser.DisplayName = strings.TrimSpace(req.Username) // Keeps the capitalization the user chose
 	}
 
-	_, err = h.DB.Exec("INSERT INTO users (id, username, password_hash, display_name, bio, qr_code_identifier, created_at, updated_at, is_active) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
-		newUser.ID, newUser.Username, newUser.PasswordHash, newUser.DisplayName, newUser.Bio, newUser.QRCodeIdentifier, newUser.CreatedAt, newUser.UpdatedAt, newUser.IsActive)
+	err = h.Users.Create(c.Request.Context(), &newUser)
+	if err == repository.ErrUsernameTaken {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeUsernameTaken, "Username already exists"))
+		return
+	}
+	if err == repository.ErrEmailTaken {
+		// The owner of the address gets an email instead of a verification link.
+		h.notifyEmailInUse(c.Request.Context(), email)
+		c.JSON(http.StatusAccepted, gin.H{"message": registrationPendingMessage})
+		return
+	}
 	if err != nil {
 		log.Printf("Error inserting new user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		apierror.Abort(c, apierror.Internal("Failed to create user"))
+		return
+	}
+
+	// Registration succeeds even if the email can't be sent; the user can request another one.
+	if newUser.Email != "" {
+		if err := h.sendVerificationEmail(c.Request.Context(), newUser.ID, newUser.Email); err != nil {
+			log.Printf("Error sending verification email to new user %s: %v", newUser.ID, err)
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": registrationPendingMessage})
 		return
 	}
 
@@ -91,45 +200,72 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // Corresponds to the previous loginHandler function.
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+	if !validation.BindJSON(c, &req) {
 		return
 	}
 
-	var user models.User
-	err := h.DB.QueryRow("SELECT id, username, password_hash, display_name, bio, qr_code_identifier, created_at, updated_at, is_active FROM users WHERE username = $1 AND is_active = TRUE", req.Username).Scan(
-		&user.ID, &user.Username, &user.PasswordHash, &user.DisplayName, &user.Bio, &user.QRCodeIdentifier, &user.CreatedAt, &user.UpdatedAt, &user.IsActive,
-	)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+	// Normalized so that "Alice" and "alice" share throttle counters as they share the account.
+	req.Username = models.NormalizeUsername(req.Username)
+	attempt, ok := h.checkLoginThrottle(c, req.Username)
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.GetByUsername(c.Request.Context(), req.Username)
+	if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+		// Count unknown usernames too, so they can't be told apart from wrong passwords.
+		h.recordLoginFailure(c, attempt, nil)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidCredentials, "Invalid username or password"))
 		return
 	}
 	if err != nil {
 		log.Printf("Error fetching user: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process login"})
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
 		return
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		h.recordLoginFailure(c, attempt, user)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidCredentials, "Invalid username or password"))
 		return
 	}
 
-	claims := jwt.MapClaims{
-		"user_id":  user.ID.String(),
-		"username": user.Username,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
-		"iat":      time.Now().Unix(),
+	// An administrator required this account to choose a new password before it can be used again.
+	if user.PasswordResetRequired {
+		h.releaseLoginAttempt(c, attempt)
+		apierror.Abort(c, apierror.Forbidden(apierror.CodePasswordResetRequired, "Password reset required"))
+		return
+	}
+
+	// With two-factor authentication enabled the password only earns a challenge token,
+	// which /auth/login/mfa exchanges for real tokens together with a second factor.
+	withMFA, err := h.MFA.Enabled(c.Request.Context(), user.ID)
+	if err != nil {
+		log.Printf("Error checking MFA for user %s: %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+	if withMFA {
+		// Second-factor guesses are throttled by LoginMFA.
+		h.releaseLoginAttempt(c, attempt)
+		challenge, err := h.createMFAChallenge(c.Request.Context(), user.ID, req.DeviceName)
+		if err != nil {
+			log.Printf("Error creating MFA challenge for user %s: %v", user.ID, err)
+			apierror.Abort(c, apierror.Internal("Failed to process login"))
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
 	}
+	h.recordLoginSuccess(c, attempt)
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(h.JwtSecretKey)
+	resp, err := h.issueTokens(c.Request.Context(), user, deviceFromRequest(c, req.DeviceName))
 	if err != nil {
-		log.Printf("Error generating token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		log.Printf("Error generating tokens: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{Token: tokenString, User: &user})
+	c.JSON(http.StatusOK, resp)
 }
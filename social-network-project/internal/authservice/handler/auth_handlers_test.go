@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+func TestRegisterWithTakenEmail(t *testing.T) {
+	h, repos := newTestHandler(t)
+	notifier := &recordingNotifier{}
+	h.Notifier = notifier
+	newVerifiedUser(t, repos, "alice", "alice@example.com")
+
+	fresh := serveJSON(uuid.Nil, http.MethodPost, "/auth/register", models.RegistrationRequest{Username: "carol", Email: "carol@example.com", Password: testPassword}, h.Register)
+	taken := serveJSON(uuid.Nil, http.MethodPost, "/auth/register", models.RegistrationRequest{Username: "mallory", Email: "Alice@Example.com", Password: testPassword}, h.Register)
+	if fresh.Code != http.StatusAccepted || taken.Code != fresh.Code || taken.Body.String() != fresh.Body.String() {
+		t.Fatalf("responses differ: free address %d %s, taken address %d %s", fresh.Code, fresh.Body.String(), taken.Code, taken.Body.String())
+	}
+
+	if _, ok := notifier.verifyLinks["carol@example.com"]; !ok || len(notifier.verifyLinks) != 1 {
+		t.Errorf("verification links = %v, want one to carol@example.com", notifier.verifyLinks)
+	}
+	if len(notifier.emailInUse) != 1 || notifier.emailInUse[0] != "alice@example.com" {
+		t.Errorf("in-use notices = %v, want one to alice@example.com", notifier.emailInUse)
+	}
+	if _, err := repos.Users.GetByUsername(context.Background(), "mallory"); err != repository.ErrNotFound {
+		t.Errorf("account for the taken address: err = %v, want %v", err, repository.ErrNotFound)
+	}
+}
@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// DefaultEmailVerificationTTL is how long a verification link stays valid.
+const DefaultEmailVerificationTTL = 24 * time.Hour
+
+var errInvalidEmail = errors.New("invalid email address")
+
+// normalizeEmail validates a bare address (no display name) and lower-cases it so
+// uniqueness checks are case-insensitive.
+func normalizeEmail(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	addr, err := mail.ParseAddress(trimmed)
+	if err != nil || addr.Address != trimmed || len(trimmed) > 255 {
+		return "", errInvalidEmail
+	}
+	return strings.ToLower(trimmed), nil
+}
+
+// sendVerificationEmail stores a new single-use verification token for the address and mails the
+// link. Links sent earlier stop working, so only the latest requested address can be confirmed.
+func (h *AuthHandler) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	token, tokenHash, err := opaquetoken.New()
+	if err != nil {
+		return err
+	}
+	if err := h.AccountTokens.UseEmailVerifications(ctx, userID); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	err = h.AccountTokens.CreateEmailVerification(ctx, &repository.AccountToken{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		Email:     email,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := h.EmailVerificationURL + "?token=" + url.QueryEscape(token)
+	return h.Notifier.VerifyEmail(ctx, email, link, h.EmailVerificationTTL)
+}
+
+// RequestEmailVerification sends a verification email to the authenticated user. If the body
+// contains a different email address, the email goes there instead and the address on file stays
+// in use, and is told about the change, until the new one is confirmed.
+func (h *AuthHandler) RequestEmailVerification(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.VerifyEmailRequest
+	if c.Request.ContentLength != 0 {
+		if !validation.BindJSON(c, &req) {
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.Users.GetByID(ctx, userID)
+	if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching email for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to request verification"))
+		return
+	}
+
+	if req.Email != "" {
+		newEmail, err := normalizeEmail(req.Email)
+		if err != nil {
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidEmail, "Invalid email address"))
+			return
+		}
+		if newEmail != user.Email {
+			h.requestEmailChange(c, user, newEmail, req)
+			return
+		}
+	}
+
+	if user.Email == "" {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeEmailRequired, "No email address on file, provide one in the request"))
+		return
+	}
+	if user.EmailVerified {
+		c.JSON(http.StatusOK, gin.H{"message": "Email address is already verified"})
+		return
+	}
+
+	if err := h.sendVerificationEmail(ctx, userID, user.Email); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to send verification email"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// requestEmailChange re-authenticates the user and mails a verification link to newEmail. The
+// address changes when ConfirmEmailVerification consumes the link.
+func (h *AuthHandler) requestEmailChange(c *gin.Context, user *models.User, newEmail string, req models.VerifyEmailRequest) {
+	if !h.reauthenticateEmailChange(c, user, req.Password, req.MFACode) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	taken, err := h.Users.EmailTaken(ctx, newEmail, user.ID)
+	if err != nil {
+		log.Printf("Error checking email availability: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to request verification"))
+		return
+	}
+	// A taken address is answered like any other, so this can't be used to find out which
+	// addresses are registered. Its owner is told instead of being sent a link.
+	if taken {
+		h.notifyEmailInUse(ctx, newEmail)
+	} else if err := h.sendVerificationEmail(ctx, user.ID, newEmail); err != nil {
+		log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to send verification email"))
+		return
+	}
+	// Like lockout notices, only verified addresses are told: an unverified one may not be the user's.
+	if user.Email != "" && user.EmailVerified {
+		if err := h.Notifier.EmailChangeRequested(ctx, user.Email, user.Username, newEmail); err != nil {
+			log.Printf("Error notifying user %s of email change: %v", user.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent to the new address"})
+}
+
+// notifyEmailInUse tells the owner of email that someone tried to use it for another account,
+// where registering or changing the address pretended to succeed. As with lockout notices, only
+// verified addresses are told.
+func (h *AuthHandler) notifyEmailInUse(ctx context.Context, email string) {
+	owner, err := h.Users.GetByEmail(ctx, email)
+	if err != nil {
+		if err != repository.ErrNotFound {
+			log.Printf("Error looking up owner of a taken email address: %v", err)
+		}
+		return
+	}
+	if !owner.EmailVerified || !owner.IsActive {
+		return
+	}
+	if err := h.Notifier.EmailInUse(ctx, email, owner.Username); err != nil {
+		log.Printf("Error notifying user %s of their email address being reused: %v", owner.ID, err)
+	}
+}
+
+// reauthenticateEmailChange checks the password, and the second factor when two-factor
+// authentication is on, before an email change. Wrong guesses count against the login limits.
+func (h *AuthHandler) reauthenticateEmailChange(c *gin.Context, user *models.User, password string, factor models.MFACode) bool {
+	if password == "" {
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeIncorrectPassword, "The password is required to change the email address"))
+		return false
+	}
+
+	ctx := c.Request.Context()
+	withMFA, err := h.MFA.Enabled(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error checking MFA for user %s: %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to request verification"))
+		return false
+	}
+	if withMFA {
+		tx, ok := h.reauthenticateMFA(c, password, factor, "Failed to request verification")
+		if !ok {
+			return false
+		}
+		// Commit to spend the second factor; the change itself waits for the new address.
+		if err := tx.Commit(); err != nil {
+			log.Printf("Error committing second factor for user %s: %v", user.ID, err)
+			apierror.Abort(c, apierror.Internal("Failed to request verification"))
+			return false
+		}
+		return true
+	}
+
+	attempt, ok := h.checkLoginThrottle(c, user.Username)
+	if !ok {
+		return false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		h.recordLoginFailure(c, attempt, user)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeIncorrectPassword, "Password is incorrect"))
+		return false
+	}
+	h.recordLoginSuccess(c, attempt)
+	return true
+}
+
+// ConfirmEmailVerification consumes a verification token and marks the address as verified,
+// replacing the address on file if the token was sent for a change. Only the latest token sent
+// to the user works.
+func (h *AuthHandler) ConfirmEmailVerification(c *gin.Context) {
+	var req models.ConfirmEmailRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting email verification transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to verify email"))
+		return
+	}
+	defer tx.Rollback()
+
+	tokens := h.AccountTokens.WithTx(tx.SQL())
+	tokenHash := opaquetoken.Hash(req.Token)
+	token, err := tokens.GetEmailVerification(ctx, tokenHash)
+	if err == repository.ErrTokenNotFound || (err == nil && (token.UsedAt != nil || time.Now().After(token.ExpiresAt))) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidVerificationToken, "Invalid or expired verification token"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up verification token: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to verify email"))
+		return
+	}
+
+	userID := token.UserID
+	if err := tokens.UseEmailVerifications(ctx, userID); err != nil {
+		log.Printf("Error consuming verification token: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to verify email"))
+		return
+	}
+	users := h.Users.WithTx(tx.SQL())
+	err = users.ConfirmEmail(ctx, userID, token.Email)
+	if err == repository.ErrNotFound {
+		// The link is for a requested change; the new address replaces the old one now.
+		if err = users.SetEmail(ctx, userID, token.Email); err == nil {
+			err = users.ConfirmEmail(ctx, userID, token.Email)
+		}
+	}
+	if err == repository.ErrEmailTaken {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeEmailTaken, "Email address already in use"))
+		return
+	}
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidVerificationToken, "Invalid or expired verification token"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error marking email verified for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to verify email"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing email verification: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to verify email"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+// newVerifiedUser stores a user whose email address is verified.
+func newVerifiedUser(t *testing.T, repos *repository.Repositories, username, email string) *models.User {
+	t.Helper()
+	user := newTestUser(t, repos, username)
+	ctx := context.Background()
+	if err := repos.Users.SetEmail(ctx, user.ID, email); err != nil {
+		t.Fatalf("setting email: %v", err)
+	}
+	if err := repos.Users.ConfirmEmail(ctx, user.ID, email); err != nil {
+		t.Fatalf("confirming email: %v", err)
+	}
+	return user
+}
+
+// linkToken returns the token of a link sent by the notifier.
+func linkToken(t *testing.T, link string) string {
+	t.Helper()
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("parsing link %q: %v", link, err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestEmailChangeRequiresReauthentication(t *testing.T) {
+	tests := []struct {
+		name     string
+		withMFA  bool
+		req      models.VerifyEmailRequest
+		wantCode apierror.Code
+	}{
+		{"no password", false, models.VerifyEmailRequest{Email: "mallory@example.com"}, apierror.CodeIncorrectPassword},
+		{"wrong password", false, models.VerifyEmailRequest{Email: "mallory@example.com", Password: "wrong password"}, apierror.CodeIncorrectPassword},
+		{"no second factor", true, models.VerifyEmailRequest{Email: "mallory@example.com", Password: testPassword}, apierror.CodeSecondFactorRequired},
+		{"wrong second factor", true, models.VerifyEmailRequest{Email: "mallory@example.com", Password: testPassword, MFACode: models.MFACode{Code: "000000"}}, apierror.CodeInvalidSecondFactor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repos := newTestHandler(t)
+			notifier := &recordingNotifier{}
+			h.Notifier = notifier
+			user := newVerifiedUser(t, repos, "alice", "alice@example.com")
+			if tt.withMFA {
+				enableTOTP(t, repos, user.ID, 0)
+			}
+
+			w := serveJSON(user.ID, http.MethodPost, "/auth/verify-email/request", tt.req, h.RequestEmailVerification)
+			if w.Code < 400 || problemCode(t, w) != tt.wantCode {
+				t.Fatalf("status = %d, want code %s: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if len(notifier.verifyLinks) != 0 || len(notifier.emailChanges) != 0 {
+				t.Errorf("notifications sent: links %v, change notices %v", notifier.verifyLinks, notifier.emailChanges)
+			}
+		})
+	}
+}
+
+func TestEmailChangeKeepsOldAddressUntilConfirmed(t *testing.T) {
+	h, repos := newTestHandler(t)
+	notifier := &recordingNotifier{}
+	h.Notifier = notifier
+	user := newVerifiedUser(t, repos, "alice", "alice@example.com")
+	ctx := context.Background()
+
+	// A first request for another address, superseded by the second.
+	for _, email := range []string{"first@example.com", "alice@example.org"} {
+		w := serveJSON(user.ID, http.MethodPost, "/auth/verify-email/request", models.VerifyEmailRequest{Email: email, Password: testPassword}, h.RequestEmailVerification)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("requesting change to %s: status = %d: %s", email, w.Code, w.Body.String())
+		}
+	}
+	if len(notifier.emailChanges) != 2 || notifier.emailChanges[0] != "alice@example.com" {
+		t.Errorf("change notices = %v, want two to alice@example.com", notifier.emailChanges)
+	}
+	stored, err := repos.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("loading user: %v", err)
+	}
+	if stored.Email != "alice@example.com" || !stored.EmailVerified {
+		t.Errorf("before confirming: email = %q, verified = %v; want the old address, verified", stored.Email, stored.EmailVerified)
+	}
+
+	w := serveJSON(uuid.Nil, http.MethodPost, "/auth/verify-email/confirm", models.ConfirmEmailRequest{Token: linkToken(t, notifier.verifyLinks["first@example.com"])}, h.ConfirmEmailVerification)
+	if w.Code != http.StatusBadRequest || problemCode(t, w) != apierror.CodeInvalidVerificationToken {
+		t.Errorf("superseded link: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serveJSON(uuid.Nil, http.MethodPost, "/auth/verify-email/confirm", models.ConfirmEmailRequest{Token: linkToken(t, notifier.verifyLinks["alice@example.org"])}, h.ConfirmEmailVerification)
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirming: status = %d: %s", w.Code, w.Body.String())
+	}
+	stored, err = repos.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("loading user: %v", err)
+	}
+	if stored.Email != "alice@example.org" || !stored.EmailVerified {
+		t.Errorf("after confirming: email = %q, verified = %v; want the new address, verified", stored.Email, stored.EmailVerified)
+	}
+}
+
+func TestEmailChangeToTakenAddress(t *testing.T) {
+	h, repos := newTestHandler(t)
+	notifier := &recordingNotifier{}
+	h.Notifier = notifier
+	user := newVerifiedUser(t, repos, "alice", "alice@example.com")
+	newVerifiedUser(t, repos, "bob", "bob@example.com")
+
+	// Answered like a free address: bob is told, and no link is sent.
+	w := serveJSON(user.ID, http.MethodPost, "/auth/verify-email/request", models.VerifyEmailRequest{Email: "Bob@Example.com", Password: testPassword}, h.RequestEmailVerification)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusAccepted, w.Body.String())
+	}
+	if len(notifier.verifyLinks) != 0 {
+		t.Errorf("verification links sent: %v", notifier.verifyLinks)
+	}
+	if len(notifier.emailInUse) != 1 || notifier.emailInUse[0] != "bob@example.com" {
+		t.Errorf("in-use notices = %v, want one to bob@example.com", notifier.emailInUse)
+	}
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/social-network/internal/authservice/keys"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+const testPassword = "correct horse battery staple"
+
+// newTestHandler returns an AuthHandler on memory repositories, signing with an ephemeral key.
+func newTestHandler(t *testing.T) (*AuthHandler, *repository.Repositories) {
+	t.Helper()
+	keySet, err := keys.GenerateEphemeral()
+	if err != nil {
+		t.Fatalf("generating keys: %v", err)
+	}
+	repos := repository.NewMemoryRepositories()
+	return NewAuthHandler(repos, keySet), repos
+}
+
+// newTestUser stores an active user whose password is testPassword.
+func newTestUser(t *testing.T, repos *repository.Repositories, username string) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hashing password: %v", err)
+	}
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:           uuid.New(),
+		Username:     username,
+		PasswordHash: string(hash),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		IsActive:     true,
+	}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("creating user %s: %v", username, err)
+	}
+	return user
+}
+
+// serveJSON sends body as JSON through handler, as if AuthMiddleware had authenticated userID
+// when it isn't uuid.Nil.
+func serveJSON(userID uuid.UUID, method, path string, body interface{}, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	return serveRoute(userID, method, path, path, body, handler)
+}
+
+// serveRoute is serveJSON for a handler registered on a route with parameters.
+func serveRoute(userID uuid.UUID, method, route, path string, body interface{}, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(apierror.Middleware(), func(c *gin.Context) {
+		if userID != uuid.Nil {
+			c.Set("userID", userID)
+		}
+		c.Next()
+	})
+	router.Handle(method, route, handler)
+
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// problemCode returns the code member of a problem document.
+func problemCode(t *testing.T, w *httptest.ResponseRecorder) apierror.Code {
+	t.Helper()
+	var problem struct {
+		Code apierror.Code `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decoding problem document %q: %v", w.Body.String(), err)
+	}
+	return problem.Code
+}
+
+// recordingNotifier records the notifications handlers send.
+type recordingNotifier struct {
+	verifyLinks  map[string]string // Latest verification link by address
+	resets       []string          // Addresses sent a password reset link
+	locked       []string          // Addresses told about a lockout
+	emailChanges []string          // Addresses told about a requested email change
+	emailInUse   []string          // Addresses told someone tried to use them for another account
+}
+
+func (n *recordingNotifier) VerifyEmail(ctx context.Context, to, link string, ttl time.Duration) error {
+	if n.verifyLinks == nil {
+		n.verifyLinks = make(map[string]string)
+	}
+	n.verifyLinks[to] = link
+	return nil
+}
+
+func (n *recordingNotifier) PasswordReset(ctx context.Context, to, username, link string, ttl time.Duration) error {
+	n.resets = append(n.resets, to)
+	return nil
+}
+
+func (n *recordingNotifier) AccountLocked(ctx context.Context, to, username string, until time.Time) error {
+	n.locked = append(n.locked, to)
+	return nil
+}
+
+func (n *recordingNotifier) EmailChangeRequested(ctx context.Context, to, username, newEmail string) error {
+	n.emailChanges = append(n.emailChanges, to)
+	return nil
+}
+
+func (n *recordingNotifier) EmailInUse(ctx context.Context, to, username string) error {
+	n.emailInUse = append(n.emailInUse, to)
+	return nil
+}
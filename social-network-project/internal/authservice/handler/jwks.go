@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+)
+
+// JWKS serves the public signing keys so other services can verify access tokens
+// without sharing a secret with auth-service.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	set, err := h.Keys.JWKS()
+	if err != nil {
+		log.Printf("Error building JWKS: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to load signing keys"))
+		return
+	}
+
+	// Short cache lifetime so verifiers notice rotated keys quickly.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
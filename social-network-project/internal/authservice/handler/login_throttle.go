@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/throttle"
+)
+
+// checkLoginThrottle reserves a login attempt for the username from the client IP, which counts
+// as a failure until reported otherwise. It answers 429 with a Retry-After header when either has
+// to back off or is locked out.
+func (h *AuthHandler) checkLoginThrottle(c *gin.Context, username string) (*throttle.Attempt, bool) {
+	attempt, decision, err := h.Throttle.Reserve(c.Request.Context(), username, c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking login throttle: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return nil, false
+	}
+	if decision.Allowed() {
+		return attempt, true
+	}
+
+	message := "Too many failed login attempts, try again later"
+	if decision.Locked {
+		message = "Too many failed login attempts, logins are temporarily locked"
+	}
+	abortThrottled(c, decision, message)
+	return nil, false
+}
+
+// abortThrottled answers 429 with a Retry-After header for a request the limiter didn't allow.
+func abortThrottled(c *gin.Context, decision throttle.Decision, message string) {
+	retryAfter := int64(math.Ceil(decision.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	apierror.Abort(c, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited, message).With("retry_after", retryAfter))
+}
+
+// recordLoginFailure reports a failed attempt. When it locks the account and the user has a
+// verified email address, they are told about it. user is nil when the username doesn't exist.
+func (h *AuthHandler) recordLoginFailure(c *gin.Context, attempt *throttle.Attempt, user *models.User) {
+	locked, err := h.Throttle.Failure(c.Request.Context(), attempt)
+	if err != nil {
+		log.Printf("Error recording failed login: %v", err)
+		return
+	}
+	if !locked || user == nil {
+		return
+	}
+
+	log.Printf("Logins for user %s locked after repeated failures", user.ID)
+	if user.Email == "" || !user.EmailVerified {
+		return
+	}
+	until := time.Now().Add(h.Throttle.Username.LockoutDuration)
+	if err := h.Notifier.AccountLocked(c.Request.Context(), user.Email, user.Username, until); err != nil {
+		log.Printf("Error sending lockout notification to user %s: %v", user.ID, err)
+	}
+}
+
+// recordLoginSuccess clears the username's failed attempts once a login fully succeeded.
+func (h *AuthHandler) recordLoginSuccess(c *gin.Context, attempt *throttle.Attempt) {
+	if err := h.Throttle.Success(c.Request.Context(), attempt); err != nil {
+		log.Printf("Error resetting login throttle: %v", err)
+	}
+}
+
+// releaseLoginAttempt takes back an attempt whose password was right but that doesn't finish the
+// login, so it neither counts as a failure nor clears earlier ones.
+func (h *AuthHandler) releaseLoginAttempt(c *gin.Context, attempt *throttle.Attempt) {
+	if err := h.Throttle.Release(c.Request.Context(), attempt); err != nil {
+		log.Printf("Error releasing login attempt: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/throttle"
+)
+
+func TestLoginLockoutNotifiesVerifiedEmail(t *testing.T) {
+	tests := []struct {
+		name       string
+		verified   bool
+		wantNotice bool
+	}{
+		{"verified email", true, true},
+		{"unverified email", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repos := newTestHandler(t)
+			notifier := &recordingNotifier{}
+			h.Notifier = notifier
+			h.Throttle.Username = throttle.Policy{LockoutThreshold: 3, LockoutDuration: 15 * time.Minute, Window: time.Hour}
+			user := newTestUser(t, repos, "alice")
+			if err := repos.Users.SetEmail(context.Background(), user.ID, "alice@example.com"); err != nil {
+				t.Fatalf("setting email: %v", err)
+			}
+			if tt.verified {
+				if err := repos.Users.ConfirmEmail(context.Background(), user.ID, "alice@example.com"); err != nil {
+					t.Fatalf("confirming email: %v", err)
+				}
+			}
+
+			for i := 1; i <= 3; i++ {
+				w := serveJSON(uuid.Nil, http.MethodPost, "/auth/login", models.LoginRequest{Username: "alice", Password: "wrong password"}, h.Login)
+				if w.Code != http.StatusUnauthorized {
+					t.Fatalf("attempt %d: status = %d, want %d: %s", i, w.Code, http.StatusUnauthorized, w.Body.String())
+				}
+			}
+			if got := len(notifier.locked) == 1; got != tt.wantNotice {
+				t.Errorf("notifications = %v, want one: %v", notifier.locked, tt.wantNotice)
+			}
+
+			// Locked out: even the right password is refused.
+			w := serveJSON(uuid.Nil, http.MethodPost, "/auth/login", models.LoginRequest{Username: "alice", Password: testPassword}, h.Login)
+			if w.Code != http.StatusTooManyRequests || problemCode(t, w) != apierror.CodeRateLimited || w.Header().Get("Retry-After") != "900" {
+				t.Errorf("locked login: status = %d, Retry-After = %q: %s", w.Code, w.Header().Get("Retry-After"), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLoginSuccessClearsFailures(t *testing.T) {
+	h, repos := newTestHandler(t)
+	newTestUser(t, repos, "alice")
+
+	w := serveJSON(uuid.Nil, http.MethodPost, "/auth/login", models.LoginRequest{Username: "alice", Password: "wrong password"}, h.Login)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+	state, err := repos.LoginThrottle.Get(context.Background(), throttle.UsernameKey("alice"))
+	if err != nil || state.Failures != 1 {
+		t.Fatalf("failures after a wrong password = %d, %v; want 1", state.Failures, err)
+	}
+
+	w = serveJSON(uuid.Nil, http.MethodPost, "/auth/login", models.LoginRequest{Username: "Alice", Password: testPassword}, h.Login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	state, err = repos.LoginThrottle.Get(context.Background(), throttle.UsernameKey("alice"))
+	if err != nil || state.Failures != 0 {
+		t.Errorf("failures after logging in = %d, %v; want 0", state.Failures, err)
+	}
+}
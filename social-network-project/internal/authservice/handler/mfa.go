@@ -0,0 +1,434 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/social-network/internal/authservice/totp"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// DefaultMFAChallengeTTL is how long a user has to enter their second factor after the password check.
+const DefaultMFAChallengeTTL = 5 * time.Minute
+
+// DefaultTOTPIssuer is the account issuer shown in authenticator apps.
+const DefaultTOTPIssuer = "Social Network"
+
+const (
+	// maxMFAAttempts is how many wrong codes a login challenge accepts before it stops working.
+	maxMFAAttempts = 5
+	// recoveryCodeCount is how many recovery codes are handed out at a time.
+	recoveryCodeCount = 10
+)
+
+var errInvalidSecondFactor = errors.New("invalid second factor")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// createMFAChallenge stores a single-use challenge for a user who passed the password check.
+// Only its hash is kept; the raw token goes back to the client for /auth/login/mfa.
+func (h *AuthHandler) createMFAChallenge(ctx context.Context, userID uuid.UUID, deviceName string) (*models.MFAChallengeResponse, error) {
+	token, tokenHash, err := opaquetoken.New()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = h.MFA.CreateChallenge(ctx, &repository.MFAChallenge{
+		TokenHash:  tokenHash,
+		UserID:     userID,
+		DeviceName: deviceName,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(h.MFAChallengeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int64(h.MFAChallengeTTL.Seconds()),
+		Methods:     []string{models.MFAMethodTOTP, models.MFAMethodRecoveryCode},
+	}, nil
+}
+
+// normalizeRecoveryCode makes recovery codes comparable regardless of case, spaces or dashes.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and stores a new set, returning
+// the plaintext codes. Like other opaque tokens only their hashes are stored.
+func replaceRecoveryCodes(ctx context.Context, mfa repository.MFARepository, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := 0; i < recoveryCodeCount; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))[:10]
+		hashes = append(hashes, opaquetoken.Hash(raw))
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+	}
+	if err := mfa.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor checks an authenticator code or consumes a recovery code. mfa must be bound
+// to a transaction: the TOTP secret is locked so the same code can't be used twice, even concurrently.
+func verifySecondFactor(ctx context.Context, mfa repository.MFARepository, userID uuid.UUID, factor models.MFACode) error {
+	switch {
+	case factor.Code != "" && factor.RecoveryCode == "":
+		secret, err := mfa.GetTOTP(ctx, userID)
+		if err == repository.ErrMFANotFound || (err == nil && !secret.Confirmed) {
+			return errInvalidSecondFactor
+		}
+		if err != nil {
+			return err
+		}
+		step, ok := totp.Validate(secret.Secret, factor.Code, time.Now())
+		if !ok || step <= secret.LastUsedStep {
+			return errInvalidSecondFactor
+		}
+		return mfa.SetLastUsedStep(ctx, userID, step)
+
+	case factor.RecoveryCode != "" && factor.Code == "":
+		err := mfa.UseRecoveryCode(ctx, userID, opaquetoken.Hash(normalizeRecoveryCode(factor.RecoveryCode)))
+		if err == repository.ErrRecoveryCodeNotFound {
+			return errInvalidSecondFactor
+		}
+		return err
+
+	default:
+		return errInvalidSecondFactor
+	}
+}
+
+// LoginMFA completes a two-step login: it exchanges the challenge token from /auth/login plus a
+// second factor for the usual access and refresh tokens.
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var req models.MFALoginRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeSecondFactorRequired, "Provide either code or recovery_code"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting MFA login transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+	defer tx.Rollback()
+
+	mfa := h.MFA.WithTx(tx.SQL())
+	tokenHash := opaquetoken.Hash(req.MFAToken)
+	challenge, err := mfa.GetChallenge(ctx, tokenHash)
+	if err == repository.ErrChallengeNotFound || (err == nil && (challenge.UsedAt != nil || challenge.Attempts >= maxMFAAttempts || time.Now().After(challenge.ExpiresAt))) {
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidMFAToken, "Invalid or expired MFA token, please log in again"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up MFA challenge: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+	userID := challenge.UserID
+
+	// Re-read the user: the account may have been deactivated or flagged since the password check.
+	user, err := h.Users.WithTx(tx.SQL()).GetByID(ctx, userID)
+	if err == repository.ErrNotFound || (err == nil && (!user.IsActive || user.PasswordResetRequired)) {
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidMFAToken, "Invalid or expired MFA token, please log in again"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user %s for MFA login: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+
+	// Second-factor guesses count against the same per-username limit as passwords, otherwise
+	// someone who knows the password could keep starting new challenges.
+	attempt, ok := h.checkLoginThrottle(c, user.Username)
+	if !ok {
+		return
+	}
+
+	err = verifySecondFactor(ctx, mfa, userID, req.MFACode)
+	if err == errInvalidSecondFactor {
+		h.recordLoginFailure(c, attempt, user)
+		// Count the failure; the challenge stops working after maxMFAAttempts.
+		if err := mfa.RecordChallengeFailure(ctx, tokenHash); err != nil {
+			log.Printf("Error recording failed MFA attempt for user %s: %v", userID, err)
+		} else if err := tx.Commit(); err != nil {
+			log.Printf("Error committing failed MFA attempt for user %s: %v", userID, err)
+		}
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidSecondFactor, "Invalid two-factor code"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error verifying second factor for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+
+	if err := mfa.UseChallenge(ctx, tokenHash); err != nil {
+		log.Printf("Error consuming MFA challenge for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing MFA login for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+	h.recordLoginSuccess(c, attempt)
+
+	resp, err := h.issueTokens(ctx, user, deviceFromRequest(c, challenge.DeviceName))
+	if err != nil {
+		log.Printf("Error generating tokens: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetMFAStatus reports whether the authenticated user has two-factor authentication enabled.
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	status, err := h.MFA.Status(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching MFA status for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch two-factor status"))
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// EnrollTOTP generates a new TOTP secret for the authenticated user. It isn't used for logins
+// until ConfirmTOTP receives a valid code from it; enrolling again replaces an unconfirmed secret.
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	username := c.GetString("username")
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Printf("Error generating TOTP secret: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to start two-factor enrollment"))
+		return
+	}
+
+	err = h.MFA.SetPendingTOTP(c.Request.Context(), userID, secret)
+	if err == repository.ErrMFAEnabled {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeMFAAlreadyEnabled, "Two-factor authentication is already enabled"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing TOTP secret for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to start two-factor enrollment"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TOTPEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(h.TOTPIssuer, username, secret),
+	})
+}
+
+// ConfirmTOTP enables TOTP once the user proves their authenticator app produces valid codes,
+// and returns the recovery codes. They are only shown here and on regeneration.
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.TOTPConfirmRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting TOTP confirmation transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to enable two-factor authentication"))
+		return
+	}
+	defer tx.Rollback()
+
+	mfa := h.MFA.WithTx(tx.SQL())
+	secret, err := mfa.GetTOTP(ctx, userID)
+	if err == repository.ErrMFANotFound || (err == nil && secret.Confirmed) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeMFANoPendingEnrollment, "No pending two-factor enrollment"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching pending TOTP secret for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to enable two-factor authentication"))
+		return
+	}
+
+	step, ok := totp.Validate(secret.Secret, req.Code, time.Now())
+	if !ok {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidSecondFactor, "Invalid two-factor code"))
+		return
+	}
+
+	if err := mfa.ConfirmTOTP(ctx, userID, step); err != nil {
+		log.Printf("Error confirming TOTP for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to enable two-factor authentication"))
+		return
+	}
+	codes, err := replaceRecoveryCodes(ctx, mfa, userID)
+	if err != nil {
+		log.Printf("Error generating recovery codes for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to enable two-factor authentication"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing TOTP confirmation for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to enable two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// reauthenticateMFA checks the password and second factor sent to change the authenticated user's
+// two-factor setup, and returns the transaction the second factor was consumed in for the change
+// to be made in. Wrong guesses count against the same per-username limit as logins, so a stolen
+// session can't be used to brute-force the password or codes. failure is the detail of the 500
+// answered on unexpected errors. The caller must roll the transaction back or commit it.
+func (h *AuthHandler) reauthenticateMFA(c *gin.Context, password string, factor models.MFACode, failure string) (repository.Tx, bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	if (factor.Code == "") == (factor.RecoveryCode == "") {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeSecondFactorRequired, "Provide either code or recovery_code"))
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.Users.GetByID(ctx, userID)
+	if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error fetching user %s to change MFA: %v", userID, err)
+		apierror.Abort(c, apierror.Internal(failure))
+		return nil, false
+	}
+	attempt, ok := h.checkLoginThrottle(c, user.Username)
+	if !ok {
+		return nil, false
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		h.recordLoginFailure(c, attempt, user)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeIncorrectPassword, "Password is incorrect"))
+		return nil, false
+	}
+
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting MFA change transaction for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal(failure))
+		return nil, false
+	}
+	err = verifySecondFactor(ctx, h.MFA.WithTx(tx.SQL()), userID, factor)
+	if err == errInvalidSecondFactor {
+		tx.Rollback()
+		h.recordLoginFailure(c, attempt, user)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidSecondFactor, "Invalid two-factor code"))
+		return nil, false
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error verifying second factor for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal(failure))
+		return nil, false
+	}
+	h.recordLoginSuccess(c, attempt)
+	return tx, true
+}
+
+// DisableTOTP turns two-factor authentication off after checking the password and a second factor.
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.DisableMFARequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	tx, ok := h.reauthenticateMFA(c, req.Password, req.MFACode, "Failed to disable two-factor authentication")
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	if err := h.MFA.WithTx(tx.SQL()).Disable(c.Request.Context(), userID); err != nil {
+		log.Printf("Error disabling MFA for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to disable two-factor authentication"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing MFA disable for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to disable two-factor authentication"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking the password and a second factor.
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.RegenerateRecoveryCodesRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	tx, ok := h.reauthenticateMFA(c, req.Password, req.MFACode, "Failed to regenerate recovery codes")
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	codes, err := replaceRecoveryCodes(c.Request.Context(), h.MFA.WithTx(tx.SQL()), userID)
+	if err != nil {
+		log.Printf("Error generating recovery codes for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to regenerate recovery codes"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing recovery codes for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to regenerate recovery codes"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/internal/authservice/totp"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+// enableTOTP turns TOTP on for the user as if they had confirmed it at step lastUsed, and returns the secret.
+func enableTOTP(t *testing.T, repos *repository.Repositories, userID uuid.UUID, lastUsed int64) string {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generating secret: %v", err)
+	}
+	ctx := context.Background()
+	if err := repos.MFA.SetPendingTOTP(ctx, userID, secret); err != nil {
+		t.Fatalf("storing secret: %v", err)
+	}
+	if err := repos.MFA.ConfirmTOTP(ctx, userID, lastUsed); err != nil {
+		t.Fatalf("confirming secret: %v", err)
+	}
+	return secret
+}
+
+// totpCode returns the code of secret at step.
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, step)
+	if err != nil {
+		t.Fatalf("computing code: %v", err)
+	}
+	return code
+}
+
+func TestVerifySecondFactorRejectsReplayedSteps(t *testing.T) {
+	ctx := context.Background()
+	_, repos := newTestHandler(t)
+	user := newTestUser(t, repos, "alice")
+	current := totp.Step(time.Now())
+	secret := enableTOTP(t, repos, user.ID, current-totp.Skew-1)
+
+	if err := verifySecondFactor(ctx, repos.MFA, user.ID, models.MFACode{Code: totpCode(t, secret, current)}); err != nil {
+		t.Fatalf("current code rejected: %v", err)
+	}
+	stored, err := repos.MFA.GetTOTP(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("loading secret: %v", err)
+	}
+	if stored.LastUsedStep != current {
+		t.Errorf("last_used_step = %d, want %d", stored.LastUsedStep, current)
+	}
+
+	// The same code, and an older one still inside the skew window, must not work again.
+	for _, step := range []int64{current, current - 1} {
+		err := verifySecondFactor(ctx, repos.MFA, user.ID, models.MFACode{Code: totpCode(t, secret, step)})
+		if err != errInvalidSecondFactor {
+			t.Errorf("code of step %d after using step %d: err = %v, want errInvalidSecondFactor", step, current, err)
+		}
+	}
+	if err := verifySecondFactor(ctx, repos.MFA, user.ID, models.MFACode{Code: totpCode(t, secret, current+1)}); err != nil {
+		t.Errorf("code of the next step rejected: %v", err)
+	}
+}
+
+func TestDisableTOTPIsThrottled(t *testing.T) {
+	h, repos := newTestHandler(t)
+	now := time.Now()
+	h.Throttle.Now = func() time.Time { return now }
+	user := newTestUser(t, repos, "alice")
+	secret := enableTOTP(t, repos, user.ID, 0)
+	current := totp.Step(now)
+	wrong := totpCode(t, secret, current+5)
+
+	for i := 0; i <= h.Throttle.Username.FreeAttempts; i++ {
+		w := serveJSON(user.ID, http.MethodDelete, "/auth/mfa/totp", models.DisableMFARequest{Password: testPassword, MFACode: models.MFACode{Code: wrong}}, h.DisableTOTP)
+		if w.Code != http.StatusUnauthorized || problemCode(t, w) != apierror.CodeInvalidSecondFactor {
+			t.Fatalf("attempt %d: status = %d, want %d: %s", i+1, w.Code, http.StatusUnauthorized, w.Body.String())
+		}
+	}
+
+	w := serveJSON(user.ID, http.MethodDelete, "/auth/mfa/totp", models.DisableMFARequest{Password: testPassword, MFACode: models.MFACode{Code: totpCode(t, secret, current)}}, h.DisableTOTP)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusTooManyRequests, w.Body.String())
+	}
+	enabled, err := repos.MFA.Enabled(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("checking MFA: %v", err)
+	}
+	if !enabled {
+		t.Error("TOTP was disabled while throttled")
+	}
+}
+
+func TestRegenerateRecoveryCodesRequiresPassword(t *testing.T) {
+	h, repos := newTestHandler(t)
+	user := newTestUser(t, repos, "alice")
+	secret := enableTOTP(t, repos, user.ID, 0)
+	current := totp.Step(time.Now())
+
+	w := serveJSON(user.ID, http.MethodPost, "/auth/mfa/recovery-codes", models.MFACode{Code: totpCode(t, secret, current)}, h.RegenerateRecoveryCodes)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("without password: status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+
+	w = serveJSON(user.ID, http.MethodPost, "/auth/mfa/recovery-codes", models.RegenerateRecoveryCodesRequest{Password: "wrong password", MFACode: models.MFACode{Code: totpCode(t, secret, current)}}, h.RegenerateRecoveryCodes)
+	if w.Code != http.StatusUnauthorized || problemCode(t, w) != apierror.CodeIncorrectPassword {
+		t.Errorf("wrong password: status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+
+	w = serveJSON(user.ID, http.MethodPost, "/auth/mfa/recovery-codes", models.RegenerateRecoveryCodesRequest{Password: testPassword, MFACode: models.MFACode{Code: totpCode(t, secret, current)}}, h.RegenerateRecoveryCodes)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp models.RecoveryCodesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(resp.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(resp.RecoveryCodes), recoveryCodeCount)
+	}
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// validRedirectURI accepts https URLs, http only on the loopback interface (native apps, local
+// development), and private-use schemes such as com.example.app:/callback (RFC 8252 section 7.1).
+// Fragments are never allowed since the code is appended as a query parameter.
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Fragment != "" || u.Opaque != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return u.Host != ""
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return strings.Contains(u.Scheme, ".")
+	}
+}
+
+// containsAll reports whether every value in want is in have.
+func containsAll(have, want []string) bool {
+	for _, v := range want {
+		if !slices.Contains(have, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// revokeClientGrants revokes the sessions and refresh tokens issued to a client and forgets the
+// consent given to it, for one user when userID is set or for every user otherwise.
+func revokeClientGrants(ctx context.Context, sessions repository.SessionRepository, oauth repository.OAuthRepository, clientID string, userID *uuid.UUID) error {
+	if err := sessions.RevokeClient(ctx, clientID, userID); err != nil {
+		return err
+	}
+	return oauth.DeleteConsents(ctx, clientID, userID)
+}
+
+// RegisterOAuthClient registers a third-party application owned by the authenticated user.
+// Confidential clients get a secret, which is only returned in this response.
+func (h *AuthHandler) RegisterOAuthClient(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.RegisterOAuthClientRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	if len(req.GrantTypes) == 0 {
+		req.GrantTypes = []string{models.GrantAuthorizationCode, models.GrantRefreshToken}
+	}
+	for _, grantType := range req.GrantTypes {
+		switch grantType {
+		case models.GrantAuthorizationCode, models.GrantRefreshToken:
+		case models.GrantClientCredentials:
+			if !req.Confidential {
+				apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidGrantTypes, "The client_credentials grant requires a confidential client"))
+				return
+			}
+		default:
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidGrantTypes, "Unsupported grant type: "+grantType))
+			return
+		}
+	}
+	if slices.Contains(req.GrantTypes, models.GrantRefreshToken) && !slices.Contains(req.GrantTypes, models.GrantAuthorizationCode) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidGrantTypes, "The refresh_token grant requires the authorization_code grant"))
+		return
+	}
+	if slices.Contains(req.GrantTypes, models.GrantAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRedirectURI, "At least one redirect URI is required"))
+		return
+	}
+	for _, redirectURI := range req.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRedirectURI, "Invalid redirect URI: "+redirectURI))
+			return
+		}
+	}
+	if !containsAll(models.OAuthScopes, req.Scopes) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeUnknownScope, "Unknown scope").With("allowed_scopes", models.OAuthScopes))
+		return
+	}
+	// Anyone can register a client, and client_credentials tokens need no user's consent, so they
+	// are kept to reading what the client's owner could read anyway.
+	if slices.Contains(req.GrantTypes, models.GrantClientCredentials) && !containsAll(models.ClientCredentialsScopes, req.Scopes) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeScopeNotAllowed, "Scope not allowed with the client_credentials grant").With("allowed_scopes", models.ClientCredentialsScopes))
+		return
+	}
+
+	resp := models.RegisterOAuthClientResponse{
+		OAuthClient: models.OAuthClient{
+			ID:           uuid.NewString(),
+			Name:         req.Name,
+			Confidential: req.Confidential,
+			RedirectURIs: req.RedirectURIs,
+			Scopes:       req.Scopes,
+			GrantTypes:   req.GrantTypes,
+			CreatedAt:    time.Now().UTC(),
+		},
+	}
+	if resp.RedirectURIs == nil {
+		resp.RedirectURIs = []string{}
+	}
+	var secretHash string
+	if req.Confidential {
+		secret, hash, err := opaquetoken.New()
+		if err != nil {
+			log.Printf("Error generating OAuth client secret: %v", err)
+			apierror.Abort(c, apierror.Internal("Failed to register client"))
+			return
+		}
+		resp.ClientSecret = secret
+		secretHash = hash
+	}
+
+	if err := h.OAuth.CreateClient(c.Request.Context(), &resp.OAuthClient, secretHash, userID); err != nil {
+		log.Printf("Error registering OAuth client for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to register client"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListOAuthClients returns the clients registered by the authenticated user.
+func (h *AuthHandler) ListOAuthClients(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	clients, err := h.OAuth.ListClients(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing OAuth clients for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list clients"))
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// DeleteOAuthClient deletes one of the authenticated user's clients and revokes every token issued to it.
+func (h *AuthHandler) DeleteOAuthClient(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	clientID := c.Param("clientId")
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting OAuth client deletion transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to delete client"))
+		return
+	}
+	defer tx.Rollback()
+
+	oauth := h.OAuth.WithTx(tx.SQL())
+	err = oauth.DeleteClient(ctx, clientID, userID)
+	if err == repository.ErrClientNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeClientNotFound, "Client not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting OAuth client %s: %v", clientID, err)
+		apierror.Abort(c, apierror.Internal("Failed to delete client"))
+		return
+	}
+	if err := revokeClientGrants(ctx, h.Sessions.WithTx(tx.SQL()), oauth, clientID, nil); err != nil {
+		log.Printf("Error revoking grants of OAuth client %s: %v", clientID, err)
+		apierror.Abort(c, apierror.Internal("Failed to delete client"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing OAuth client deletion %s: %v", clientID, err)
+		apierror.Abort(c, apierror.Internal("Failed to delete client"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Client deleted"})
+}
+
+// ListOAuthConsents returns the applications the authenticated user has authorized.
+func (h *AuthHandler) ListOAuthConsents(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	consents, err := h.OAuth.ListConsents(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing OAuth consents for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list authorized applications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, consents)
+}
+
+// RevokeOAuthConsent withdraws the authenticated user's consent for an application and revokes
+// the tokens it holds for them. The application has to ask for consent again.
+func (h *AuthHandler) RevokeOAuthConsent(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	clientID := c.Param("clientId")
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting consent revocation transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to revoke access"))
+		return
+	}
+	defer tx.Rollback()
+
+	oauth := h.OAuth.WithTx(tx.SQL())
+	_, err = oauth.GetConsent(ctx, userID, clientID)
+	if err == repository.ErrConsentNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeConsentNotFound, "Application not authorized"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up consent of user %s for client %s: %v", userID, clientID, err)
+		apierror.Abort(c, apierror.Internal("Failed to revoke access"))
+		return
+	}
+	if err := revokeClientGrants(ctx, h.Sessions.WithTx(tx.SQL()), oauth, clientID, &userID); err != nil {
+		log.Printf("Error revoking access of client %s for user %s: %v", clientID, userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to revoke access"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing consent revocation for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to revoke access"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked"})
+}
@@ -0,0 +1,610 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/authmw"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// DefaultOAuthCodeTTL is how long a client has to exchange an authorization code. RFC 6749 recommends at most 10 minutes.
+const DefaultOAuthCodeTTL = time.Minute
+
+// oauthError writes an OAuth 2.0 error response (RFC 6749 section 5.2). The token, introspection and
+// revocation endpoints use it instead of apierror because OAuth client libraries expect this format.
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// authenticateClient identifies the client calling /oauth/token, /oauth/introspect or /oauth/revoke,
+// with HTTP Basic or client_id/client_secret form fields (RFC 6749 section 2.3.1).
+// Public clients only send their client_id.
+func (h *AuthHandler) authenticateClient(c *gin.Context) (*models.OAuthClient, bool) {
+	clientID, secret, basic := c.Request.BasicAuth()
+	if basic {
+		// Basic credentials are form-urlencoded before being base64 encoded.
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	unauthorized := func() {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+	}
+	if clientID == "" {
+		unauthorized()
+		return nil, false
+	}
+
+	client, secretHash, err := h.OAuth.GetClient(c.Request.Context(), clientID)
+	if err == repository.ErrClientNotFound {
+		unauthorized()
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error loading OAuth client %s: %v", clientID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to authenticate client")
+		return nil, false
+	}
+	if client.Confidential {
+		if secret == "" || subtle.ConstantTimeCompare([]byte(opaquetoken.Hash(secret)), []byte(secretHash)) != 1 {
+			unauthorized()
+			return nil, false
+		}
+	} else if secret != "" {
+		unauthorized()
+		return nil, false
+	}
+	return client, true
+}
+
+// requestedScopes parses a space-delimited scope parameter, defaulting to every scope the client is
+// registered for. It fails if any scope is outside allowed.
+func requestedScopes(raw string, allowed []string) ([]string, bool) {
+	if strings.TrimSpace(raw) == "" {
+		return slices.Clone(allowed), true
+	}
+	var scopes []string
+	for _, scope := range strings.Fields(raw) {
+		if !slices.Contains(allowed, scope) {
+			return nil, false
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, true
+}
+
+// verifyPKCE checks a code_verifier against the S256 code_challenge sent with the authorization request (RFC 7636).
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+// authorizeRedirect appends params to the client's redirect URI, keeping any query it already has.
+func authorizeRedirect(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// authorizeRedirectURI returns where to send the user back to: the redirect URI in the request, or
+// the client's only registered one when the request leaves it out.
+func authorizeRedirectURI(client *models.OAuthClient, req *models.OAuthAuthorizeRequest) string {
+	if req.RedirectURI == "" && len(client.RedirectURIs) == 1 {
+		return client.RedirectURIs[0]
+	}
+	return req.RedirectURI
+}
+
+// respondAuthorizeError reports an authorization error to the client through its redirect URI (RFC 6749 section 4.1.2.1).
+func respondAuthorizeError(c *gin.Context, client *models.OAuthClient, req *models.OAuthAuthorizeRequest, code, description string) {
+	params := url.Values{"error": {code}, "error_description": {description}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	c.JSON(http.StatusOK, models.OAuthRedirect{RedirectTo: authorizeRedirect(authorizeRedirectURI(client, req), params)})
+}
+
+// validateAuthorizeRequest checks an authorization request and returns the client and the scopes requested.
+// An unknown client or redirect URI gets a plain 400, since the user must never be sent to an
+// unregistered redirect URI; every other problem is reported to the client through the redirect URI.
+func (h *AuthHandler) validateAuthorizeRequest(c *gin.Context, req *models.OAuthAuthorizeRequest) (*models.OAuthClient, []string, bool) {
+	client, _, err := h.OAuth.GetClient(c.Request.Context(), req.ClientID)
+	if err == repository.ErrClientNotFound || (err == nil && !slices.Contains(client.GrantTypes, models.GrantAuthorizationCode)) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeUnknownClient, "Unknown client"))
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("Error loading OAuth client %s: %v", req.ClientID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process authorization request"))
+		return nil, nil, false
+	}
+	// The redirect URI may only be left out when the client registered exactly one.
+	if !slices.Contains(client.RedirectURIs, authorizeRedirectURI(client, req)) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidRedirectURI, "Redirect URI is not registered for this client"))
+		return nil, nil, false
+	}
+
+	if req.ResponseType != "code" {
+		respondAuthorizeError(c, client, req, "unsupported_response_type", "Only the code response type is supported")
+		return nil, nil, false
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		respondAuthorizeError(c, client, req, "invalid_request", "PKCE with the S256 method is required")
+		return nil, nil, false
+	}
+	scopes, ok := requestedScopes(req.Scope, client.Scopes)
+	if !ok {
+		respondAuthorizeError(c, client, req, "invalid_scope", "The requested scope is not allowed for this client")
+		return nil, nil, false
+	}
+	return client, scopes, true
+}
+
+// issueAuthorizationCode stores a single-use code for the user and answers with the redirect that delivers it.
+func (h *AuthHandler) issueAuthorizationCode(c *gin.Context, client *models.OAuthClient, req *models.OAuthAuthorizeRequest, userID uuid.UUID, scopes []string) {
+	code, codeHash, err := opaquetoken.New()
+	if err != nil {
+		log.Printf("Error generating authorization code: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process authorization request"))
+		return
+	}
+
+	now := time.Now().UTC()
+	err = h.OAuth.CreateCode(c.Request.Context(), &repository.AuthorizationCode{
+		CodeHash:      codeHash,
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: req.CodeChallenge,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(h.OAuthCodeTTL),
+	})
+	if err != nil {
+		log.Printf("Error storing authorization code for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process authorization request"))
+		return
+	}
+
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	c.JSON(http.StatusOK, models.OAuthRedirect{RedirectTo: authorizeRedirect(authorizeRedirectURI(client, req), params)})
+}
+
+// Authorize is called by our consent page with the parameters a third-party client sent the user
+// there with. If the user already consented to the requested scopes it answers with the redirect
+// carrying the authorization code; otherwise with a prompt to show, answered with ApproveAuthorization.
+func (h *AuthHandler) Authorize(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.OAuthAuthorizeRequest
+	if !validation.BindQuery(c, &req) {
+		return
+	}
+	client, scopes, ok := h.validateAuthorizeRequest(c, &req)
+	if !ok {
+		return
+	}
+
+	granted, err := h.OAuth.GetConsent(c.Request.Context(), userID, client.ID)
+	if err != nil && err != repository.ErrConsentNotFound {
+		log.Printf("Error looking up consent of user %s for client %s: %v", userID, client.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process authorization request"))
+		return
+	}
+	if err == nil && containsAll(granted, scopes) {
+		h.issueAuthorizationCode(c, client, &req, userID, scopes)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OAuthConsentPrompt{
+		ConsentRequired: true,
+		ClientID:        client.ID,
+		ClientName:      client.Name,
+		Scopes:          scopes,
+	})
+}
+
+// ApproveAuthorization records the user's answer to a consent prompt. Either way it answers with the
+// redirect to send the user back to the client: with an authorization code, or with access_denied.
+func (h *AuthHandler) ApproveAuthorization(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.OAuthConsentRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	client, scopes, ok := h.validateAuthorizeRequest(c, &req.OAuthAuthorizeRequest)
+	if !ok {
+		return
+	}
+	if !req.Approve {
+		respondAuthorizeError(c, client, &req.OAuthAuthorizeRequest, "access_denied", "The user denied the request")
+		return
+	}
+
+	// Consent accumulates, so a client asking for fewer scopes later doesn't prompt again.
+	now := time.Now().UTC()
+	if err := h.OAuth.AddConsent(c.Request.Context(), userID, client.ID, scopes, now); err != nil {
+		log.Printf("Error storing consent of user %s for client %s: %v", userID, client.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process authorization request"))
+		return
+	}
+
+	h.issueAuthorizationCode(c, client, &req.OAuthAuthorizeRequest, userID, scopes)
+}
+
+// respondOAuthTokens writes a token response (RFC 6749 section 5.1).
+func respondOAuthTokens(c *gin.Context, accessToken, refreshToken string, expiresIn int64, scope string) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, models.OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    expiresIn,
+		RefreshToken: refreshToken,
+		Scope:        scope,
+	})
+}
+
+// Token is the OAuth 2.0 token endpoint. It takes form-encoded requests for the authorization_code
+// (with PKCE), refresh_token and client_credentials grants.
+func (h *AuthHandler) Token(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	grantType := c.PostForm("grant_type")
+	switch grantType {
+	case models.GrantAuthorizationCode, models.GrantRefreshToken, models.GrantClientCredentials:
+		if !slices.Contains(client.GrantTypes, grantType) {
+			oauthError(c, http.StatusBadRequest, "unauthorized_client", "The client is not allowed to use this grant type")
+			return
+		}
+	default:
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Unsupported grant type")
+		return
+	}
+
+	switch grantType {
+	case models.GrantAuthorizationCode:
+		h.exchangeAuthorizationCode(c, client)
+	case models.GrantRefreshToken:
+		h.refreshClientTokens(c, client)
+	case models.GrantClientCredentials:
+		h.issueClientCredentials(c, client)
+	}
+}
+
+// exchangeAuthorizationCode redeems a code for a session scoped to the client. A code presented a
+// second time was probably intercepted, so the session issued for it is revoked (RFC 6749 section 4.1.2).
+func (h *AuthHandler) exchangeAuthorizationCode(c *gin.Context, client *models.OAuthClient) {
+	code, redirectURI, verifier := c.PostForm("code"), c.PostForm("redirect_uri"), c.PostForm("code_verifier")
+	if code == "" || verifier == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "code and code_verifier are required")
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting authorization code transaction: %v", err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+	defer tx.Rollback()
+
+	oauth := h.OAuth.WithTx(tx.SQL())
+	codeHash := opaquetoken.Hash(code)
+	authCode, err := oauth.GetCode(ctx, codeHash)
+	if err == repository.ErrCodeNotFound {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code")
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up authorization code: %v", err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+	userID := authCode.UserID
+	if authCode.UsedAt != nil {
+		if sessionID := authCode.SessionID; sessionID != nil {
+			log.Printf("Authorization code reuse detected for user %s (session %s), revoking session", userID, *sessionID)
+			if err := revokeSession(ctx, h.Sessions.WithTx(tx.SQL()), userID, *sessionID); err != nil {
+				log.Printf("Error revoking session %s: %v", *sessionID, err)
+			} else if err := tx.Commit(); err != nil {
+				log.Printf("Error committing revocation of session %s: %v", *sessionID, err)
+			}
+		}
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code")
+		return
+	}
+	// redirect_uri must match if the authorization request included it (RFC 6749 section 4.1.3).
+	redirectMismatch := authCode.RedirectURI != "" && authCode.RedirectURI != redirectURI
+	if authCode.ClientID != client.ID || redirectMismatch || time.Now().After(authCode.ExpiresAt) || !verifyPKCE(verifier, authCode.CodeChallenge) {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code")
+		return
+	}
+
+	// The account may have been deactivated or flagged since the user consented.
+	user, err := h.Users.WithTx(tx.SQL()).GetByID(ctx, userID)
+	if err == repository.ErrNotFound || (err == nil && (!user.IsActive || user.PasswordResetRequired)) {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Invalid or expired authorization code")
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user %s for authorization code: %v", userID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+
+	grant := &clientGrant{ClientID: client.ID, Scope: authCode.Scope}
+	resp, newSessionID, err := h.issueSessionTokens(ctx, tx, user, deviceFromRequest(c, client.Name), grant, slices.Contains(client.GrantTypes, models.GrantRefreshToken))
+	if err != nil {
+		log.Printf("Error issuing tokens to client %s for user %s: %v", client.ID, userID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+	if err := oauth.UseCode(ctx, codeHash, newSessionID); err != nil {
+		log.Printf("Error consuming authorization code for user %s: %v", userID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing authorization code exchange for user %s: %v", userID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+
+	respondOAuthTokens(c, resp.Token, resp.RefreshToken, resp.ExpiresIn, authCode.Scope)
+}
+
+// refreshClientTokens rotates a refresh token issued to the client. The new tokens keep the
+// originally granted scope.
+func (h *AuthHandler) refreshClientTokens(c *gin.Context, client *models.OAuthClient) {
+	refreshToken := c.PostForm("refresh_token")
+	if refreshToken == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "refresh_token is required")
+		return
+	}
+
+	resp, err := h.rotateRefreshToken(c.Request.Context(), refreshToken, client.ID)
+	if err == errInvalidRefreshToken {
+		oauthError(c, http.StatusBadRequest, "invalid_grant", "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		log.Printf("Error refreshing tokens for client %s: %v", client.ID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+
+	respondOAuthTokens(c, resp.Token, resp.RefreshToken, resp.ExpiresIn, "")
+}
+
+// issueClientCredentials signs an access token for the client itself, with no user behind it.
+// Such tokens have no session or refresh token; their jti is recorded in oauth_client_tokens so
+// they can be revoked and disappear when the client is deleted. They never carry scopes outside
+// models.ClientCredentialsScopes, even for clients registered before that list existed.
+func (h *AuthHandler) issueClientCredentials(c *gin.Context, client *models.OAuthClient) {
+	allowed := slices.DeleteFunc(slices.Clone(client.Scopes), func(scope string) bool {
+		return !slices.Contains(models.ClientCredentialsScopes, scope)
+	})
+	scopes, ok := requestedScopes(c.PostForm("scope"), allowed)
+	if !ok || len(scopes) == 0 {
+		oauthError(c, http.StatusBadRequest, "invalid_scope", "The requested scope is not allowed for this client")
+		return
+	}
+	scope := strings.Join(scopes, " ")
+
+	now := time.Now()
+	expiresAt := now.Add(h.AccessTokenTTL)
+	jti := uuid.New()
+	if err := h.Sessions.AddClientToken(c.Request.Context(), jti, client.ID, now, expiresAt); err != nil {
+		log.Printf("Error recording client token for client %s: %v", client.ID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+
+	accessToken, err := h.Keys.Sign(models.AuthTokenClaims{
+		Scope:    scope,
+		ClientID: client.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti.String(),
+			Subject:   client.ID,
+			Issuer:    h.Issuer,
+			Audience:  jwt.ClaimStrings{h.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		log.Printf("Error signing client token for client %s: %v", client.ID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue tokens")
+		return
+	}
+
+	respondOAuthTokens(c, accessToken, "", int64(h.AccessTokenTTL.Seconds()), scope)
+}
+
+// isJWT tells our signed access tokens apart from opaque refresh tokens, which contain no dots.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// introspect describes a token if it is active and was issued to clientID.
+func (h *AuthHandler) introspect(ctx context.Context, clientID, token string) (*models.IntrospectionResponse, error) {
+	inactive := &models.IntrospectionResponse{Active: false}
+
+	if isJWT(token) {
+		claims, err := authmw.Verify(h.tokenConfig(), token)
+		if err == authmw.ErrInvalidToken || err == authmw.ErrSessionRevoked {
+			return inactive, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if claims.ClientID != clientID {
+			return inactive, nil
+		}
+		return &models.IntrospectionResponse{
+			Active:    true,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Username:  claims.Username,
+			TokenType: "Bearer",
+			ExpiresAt: claims.ExpiresAt.Unix(),
+			IssuedAt:  claims.IssuedAt.Unix(),
+			Subject:   claims.Subject,
+			Issuer:    claims.Issuer,
+		}, nil
+	}
+
+	refresh, err := h.Sessions.GetRefreshToken(ctx, opaquetoken.Hash(token))
+	if err == repository.ErrTokenNotFound {
+		return inactive, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if refresh.ClientID != clientID || refresh.UsedAt != nil || refresh.RevokedAt != nil || refresh.SessionRevoked || time.Now().After(refresh.ExpiresAt) {
+		return inactive, nil
+	}
+	userID := refresh.UserID
+	user, err := h.Users.GetByID(ctx, userID)
+	if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+		return inactive, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.IntrospectionResponse{
+		Active:    true,
+		Scope:     refresh.Scope,
+		ClientID:  clientID,
+		Username:  user.Username,
+		TokenType: "refresh_token",
+		ExpiresAt: refresh.ExpiresAt.Unix(),
+		IssuedAt:  refresh.CreatedAt.Unix(),
+		Subject:   userID.String(),
+		Issuer:    h.Issuer,
+	}, nil
+}
+
+// IntrospectToken implements token introspection (RFC 7662) for confidential clients. A client can
+// only introspect its own tokens; anything else is reported as inactive.
+func (h *AuthHandler) IntrospectToken(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+	if !client.Confidential {
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "Only confidential clients can introspect tokens")
+		return
+	}
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	resp, err := h.introspect(c.Request.Context(), client.ID, token)
+	if err != nil {
+		log.Printf("Error introspecting token for client %s: %v", client.ID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "Failed to introspect token")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, resp)
+}
+
+// RevokeToken implements token revocation (RFC 7009). Revoking an access or refresh token issued
+// for a user ends the whole session, so both stop working. Unknown tokens and tokens of other
+// clients are ignored, as the RFC requires.
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	client, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+	token := c.PostForm("token")
+	if token == "" {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "token is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+	var err error
+	if isJWT(token) {
+		var claims *models.AuthTokenClaims
+		claims, err = authmw.Verify(h.tokenConfig(), token)
+		switch {
+		case err == authmw.ErrInvalidToken || err == authmw.ErrSessionRevoked:
+			err = nil
+		case err != nil:
+		case claims.ClientID != client.ID:
+		case claims.HasUser():
+			err = revokeSession(ctx, h.Sessions, claims.UserID, claims.SessionID)
+		default:
+			// Verify already checked the jti against the registry, so it parses.
+			jti, _ := uuid.Parse(claims.ID)
+			err = h.Sessions.RevokeClientToken(ctx, jti)
+		}
+	} else {
+		var refresh *repository.RefreshToken
+		refresh, err = h.Sessions.GetRefreshToken(ctx, opaquetoken.Hash(token))
+		switch {
+		case err == repository.ErrTokenNotFound:
+			err = nil
+		case err != nil:
+		case refresh.ClientID == client.ID:
+			err = revokeSession(ctx, h.Sessions, refresh.UserID, refresh.SessionID)
+		}
+	}
+	if err != nil {
+		log.Printf("Error revoking token for client %s: %v", client.ID, err)
+		oauthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "Failed to revoke token")
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+)
+
+const testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+
+// postForm sends a form-encoded POST through handler.
+func postForm(path string, form url.Values, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.POST(path, handler)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthorizationCodeRedirectURI(t *testing.T) {
+	const registered = "https://app.example.com/callback"
+	tests := []struct {
+		name          string
+		authorizeWith string // redirect_uri sent to /oauth/authorize
+		tokenWith     string // redirect_uri sent to /oauth/token
+		wantStatus    int
+	}{
+		{"left out at both", "", "", http.StatusOK},
+		{"left out at authorize only", "", registered, http.StatusOK},
+		{"sent to both", registered, registered, http.StatusOK},
+		{"sent to authorize only", registered, "", http.StatusBadRequest},
+		{"different at token", registered, "https://app.example.com/other", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repos := newTestHandler(t)
+			user := newTestUser(t, repos, "alice")
+			client := &models.OAuthClient{
+				ID:           "test-client",
+				Name:         "Test client",
+				RedirectURIs: []string{registered},
+				Scopes:       []string{models.ScopeProfileRead},
+				GrantTypes:   []string{models.GrantAuthorizationCode},
+				CreatedAt:    time.Now().UTC(),
+			}
+			if err := repos.OAuth.CreateClient(context.Background(), client, "", user.ID); err != nil {
+				t.Fatalf("registering client: %v", err)
+			}
+
+			sum := sha256.Sum256([]byte(testCodeVerifier))
+			w := serveJSON(user.ID, http.MethodPost, "/oauth/authorize", models.OAuthConsentRequest{
+				OAuthAuthorizeRequest: models.OAuthAuthorizeRequest{
+					ResponseType:        "code",
+					ClientID:            client.ID,
+					RedirectURI:         tt.authorizeWith,
+					CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+					CodeChallengeMethod: "S256",
+				},
+				Approve: true,
+			}, h.ApproveAuthorization)
+			if w.Code != http.StatusOK {
+				t.Fatalf("authorize: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			var redirect models.OAuthRedirect
+			if err := json.Unmarshal(w.Body.Bytes(), &redirect); err != nil {
+				t.Fatalf("decoding redirect: %v", err)
+			}
+			to, err := url.Parse(redirect.RedirectTo)
+			if err != nil || !strings.HasPrefix(redirect.RedirectTo, registered+"?") || to.Query().Get("code") == "" {
+				t.Fatalf("redirect = %q, want the registered URI with a code", redirect.RedirectTo)
+			}
+
+			form := url.Values{
+				"grant_type":    {models.GrantAuthorizationCode},
+				"client_id":     {client.ID},
+				"code":          {to.Query().Get("code")},
+				"code_verifier": {testCodeVerifier},
+			}
+			if tt.tokenWith != "" {
+				form.Set("redirect_uri", tt.tokenWith)
+			}
+			w = postForm("/oauth/token", form, h.Token)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("token: status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK && !strings.Contains(w.Body.String(), `"invalid_grant"`) {
+				t.Errorf("token error = %s, want invalid_grant", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestClientCredentialsScopes(t *testing.T) {
+	h, repos := newTestHandler(t)
+	user := newTestUser(t, repos, "alice")
+
+	w := serveJSON(user.ID, http.MethodPost, "/oauth/clients", models.RegisterOAuthClientRequest{
+		Name:         "Scraper",
+		Scopes:       []string{models.ScopeProfileRead, models.ScopeFollowsWrite},
+		GrantTypes:   []string{models.GrantClientCredentials},
+		Confidential: true,
+	}, h.RegisterOAuthClient)
+	if w.Code != http.StatusBadRequest || problemCode(t, w) != apierror.CodeScopeNotAllowed {
+		t.Errorf("registering with a write scope: status = %d: %s", w.Code, w.Body.String())
+	}
+
+	// A client registered before the restriction still only gets the allowed scopes.
+	const secret = "client secret"
+	client := &models.OAuthClient{
+		ID:         "legacy-client",
+		Name:       "Legacy client",
+		Scopes:     []string{models.ScopeProfileRead, models.ScopeProfileWrite},
+		GrantTypes: []string{models.GrantClientCredentials},
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := repos.OAuth.CreateClient(context.Background(), client, opaquetoken.Hash(secret), user.ID); err != nil {
+		t.Fatalf("registering client: %v", err)
+	}
+	tests := []struct {
+		scope      string
+		wantStatus int
+		wantScope  string
+	}{
+		{"", http.StatusOK, models.ScopeProfileRead},
+		{models.ScopeProfileRead, http.StatusOK, models.ScopeProfileRead},
+		{models.ScopeProfileWrite, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		w := postForm("/oauth/token", url.Values{
+			"grant_type":    {models.GrantClientCredentials},
+			"client_id":     {client.ID},
+			"client_secret": {secret},
+			"scope":         {tt.scope},
+		}, h.Token)
+		if w.Code != tt.wantStatus {
+			t.Errorf("scope %q: status = %d, want %d: %s", tt.scope, w.Code, tt.wantStatus, w.Body.String())
+			continue
+		}
+		var resp struct {
+			Scope string `json:"scope"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		if resp.Scope != tt.wantScope {
+			t.Errorf("scope %q: granted %q, want %q", tt.scope, resp.Scope, tt.wantScope)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// DefaultOIDCStateTTL is how long a user has to finish signing in at the external provider.
+const DefaultOIDCStateTTL = 10 * time.Minute
+
+var (
+	errInvalidOIDCState = errors.New("invalid or expired oidc state")
+	errOIDCExchange     = errors.New("oidc code exchange failed")
+	errOIDCEmailTaken   = errors.New("email belongs to an existing account")
+)
+
+// OIDCProvider is an external OpenID Connect provider users can sign in with ("Sign in with ...").
+type OIDCProvider struct {
+	Name     string
+	OAuth2   oauth2.Config
+	Verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCProvider discovers the provider's endpoints and signing keys from its issuer URL.
+// redirectURL is where the provider sends the browser back to; the client posts the code from there to the callback endpoint.
+func NewOIDCProvider(ctx context.Context, name, issuerURL, clientID, clientSecret, redirectURL string) (*OIDCProvider, error) {
+	provider, err := oidc.NewProvider(ctx, issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discovering oidc provider %s: %w", name, err)
+	}
+	return &OIDCProvider{
+		Name: name,
+		OAuth2: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		},
+		Verifier: provider.Verifier(&oidc.Config{ClientID: clientID}),
+	}, nil
+}
+
+// oidcClaims are the ID token claims used to find or create the user.
+type oidcClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Name              string `json:"name"`
+}
+
+// oidcProvider looks up the provider named in the URL, answering 404 for unknown ones.
+func (h *AuthHandler) oidcProvider(c *gin.Context) (*OIDCProvider, bool) {
+	provider, ok := h.OIDCProviders[c.Param("provider")]
+	if !ok {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUnknownProvider, "Unknown identity provider"))
+		return nil, false
+	}
+	return provider, true
+}
+
+// startOIDC stores the state, nonce and PKCE verifier for a new authorization request and returns
+// the provider URL to send the user to. userID is set when linking to an existing account.
+func (h *AuthHandler) startOIDC(c *gin.Context, provider *OIDCProvider, userID *uuid.UUID) {
+	state, stateHash, err := opaquetoken.New()
+	if err != nil {
+		log.Printf("Error generating OIDC state: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to start sign-in"))
+		return
+	}
+	nonce, _, err := opaquetoken.New()
+	if err != nil {
+		log.Printf("Error generating OIDC nonce: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to start sign-in"))
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	now := time.Now().UTC()
+	err = h.Identities.CreateState(c.Request.Context(), &repository.OIDCState{
+		StateHash:    stateHash,
+		Provider:     provider.Name,
+		UserID:       userID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(h.OIDCStateTTL),
+	})
+	if err != nil {
+		log.Printf("Error storing OIDC state: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to start sign-in"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.OIDCStartResponse{
+		AuthorizationURL: provider.OAuth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State:            state,
+	})
+}
+
+// exchangeOIDC consumes the state and exchanges the authorization code for verified ID token claims.
+// It returns the user the state was started for when linking.
+func (h *AuthHandler) exchangeOIDC(ctx context.Context, provider *OIDCProvider, req *models.OIDCCallbackRequest) (*oidcClaims, *uuid.UUID, error) {
+	state, err := h.Identities.TakeState(ctx, opaquetoken.Hash(req.State), provider.Name)
+	if err == repository.ErrOIDCStateNotFound {
+		return nil, nil, errInvalidOIDCState
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	userID := state.UserID
+
+	token, err := provider.OAuth2.Exchange(ctx, req.Code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		log.Printf("OIDC code exchange with %s failed: %v", provider.Name, err)
+		return nil, userID, errOIDCExchange
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		log.Printf("OIDC token response from %s has no id_token", provider.Name)
+		return nil, userID, errOIDCExchange
+	}
+	idToken, err := provider.Verifier.Verify(ctx, rawIDToken)
+	if err != nil || idToken.Nonce != state.Nonce {
+		log.Printf("OIDC ID token from %s rejected: %v", provider.Name, err)
+		return nil, userID, errOIDCExchange
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil || claims.Subject == "" {
+		log.Printf("OIDC ID token from %s has unusable claims: %v", provider.Name, err)
+		return nil, userID, errOIDCExchange
+	}
+	return &claims, userID, nil
+}
+
+// respondOIDCError maps exchangeOIDC errors to responses.
+func respondOIDCError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidOIDCState:
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidSignInState, "Invalid or expired sign-in state, please start again"))
+	case errOIDCExchange:
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeProviderSignInFailed, "Sign-in with the identity provider failed"))
+	default:
+		log.Printf("Error completing OIDC sign-in: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+	}
+}
+
+var invalidUsernameChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// oidcUsernameBase derives a username from the provider's claims, preferring the provider's own
+// username, then the email's local part, then the name.
+func oidcUsernameBase(claims *oidcClaims) string {
+	local, _, _ := strings.Cut(claims.Email, "@")
+	for _, candidate := range []string{claims.PreferredUsername, local, claims.Name} {
+		name := invalidUsernameChars.ReplaceAllString(strings.ToLower(candidate), "_")
+		name = strings.Trim(name, "_")
+		if len(name) > 30 {
+			name = name[:30]
+		}
+		if len(name) >= 3 {
+			return name
+		}
+	}
+	return "user"
+}
+
+// maxUsernameAttempts is how many usernames provisionOIDCUser tries before giving up.
+const maxUsernameAttempts = 10
+
+// usernameCandidate returns the username to try on the given attempt: base itself first, then
+// base with a random suffix.
+func usernameCandidate(base string, attempt int) string {
+	if attempt == 0 {
+		return base
+	}
+	return fmt.Sprintf("%s_%04d", base, rand.IntN(10000))
+}
+
+// provisionOIDCUser creates an account for a first-time sign-in and links the identity to it.
+// The account has no password; the user can set one through the password reset flow.
+func provisionOIDCUser(ctx context.Context, users repository.UserRepository, identities repository.IdentityRepository, provider string, claims *oidcClaims) (*models.User, error) {
+	var email string
+	if claims.EmailVerified {
+		if normalized, err := normalizeEmail(claims.Email); err == nil {
+			email = normalized
+		}
+	}
+	if email != "" {
+		// Taking over an existing account by email would trust the provider with that account,
+		// so the owner has to log in and link the provider explicitly instead.
+		taken, err := users.EmailTaken(ctx, email, uuid.Nil)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, errOIDCEmailTaken
+		}
+	}
+
+	now := time.Now().UTC()
+	user := models.User{
+		ID:               uuid.New(),
+		Email:            email,
+		EmailVerified:    email != "",
+		DisplayName:      claims.Name,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		IsActive:         true,
+		QRCodeIdentifier: uuid.NewString(),
+	}
+
+	// Create relies on the unique constraint, so a name taken since any check is caught too.
+	base := oidcUsernameBase(claims)
+	err := repository.ErrUsernameTaken
+	for attempt := 0; attempt < maxUsernameAttempts && err == repository.ErrUsernameTaken; attempt++ {
+		user.Username = usernameCandidate(base, attempt)
+		if claims.Name == "" {
+			user.DisplayName = user.Username
+		}
+		err = users.Create(ctx, &user)
+	}
+	if err == repository.ErrUsernameTaken {
+		return nil, fmt.Errorf("no free username for %q", base)
+	}
+	if err == repository.ErrEmailTaken {
+		return nil, errOIDCEmailTaken // Registered concurrently
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := insertIdentity(ctx, identities, user.ID, provider, claims); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// insertIdentity links the provider account in claims to the user.
+func insertIdentity(ctx context.Context, identities repository.IdentityRepository, userID uuid.UUID, provider string, claims *oidcClaims) error {
+	now := time.Now().UTC()
+	return identities.Create(ctx, &repository.LinkedIdentity{
+		Identity: models.Identity{
+			Provider:    provider,
+			Email:       claims.Email,
+			CreatedAt:   now,
+			LastLoginAt: &now,
+		},
+		UserID:  userID,
+		Subject: claims.Subject,
+	})
+}
+
+// StartOIDCLogin starts signing in with an external provider.
+func (h *AuthHandler) StartOIDCLogin(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+	h.startOIDC(c, provider, nil)
+}
+
+// OIDCLoginCallback finishes signing in with an external provider. A known identity logs into its
+// linked account; an unknown one gets a new account with a generated username. Accounts with
+// two-factor authentication still get an MFA challenge, exactly as with Login.
+func (h *AuthHandler) OIDCLoginCallback(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+	var req models.OIDCCallbackRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	claims, linkUserID, err := h.exchangeOIDC(ctx, provider, &req)
+	if err == nil && linkUserID != nil {
+		// A state started for linking can't be used to log in.
+		err = errInvalidOIDCState
+	}
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting OIDC login transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+		return
+	}
+	defer tx.Rollback()
+
+	identities := h.Identities.WithTx(tx.SQL())
+	var user *models.User
+	identity, err := identities.Get(ctx, provider.Name, claims.Subject)
+	if err == nil {
+		user, err = h.Users.WithTx(tx.SQL()).GetByID(ctx, identity.UserID)
+	}
+	switch {
+	case err == repository.ErrIdentityNotFound:
+		provisioned, err := provisionOIDCUser(ctx, h.Users.WithTx(tx.SQL()), identities, provider.Name, claims)
+		if err == errOIDCEmailTaken {
+			apierror.Abort(c, apierror.Conflict(apierror.CodeEmailBelongsToAccount, "An account with this email address already exists. Log in and link this provider from your account settings."))
+			return
+		}
+		if err != nil {
+			log.Printf("Error creating account for %s identity: %v", provider.Name, err)
+			apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+			return
+		}
+		user = provisioned
+	case err != nil:
+		log.Printf("Error looking up %s identity: %v", provider.Name, err)
+		apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+		return
+	default:
+		if !user.IsActive {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeAccountDeactivated, "Account is deactivated"))
+			return
+		}
+		if err := identities.RecordLogin(ctx, provider.Name, claims.Subject); err != nil {
+			log.Printf("Error updating %s identity for user %s: %v", provider.Name, user.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing OIDC login: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+		return
+	}
+
+	if user.PasswordResetRequired {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodePasswordResetRequired, "Password reset required"))
+		return
+	}
+
+	withMFA, err := h.MFA.Enabled(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error checking MFA for user %s: %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+		return
+	}
+	if withMFA {
+		challenge, err := h.createMFAChallenge(ctx, user.ID, req.DeviceName)
+		if err != nil {
+			log.Printf("Error creating MFA challenge for user %s: %v", user.ID, err)
+			apierror.Abort(c, apierror.Internal("Failed to complete sign-in"))
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	resp, err := h.issueTokens(ctx, user, deviceFromRequest(c, req.DeviceName))
+	if err != nil {
+		log.Printf("Error generating tokens: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// StartOIDCLink starts linking an external provider to the authenticated user's account.
+func (h *AuthHandler) StartOIDCLink(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userID").(uuid.UUID)
+	h.startOIDC(c, provider, &userID)
+}
+
+// OIDCLinkCallback finishes linking. It has to be called by the same user who started the link,
+// so nobody can attach their own provider account to someone else's session.
+func (h *AuthHandler) OIDCLinkCallback(c *gin.Context) {
+	provider, ok := h.oidcProvider(c)
+	if !ok {
+		return
+	}
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.OIDCCallbackRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	claims, linkUserID, err := h.exchangeOIDC(ctx, provider, &req)
+	if err == nil && (linkUserID == nil || *linkUserID != userID) {
+		err = errInvalidOIDCState
+	}
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	identity, err := h.Identities.Get(ctx, provider.Name, claims.Subject)
+	if err == nil {
+		if identity.UserID == userID {
+			apierror.Abort(c, apierror.Conflict(apierror.CodeProviderAlreadyLinked, "An account from this provider is already linked"))
+		} else {
+			apierror.Abort(c, apierror.Conflict(apierror.CodeIdentityLinkedElsewhere, "This provider account is already linked to another user"))
+		}
+		return
+	}
+	if err != repository.ErrIdentityNotFound {
+		log.Printf("Error checking %s identity for user %s: %v", provider.Name, userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to link account"))
+		return
+	}
+
+	err = insertIdentity(ctx, h.Identities, userID, provider.Name, claims)
+	if err == repository.ErrIdentityExists {
+		// The user has another account from this provider linked, or linked this one concurrently.
+		apierror.Abort(c, apierror.Conflict(apierror.CodeProviderAlreadyLinked, "An account from this provider is already linked"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error linking %s identity to user %s: %v", provider.Name, userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to link account"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.Identity{Provider: provider.Name, Email: claims.Email, CreatedAt: time.Now().UTC()})
+}
+
+// ListIdentities lists the external providers linked to the authenticated user's account.
+func (h *AuthHandler) ListIdentities(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	identities, err := h.Identities.List(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing identities for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list linked accounts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentity removes a linked provider, unless it is the only way left to sign in.
+func (h *AuthHandler) UnlinkIdentity(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	provider := c.Param("provider")
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting unlink transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to unlink account"))
+		return
+	}
+	defer tx.Rollback()
+
+	identities := h.Identities.WithTx(tx.SQL())
+	linked, err := identities.List(ctx, userID)
+	if err != nil {
+		log.Printf("Error listing identities for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unlink account"))
+		return
+	}
+	if !slices.ContainsFunc(linked, func(identity models.Identity) bool { return identity.Provider == provider }) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeIdentityNotLinked, "No linked account for this provider"))
+		return
+	}
+
+	// The user must keep a way to sign in: a password, another provider or a passkey.
+	user, err := h.Users.WithTx(tx.SQL()).GetByID(ctx, userID)
+	canSignIn := err == nil && (user.PasswordHash != "" || len(linked) > 1)
+	if err == nil && !canSignIn {
+		var passkeys []models.Passkey
+		passkeys, err = h.Passkeys.WithTx(tx.SQL()).List(ctx, userID)
+		canSignIn = len(passkeys) > 0
+	}
+	if err != nil {
+		log.Printf("Error checking sign-in methods for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unlink account"))
+		return
+	}
+	if !canSignIn {
+		apierror.Abort(c, apierror.Conflict(apierror.CodeLastSignInMethod, "This is your only way to sign in. Set a password or add a passkey first."))
+		return
+	}
+
+	err = identities.Delete(ctx, userID, provider)
+	if err == repository.ErrIdentityNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeIdentityNotLinked, "No linked account for this provider"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error unlinking %s for user %s: %v", provider, userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unlink account"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing unlink for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unlink account"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked"})
+}
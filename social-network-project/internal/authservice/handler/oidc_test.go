@@ -0,0 +1,454 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/internal/authservice/keys"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+const (
+	fakeClientID     = "social-network"
+	fakeClientSecret = "client secret"
+	fakeRedirectURL  = "http://localhost/oidc/callback/example"
+)
+
+// fakeOIDCProvider is an OpenID Connect provider serving discovery, its JWKS and a token endpoint
+// that enforces PKCE. Tests play the browser's part at the authorization endpoint with authorize.
+type fakeOIDCProvider struct {
+	server *httptest.Server
+	keys   *keys.KeySet
+
+	mu     sync.Mutex
+	grants map[string]fakeGrant // By authorization code
+}
+
+// fakeGrant is an authorization code waiting to be exchanged.
+type fakeGrant struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	t.Helper()
+	keySet, err := keys.GenerateEphemeral()
+	if err != nil {
+		t.Fatalf("generating provider keys: %v", err)
+	}
+	p := &fakeOIDCProvider{keys: keySet, grants: map[string]fakeGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *fakeOIDCProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"EdDSA"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (p *fakeOIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	set, err := p.keys.JWKS()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, set)
+}
+
+// token redeems an authorization code once, if the client authenticates and its code_verifier
+// hashes to the challenge sent to the authorization endpoint.
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != fakeClientID || secret != fakeClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	grant, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"aud":   fakeClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": grant.nonce,
+	}
+	for name, value := range grant.claims {
+		claims[name] = value
+	}
+	idToken, err := p.keys.Sign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": uuid.NewString(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize stands in for the user signing in at the provider: it checks the authorization URL
+// and returns the code the provider would append to the redirect URL, for an ID token with claims.
+func (p *fakeOIDCProvider) authorize(t *testing.T, authorizationURL string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("parsing authorization URL: %v", err)
+	}
+	q := u.Query()
+	if u.Scheme+"://"+u.Host+u.Path != p.server.URL+"/authorize" || q.Get("client_id") != fakeClientID ||
+		q.Get("response_type") != "code" || q.Get("redirect_uri") != fakeRedirectURL ||
+		!strings.Contains(q.Get("scope"), "openid") || q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("unexpected authorization URL %s", authorizationURL)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL %s has no S256 code challenge", authorizationURL)
+	}
+
+	code := uuid.NewString()
+	p.mu.Lock()
+	p.grants[code] = fakeGrant{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	p.mu.Unlock()
+	return code
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// newOIDCHandler returns a test handler with the fake provider registered as "example".
+func newOIDCHandler(t *testing.T) (*AuthHandler, *repository.Repositories, *fakeOIDCProvider) {
+	t.Helper()
+	h, repos := newTestHandler(t)
+	fake := newFakeOIDCProvider(t)
+	provider, err := NewOIDCProvider(context.Background(), "example", fake.server.URL, fakeClientID, fakeClientSecret, fakeRedirectURL)
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+	h.OIDCProviders[provider.Name] = provider
+	return h, repos, fake
+}
+
+// startOIDC calls the start endpoint, as userID when linking, and returns its response.
+func startOIDC(t *testing.T, h *AuthHandler, userID uuid.UUID) models.OIDCStartResponse {
+	t.Helper()
+	route, path, handler := "/auth/oidc/:provider/start", "/auth/oidc/example/start", h.StartOIDCLogin
+	if userID != uuid.Nil {
+		route, path, handler = "/auth/oidc/:provider/link", "/auth/oidc/example/link", h.StartOIDCLink
+	}
+	w := serveRoute(userID, http.MethodPost, route, path, nil, handler)
+	if w.Code != http.StatusOK {
+		t.Fatalf("start: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp models.OIDCStartResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding start response: %v", err)
+	}
+	return resp
+}
+
+// oidcLoginCallback posts the code and state to the login callback.
+func oidcLoginCallback(h *AuthHandler, code, state string) *httptest.ResponseRecorder {
+	return serveRoute(uuid.Nil, http.MethodPost, "/auth/oidc/:provider/callback", "/auth/oidc/example/callback",
+		models.OIDCCallbackRequest{Code: code, State: state}, h.OIDCLoginCallback)
+}
+
+// oidcLogin signs in with the fake provider as the account described by claims.
+func oidcLogin(t *testing.T, h *AuthHandler, fake *fakeOIDCProvider, claims jwt.MapClaims) *httptest.ResponseRecorder {
+	t.Helper()
+	start := startOIDC(t, h, uuid.Nil)
+	return oidcLoginCallback(h, fake.authorize(t, start.AuthorizationURL, claims), start.State)
+}
+
+// loginUser decodes a successful login response and returns the user it is for.
+func loginUser(t *testing.T, w *httptest.ResponseRecorder) *models.User {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp models.LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding login response: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.User == nil {
+		t.Fatalf("login response = %s, want tokens and a user", w.Body.String())
+	}
+	return resp.User
+}
+
+func TestOIDCLoginProvisionsUser(t *testing.T) {
+	ctx := context.Background()
+	h, repos, fake := newOIDCHandler(t)
+	claims := jwt.MapClaims{"sub": "248289761001", "email": "Alice@Example.com", "email_verified": true, "preferred_username": "Alice.Smith", "name": "Alice Smith"}
+
+	user := loginUser(t, oidcLogin(t, h, fake, claims))
+	stored, err := repos.Users.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("loading provisioned user: %v", err)
+	}
+	if stored.Username != "alice_smith" || stored.Email != "alice@example.com" || !stored.EmailVerified || stored.DisplayName != "Alice Smith" || stored.PasswordHash != "" {
+		t.Errorf("provisioned user = %+v", stored)
+	}
+	identity, err := repos.Identities.Get(ctx, "example", "248289761001")
+	if err != nil || identity.UserID != user.ID {
+		t.Fatalf("identity = %+v, %v; want one linked to %s", identity, err, user.ID)
+	}
+
+	// Signing in again with the same provider account logs into the same user.
+	if again := loginUser(t, oidcLogin(t, h, fake, claims)); again.ID != user.ID {
+		t.Errorf("second sign-in logged into %s, want %s", again.ID, user.ID)
+	}
+	if users, err := repos.Users.List(ctx, repository.UserFilter{Limit: 10}); err != nil || len(users) != 1 {
+		t.Errorf("users after two sign-ins = %d, %v; want 1", len(users), err)
+	}
+}
+
+func TestOIDCLoginUnverifiedEmailIsNotStored(t *testing.T) {
+	h, repos, fake := newOIDCHandler(t)
+	claims := jwt.MapClaims{"sub": "1", "email": "bob@example.com", "email_verified": false}
+
+	user := loginUser(t, oidcLogin(t, h, fake, claims))
+	stored, err := repos.Users.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("loading provisioned user: %v", err)
+	}
+	if stored.Username != "bob" || stored.Email != "" || stored.EmailVerified {
+		t.Errorf("provisioned user = %+v, want username bob without email", stored)
+	}
+}
+
+func TestOIDCLoginRejectsWrongCodeVerifier(t *testing.T) {
+	h, repos, fake := newOIDCHandler(t)
+
+	// The code was issued for the first authorization request, so exchanging it with the second
+	// one's state sends the wrong PKCE verifier and the provider refuses it.
+	first := startOIDC(t, h, uuid.Nil)
+	second := startOIDC(t, h, uuid.Nil)
+	code := fake.authorize(t, first.AuthorizationURL, jwt.MapClaims{"sub": "1", "preferred_username": "mallory"})
+	w := oidcLoginCallback(h, code, second.State)
+	if w.Code != http.StatusUnauthorized || problemCode(t, w) != apierror.CodeProviderSignInFailed {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+	if taken, _ := repos.Users.UsernameTaken(context.Background(), "mallory"); taken {
+		t.Error("user created without a valid code exchange")
+	}
+
+	// A code from a new authorization with the first request's challenge goes with its state, once.
+	code = fake.authorize(t, first.AuthorizationURL, jwt.MapClaims{"sub": "1", "preferred_username": "mallory"})
+	loginUser(t, oidcLoginCallback(h, code, first.State))
+	w = oidcLoginCallback(h, code, first.State)
+	if w.Code != http.StatusBadRequest || problemCode(t, w) != apierror.CodeInvalidSignInState {
+		t.Errorf("reused state: status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
+func TestOIDCLoginRetriesTakenUsername(t *testing.T) {
+	h, repos, fake := newOIDCHandler(t)
+	newTestUser(t, repos, "alice")
+
+	user := loginUser(t, oidcLogin(t, h, fake, jwt.MapClaims{"sub": "1", "preferred_username": "alice"}))
+	if !strings.HasPrefix(user.Username, "alice_") || len(user.Username) != len("alice_0000") {
+		t.Errorf("username = %q, want alice with a numeric suffix", user.Username)
+	}
+}
+
+// takenUsernames is a UserRepository on which the first taken calls to Create fail with
+// ErrUsernameTaken, as if someone registered each name just before.
+type takenUsernames struct {
+	repository.UserRepository
+	taken int
+	tried []string
+}
+
+func (r *takenUsernames) Create(ctx context.Context, user *models.User) error {
+	r.tried = append(r.tried, user.Username)
+	if len(r.tried) <= r.taken {
+		return repository.ErrUsernameTaken
+	}
+	return r.UserRepository.Create(ctx, user)
+}
+
+func TestProvisionOIDCUserRetriesConcurrentlyTakenUsername(t *testing.T) {
+	tests := []struct {
+		name    string
+		taken   int
+		wantErr bool
+	}{
+		{"free", 0, false},
+		{"taken a few times", 3, false},
+		{"always taken", maxUsernameAttempts, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := repository.NewMemoryRepositories()
+			users := &takenUsernames{UserRepository: repos.Users, taken: tt.taken}
+			user, err := provisionOIDCUser(context.Background(), users, repos.Identities, "example", &oidcClaims{Subject: "1", PreferredUsername: "carol"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("provisionOIDCUser = %+v, want an error", user)
+				}
+				if len(users.tried) != maxUsernameAttempts {
+					t.Errorf("tried %d usernames, want %d", len(users.tried), maxUsernameAttempts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("provisionOIDCUser: %v", err)
+			}
+			if users.tried[0] != "carol" || user.Username != users.tried[tt.taken] || len(users.tried) != tt.taken+1 {
+				t.Errorf("tried %v, got %q", users.tried, user.Username)
+			}
+			if identity, err := repos.Identities.Get(context.Background(), "example", "1"); err != nil || identity.UserID != user.ID {
+				t.Errorf("identity = %+v, %v; want one linked to %s", identity, err, user.ID)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginRefusesExistingEmail(t *testing.T) {
+	ctx := context.Background()
+	h, repos, fake := newOIDCHandler(t)
+	alice := newTestUser(t, repos, "alice")
+	if err := repos.Users.SetEmail(ctx, alice.ID, "alice@example.com"); err != nil {
+		t.Fatalf("setting email: %v", err)
+	}
+
+	w := oidcLogin(t, h, fake, jwt.MapClaims{"sub": "1", "email": "ALICE@example.com", "email_verified": true})
+	if w.Code != http.StatusConflict || problemCode(t, w) != apierror.CodeEmailBelongsToAccount {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+	if _, err := repos.Identities.Get(ctx, "example", "1"); err != repository.ErrIdentityNotFound {
+		t.Errorf("identity lookup: err = %v, want ErrIdentityNotFound", err)
+	}
+}
+
+func TestOIDCLinkToExistingUser(t *testing.T) {
+	ctx := context.Background()
+	h, repos, fake := newOIDCHandler(t)
+	alice := newTestUser(t, repos, "alice")
+	bob := newTestUser(t, repos, "bob")
+	claims := jwt.MapClaims{"sub": "alice-at-example", "email": "alice@example.com", "email_verified": true}
+
+	linkCallback := func(userID uuid.UUID, code, state string) *httptest.ResponseRecorder {
+		return serveRoute(userID, http.MethodPost, "/auth/oidc/:provider/link/callback", "/auth/oidc/example/link/callback",
+			models.OIDCCallbackRequest{Code: code, State: state}, h.OIDCLinkCallback)
+	}
+
+	// Only the user who started the link can finish it.
+	start := startOIDC(t, h, alice.ID)
+	w := linkCallback(bob.ID, fake.authorize(t, start.AuthorizationURL, claims), start.State)
+	if w.Code != http.StatusBadRequest || problemCode(t, w) != apierror.CodeInvalidSignInState {
+		t.Fatalf("link by another user: status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+
+	// A state started for linking can't be used to log in.
+	start = startOIDC(t, h, alice.ID)
+	w = oidcLoginCallback(h, fake.authorize(t, start.AuthorizationURL, claims), start.State)
+	if w.Code != http.StatusBadRequest || problemCode(t, w) != apierror.CodeInvalidSignInState {
+		t.Fatalf("login with a link state: status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+
+	start = startOIDC(t, h, alice.ID)
+	w = linkCallback(alice.ID, fake.authorize(t, start.AuthorizationURL, claims), start.State)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("link: status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	linked, err := repos.Identities.List(ctx, alice.ID)
+	if err != nil || len(linked) != 1 || linked[0].Provider != "example" || linked[0].Email != "alice@example.com" {
+		t.Fatalf("alice's identities = %+v, %v; want the example account", linked, err)
+	}
+
+	// The provider account now logs into alice instead of getting an account of its own.
+	if user := loginUser(t, oidcLogin(t, h, fake, claims)); user.ID != alice.ID {
+		t.Errorf("sign-in logged into %s, want alice %s", user.ID, alice.ID)
+	}
+
+	// It can't be linked to bob as well.
+	start = startOIDC(t, h, bob.ID)
+	w = linkCallback(bob.ID, fake.authorize(t, start.AuthorizationURL, claims), start.State)
+	if w.Code != http.StatusConflict || problemCode(t, w) != apierror.CodeIdentityLinkedElsewhere {
+		t.Errorf("link to bob: status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+}
+
+func TestUnlinkIdentityKeepsASignInMethod(t *testing.T) {
+	ctx := context.Background()
+	h, repos, fake := newOIDCHandler(t)
+	user := loginUser(t, oidcLogin(t, h, fake, jwt.MapClaims{"sub": "1", "preferred_username": "dave"}))
+
+	unlink := func(provider string) *httptest.ResponseRecorder {
+		return serveRoute(user.ID, http.MethodDelete, "/auth/oidc/identities/:provider", "/auth/oidc/identities/"+provider, nil, h.UnlinkIdentity)
+	}
+
+	if w := unlink("other"); w.Code != http.StatusNotFound || problemCode(t, w) != apierror.CodeIdentityNotLinked {
+		t.Errorf("unlinking an unlinked provider: status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body.String())
+	}
+
+	// The provisioned account has no password, so the provider is its only way to sign in.
+	w := unlink("example")
+	if w.Code != http.StatusConflict || problemCode(t, w) != apierror.CodeLastSignInMethod {
+		t.Fatalf("unlinking the last method: status = %d, want %d: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+	if linked, err := repos.Identities.List(ctx, user.ID); err != nil || len(linked) != 1 {
+		t.Fatalf("identities = %+v, %v; want the example account kept", linked, err)
+	}
+
+	if err := repos.Users.UpdatePassword(ctx, user.ID, "hash"); err != nil {
+		t.Fatalf("setting password: %v", err)
+	}
+	if w := unlink("example"); w.Code != http.StatusOK {
+		t.Fatalf("unlinking with a password set: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	if linked, err := repos.Identities.List(ctx, user.ID); err != nil || len(linked) != 0 {
+		t.Errorf("identities = %+v, %v; want none", linked, err)
+	}
+}
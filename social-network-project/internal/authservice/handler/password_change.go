@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/social-network/internal/authservice/passwordpolicy"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// ChangePassword changes the authenticated user's password after checking the current one.
+// Every existing session is revoked and password_changed_at is bumped so AuthMiddleware rejects
+// tokens issued before the change; the caller gets a fresh session in the response.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.ChangePasswordRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	user, err := h.Users.GetByID(c.Request.Context(), userID)
+	if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user %s for password change: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to change password"))
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeIncorrectPassword, "Current password is incorrect"))
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodePasswordUnchanged, "New password must be different from the current password"))
+		return
+	}
+	if !h.checkPasswordPolicy(c, req.NewPassword, passwordpolicy.Account{Username: user.Username, Email: user.Email}) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to change password"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting password change transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to change password"))
+		return
+	}
+	defer tx.Rollback()
+
+	err = h.Users.WithTx(tx.SQL()).UpdatePassword(ctx, userID, string(hashedPassword))
+	if err != nil {
+		log.Printf("Error updating password for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to change password"))
+		return
+	}
+	if err := h.Sessions.WithTx(tx.SQL()).RevokeAll(ctx, userID); err != nil {
+		log.Printf("Error revoking sessions for user %s after password change: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to change password"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing password change for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to change password"))
+		return
+	}
+
+	resp, err := h.issueTokens(ctx, user, deviceFromRequest(c, ""))
+	if err != nil {
+		log.Printf("Error issuing tokens after password change for user %s: %v", userID, err)
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed. Please log in again."})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/authmw"
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+func TestChangePasswordRejectsOlderTokens(t *testing.T) {
+	h, repos := newTestHandler(t)
+	user := newTestUser(t, repos, "alice")
+	cfg := authmw.Config{
+		Keyfunc:      h.Keys.Keyfunc,
+		Issuer:       h.Issuer,
+		Audience:     h.Audience,
+		CheckSession: authmw.RegistrySessionChecker(repos.Sessions),
+	}
+	token := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		var resp models.LoginResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" {
+			t.Fatalf("decoding tokens: %+v, %v", resp, err)
+		}
+		return resp.Token
+	}
+
+	w := serveJSON(uuid.Nil, http.MethodPost, "/auth/login", models.LoginRequest{Username: "alice", Password: testPassword}, h.Login)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status = %d: %s", w.Code, w.Body.String())
+	}
+	oldToken := token(w)
+
+	w = serveJSON(user.ID, http.MethodPost, "/auth/password", models.ChangePasswordRequest{
+		CurrentPassword: testPassword,
+		NewPassword:     "a different horse battery staple",
+	}, h.ChangePassword)
+	if w.Code != http.StatusOK {
+		t.Fatalf("change password: status = %d: %s", w.Code, w.Body.String())
+	}
+	newToken := token(w)
+
+	if _, err := authmw.Verify(cfg, oldToken); err != authmw.ErrSessionRevoked {
+		t.Errorf("token from before the change: err = %v, want %v", err, authmw.ErrSessionRevoked)
+	}
+	// The new token is usually issued in the same second as the change, which "iat" can't tell apart.
+	if _, err := authmw.Verify(cfg, newToken); err != nil {
+		t.Errorf("token from the change: err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/social-network/internal/authservice/passwordpolicy"
+	"github.com/yourusername/social-network/pkg/apierror"
+)
+
+// checkPasswordPolicy answers 400 with every violated rule when a new password isn't acceptable.
+func (h *AuthHandler) checkPasswordPolicy(c *gin.Context, password string, account passwordpolicy.Account) bool {
+	violations := h.PasswordPolicy.Check(c.Request.Context(), password, account)
+	if len(violations) == 0 {
+		return true
+	}
+	apierror.Abort(c, apierror.BadRequest(apierror.CodePasswordPolicy, "Password does not meet the requirements").With("violations", violations))
+	return false
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/yourusername/social-network/internal/authservice/passwordpolicy"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/throttle"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// DefaultPasswordResetTTL is how long a password reset link stays valid.
+const DefaultPasswordResetTTL = time.Hour
+
+// forgotPasswordMessage is returned whether or not an account matched, so the endpoint
+// can't be used to find out which email addresses are registered.
+const forgotPasswordMessage = "If an account with that email exists, a password reset link has been sent"
+
+// passwordResetPolicy limits reset requests per email address: a few right away, then one at a
+// time with growing waits, so the endpoint can't be used to flood someone's mailbox. There is no
+// lockout, which would let anyone block the resets of an account.
+var passwordResetPolicy = throttle.Policy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Minute,
+	MaxDelay:     time.Hour,
+	Window:       24 * time.Hour,
+}
+
+// newPasswordResetLimiter throttles reset requests per email address and IP. Its counters are
+// kept apart from the login ones, so asking for reset links never locks anyone out of logging in.
+func newPasswordResetLimiter(store throttle.Store) *throttle.Limiter {
+	l := throttle.NewLimiter(store)
+	l.Scope = "reset:"
+	l.Username = passwordResetPolicy
+	return l
+}
+
+// ForgotPassword issues a single-use password reset token for the account with the given
+// verified email address and delivers it through the notifier.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	email, err := normalizeEmail(req.Email)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidEmail, "Invalid email address"))
+		return
+	}
+
+	// Every request counts, whether or not an account matches, so the limit can't tell which
+	// addresses are registered either. The attempt is never reported back.
+	_, decision, err := h.PasswordResetThrottle.Reserve(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		log.Printf("Error checking password reset throttle: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process password reset"))
+		return
+	}
+	if !decision.Allowed() {
+		abortThrottled(c, decision, "Too many password reset requests, try again later")
+		return
+	}
+
+	user, err := h.Users.GetByEmail(c.Request.Context(), email)
+	if err != nil && err != repository.ErrNotFound {
+		log.Printf("Error looking up user for password reset: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process password reset"))
+		return
+	}
+	// Only verified addresses can receive reset links; otherwise anyone could attach a victim's
+	// address to their own account and confuse the victim with reset emails.
+	if err == repository.ErrNotFound || !user.EmailVerified || !user.IsActive {
+		c.JSON(http.StatusAccepted, gin.H{"message": forgotPasswordMessage})
+		return
+	}
+	userID := user.ID
+
+	token, tokenHash, err := opaquetoken.New()
+	if err != nil {
+		log.Printf("Error generating password reset token: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process password reset"))
+		return
+	}
+	now := time.Now().UTC()
+	err = h.AccountTokens.CreatePasswordReset(c.Request.Context(), &repository.AccountToken{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.PasswordResetTTL),
+	})
+	if err != nil {
+		log.Printf("Error storing password reset token for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process password reset"))
+		return
+	}
+
+	link := h.PasswordResetURL + "?token=" + url.QueryEscape(token)
+	if err := h.Notifier.PasswordReset(c.Request.Context(), email, user.Username, link, h.PasswordResetTTL); err != nil {
+		// Still answer with the generic message; a delivery error must not reveal that the account exists.
+		log.Printf("Error sending password reset to user %s: %v", userID, err)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": forgotPasswordMessage})
+}
+
+// ResetPassword consumes a reset token, sets the new password and signs the user out everywhere.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting password reset transaction: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+	defer tx.Rollback()
+
+	tokens := h.AccountTokens.WithTx(tx.SQL())
+	token, err := tokens.GetPasswordReset(ctx, opaquetoken.Hash(req.Token))
+	if err == repository.ErrTokenNotFound || (err == nil && (token.UsedAt != nil || time.Now().After(token.ExpiresAt))) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidResetToken, "Invalid or expired reset token"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking up password reset token: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+	userID := token.UserID
+
+	users := h.Users.WithTx(tx.SQL())
+	user, err := users.GetByID(ctx, userID)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidResetToken, "Invalid or expired reset token"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user %s for password reset: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+	if !h.checkPasswordPolicy(c, req.NewPassword, passwordpolicy.Account{Username: user.Username, Email: user.Email}) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("Error hashing password: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+
+	err = users.UpdatePassword(ctx, userID, string(hashedPassword))
+	if err == repository.ErrNotFound {
+		// Account was deactivated after the link was sent.
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidResetToken, "Invalid or expired reset token"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating password for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+
+	// Consume this token and any other outstanding ones for the account.
+	if err := tokens.UsePasswordResets(ctx, userID); err != nil {
+		log.Printf("Error consuming password reset tokens for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+	// Whoever knew the old password may still hold a session.
+	if err := h.Sessions.WithTx(tx.SQL()).RevokeAll(ctx, userID); err != nil {
+		log.Printf("Error revoking sessions for user %s after password reset: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing password reset for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to reset password"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset. Please log in with your new password."})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+func TestForgotPasswordIsThrottled(t *testing.T) {
+	h, repos := newTestHandler(t)
+	notifier := &recordingNotifier{}
+	h.Notifier = notifier
+	newVerifiedUser(t, repos, "alice", "alice@example.com")
+	// The delay applies after the first request past the free ones.
+	allowed := passwordResetPolicy.FreeAttempts + 1
+
+	// Unknown addresses are limited the same way as registered ones.
+	for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+		for i := 1; i <= allowed; i++ {
+			w := serveJSON(uuid.Nil, http.MethodPost, "/auth/password/forgot", models.ForgotPasswordRequest{Email: email}, h.ForgotPassword)
+			if w.Code != http.StatusAccepted {
+				t.Fatalf("%s, request %d: status = %d: %s", email, i, w.Code, w.Body.String())
+			}
+		}
+		w := serveJSON(uuid.Nil, http.MethodPost, "/auth/password/forgot", models.ForgotPasswordRequest{Email: email}, h.ForgotPassword)
+		if w.Code != http.StatusTooManyRequests || problemCode(t, w) != apierror.CodeRateLimited || w.Header().Get("Retry-After") != "60" {
+			t.Errorf("%s, throttled request: status = %d, Retry-After = %q: %s", email, w.Code, w.Header().Get("Retry-After"), w.Body.String())
+		}
+	}
+	if len(notifier.resets) != allowed {
+		t.Errorf("reset links sent to %v, want %d", notifier.resets, allowed)
+	}
+
+	// Reset requests don't count against logins.
+	w := serveJSON(uuid.Nil, http.MethodPost, "/auth/login", models.LoginRequest{Username: "alice", Password: testPassword}, h.Login)
+	if w.Code != http.StatusOK {
+		t.Errorf("login: status = %d: %s", w.Code, w.Body.String())
+	}
+}
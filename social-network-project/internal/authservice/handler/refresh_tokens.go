@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// Default token lifetimes and registered claims. They can be overridden on the AuthHandler (see cmd/auth-service).
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+	DefaultIssuer          = "auth-service"
+	DefaultAudience        = "social-network"
+)
+
+// errInvalidRefreshToken is returned by rotateRefreshToken for any token that can't be exchanged.
+// Callers respond with a generic 401 so clients can't tell unknown, expired and reused tokens apart.
+var errInvalidRefreshToken = errors.New("invalid refresh token")
+
+// clientGrant describes a session issued to a third-party OAuth client instead of our own apps:
+// its tokens carry the client ID and only the scopes the user consented to, and no roles.
+type clientGrant struct {
+	ClientID string
+	Scope    string
+}
+
+// generateAccessToken signs a short-lived JWT for the given user and session.
+// Each token's unique "jti" is recorded in the session registry so AuthMiddleware can
+// reject it as soon as its session is revoked, instead of waiting for "exp".
+// grant is nil for first-party sessions. sessions and roles may be bound to a transaction.
+func (h *AuthHandler) generateAccessToken(ctx context.Context, sessions repository.SessionRepository, roles repository.RoleRepository, user *models.User, sessionID uuid.UUID, grant *clientGrant) (string, error) {
+	now := time.Now()
+	expiresAt := now.Add(h.AccessTokenTTL)
+	jti := uuid.New()
+
+	var (
+		tokenRoles []string
+		scope      = strings.Join(models.DefaultScopes, " ")
+		clientID   string
+	)
+	if grant == nil {
+		// Everyone has RoleUser; only the extra roles are assigned.
+		assigned, err := roles.UserRoles(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+		tokenRoles = append([]string{models.RoleUser}, assigned...)
+	} else {
+		scope, clientID = grant.Scope, grant.ClientID
+	}
+
+	if err := sessions.AddAccessToken(ctx, jti, sessionID, expiresAt); err != nil {
+		return "", err
+	}
+
+	claims := models.AuthTokenClaims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		SessionID: sessionID,
+		Roles:     tokenRoles,
+		Scope:     scope,
+		ClientID:  clientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti.String(),
+			Subject:   user.ID.String(),
+			Issuer:    h.Issuer,
+			Audience:  jwt.ClaimStrings{h.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	return h.Keys.Sign(claims)
+}
+
+// insertRefreshToken stores a new refresh token for the user in the given session and returns the raw token.
+// The session is kept alive for as long as its newest refresh token.
+func (h *AuthHandler) insertRefreshToken(ctx context.Context, sessions repository.SessionRepository, userID, sessionID uuid.UUID) (uuid.UUID, string, error) {
+	token, tokenHash, err := opaquetoken.New()
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	now := time.Now().UTC()
+	refreshToken := repository.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		SessionID: sessionID,
+		TokenHash: tokenHash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.RefreshTokenTTL),
+	}
+	if err := sessions.AddRefreshToken(ctx, &refreshToken); err != nil {
+		return uuid.Nil, "", err
+	}
+	return refreshToken.ID, token, nil
+}
+
+// issueTokens starts a new session for the user and returns its first access and refresh tokens.
+func (h *AuthHandler) issueTokens(ctx context.Context, user *models.User, device sessionDevice) (*models.LoginResponse, error) {
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	resp, _, err := h.issueSessionTokens(ctx, tx, user, device, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// issueSessionTokens creates a session inside tx and returns its first access token, plus a
+// refresh token when withRefresh is set. grant is nil for first-party sessions.
+func (h *AuthHandler) issueSessionTokens(ctx context.Context, tx repository.Tx, user *models.User, device sessionDevice, grant *clientGrant, withRefresh bool) (*models.LoginResponse, uuid.UUID, error) {
+	sessions := h.Sessions.WithTx(tx.SQL())
+	sessionID, err := createSession(ctx, sessions, user.ID, device, grant)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+	var refreshToken string
+	if withRefresh {
+		if _, refreshToken, err = h.insertRefreshToken(ctx, sessions, user.ID, sessionID); err != nil {
+			return nil, uuid.Nil, err
+		}
+	} else {
+		// Without a refresh token the session lives exactly as long as its access token.
+		if err = sessions.SetExpiry(ctx, sessionID, time.Now().Add(h.AccessTokenTTL)); err != nil {
+			return nil, uuid.Nil, err
+		}
+	}
+	accessToken, err := h.generateAccessToken(ctx, sessions, h.Roles.WithTx(tx.SQL()), user, sessionID, grant)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, sessionID, nil
+}
+
+// revokeSession revokes one session of the user. Sessions that are already revoked are ignored.
+func revokeSession(ctx context.Context, sessions repository.SessionRepository, userID, sessionID uuid.UUID) error {
+	if err := sessions.Revoke(ctx, userID, sessionID); err != nil && err != repository.ErrSessionNotFound {
+		return err
+	}
+	return nil
+}
+
+// rotateRefreshToken exchanges a refresh token for a new token pair in the same session.
+// Refresh tokens are single-use: presenting one that was already rotated or revoked
+// is treated as theft and revokes the whole session, logging out every holder of it.
+// clientID is the OAuth client presenting the token, empty for our own apps; tokens only
+// work for the client they were issued to.
+func (h *AuthHandler) rotateRefreshToken(ctx context.Context, rawToken, clientID string) (*models.LoginResponse, error) {
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sessions := h.Sessions.WithTx(tx.SQL())
+	token, err := sessions.GetRefreshToken(ctx, opaquetoken.Hash(rawToken))
+	if err == repository.ErrTokenNotFound || (err == nil && token.ClientID != clientID) {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := h.Users.WithTx(tx.SQL()).GetByID(ctx, token.UserID)
+	if err == repository.ErrNotFound {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if token.UsedAt != nil || token.RevokedAt != nil {
+		// Reuse of a rotated token: someone else holds a copy. Kill the whole session.
+		log.Printf("Refresh token reuse detected for user %s (session %s), revoking session", user.ID, token.SessionID)
+		if err := revokeSession(ctx, sessions, user.ID, token.SessionID); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, errInvalidRefreshToken
+	}
+	if time.Now().After(token.ExpiresAt) || !user.IsActive {
+		return nil, errInvalidRefreshToken
+	}
+
+	newID, newToken, err := h.insertRefreshToken(ctx, sessions, user.ID, token.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := sessions.UseRefreshToken(ctx, token.ID, newID); err != nil {
+		return nil, err
+	}
+	var sessionGrant *clientGrant
+	if token.ClientID != "" {
+		sessionGrant = &clientGrant{ClientID: token.ClientID, Scope: token.Scope}
+	}
+	accessToken, err := h.generateAccessToken(ctx, sessions, h.Roles.WithTx(tx.SQL()), user, token.SessionID, sessionGrant)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.LoginResponse{
+		Token:        accessToken,
+		RefreshToken: newToken,
+		ExpiresIn:    int64(h.AccessTokenTTL.Seconds()),
+		User:         user,
+	}, nil
+}
+
+// Refresh exchanges a valid refresh token for a new access token and a new refresh token.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	resp, err := h.rotateRefreshToken(c.Request.Context(), req.RefreshToken, "")
+	if err == errInvalidRefreshToken {
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidRefreshToken, "Invalid or expired refresh token"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error rotating refresh token: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to refresh token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the session the presented refresh token belongs to.
+// Unknown tokens are accepted silently so logout is idempotent.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	token, err := h.Sessions.GetRefreshToken(ctx, opaquetoken.Hash(req.RefreshToken))
+	if err != nil && err != repository.ErrTokenNotFound {
+		log.Printf("Error looking up refresh token for logout: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to log out"))
+		return
+	}
+	if err == nil {
+		if err := revokeSession(ctx, h.Sessions, token.UserID, token.SessionID); err != nil {
+			log.Printf("Error revoking session %s: %v", token.SessionID, err)
+			apierror.Abort(c, apierror.Internal("Failed to log out"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/authmw"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+// sessionDevice describes the client a session was created from.
+type sessionDevice struct {
+	Name      string
+	UserAgent string
+	IPAddress string
+}
+
+// deviceFromRequest collects the device details recorded on a new session.
+func deviceFromRequest(c *gin.Context, deviceName string) sessionDevice {
+	return sessionDevice{
+		Name:      deviceName,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// createSession stores a new session. Its expiry is set when the first refresh token is stored.
+// grant is nil for first-party sessions.
+func createSession(ctx context.Context, sessions repository.SessionRepository, userID uuid.UUID, device sessionDevice, grant *clientGrant) (uuid.UUID, error) {
+	now := time.Now().UTC()
+	session := models.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		DeviceName: device.Name,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now,
+	}
+	if grant != nil {
+		session.ClientID, session.Scope = grant.ClientID, grant.Scope
+	}
+	if err := sessions.Create(ctx, &session); err != nil {
+		return uuid.Nil, err
+	}
+	return session.ID, nil
+}
+
+// AuthMiddleware verifies the access token with auth-service's own keys and checks that its
+// session has not been revoked. Account management is first-party only, so tokens issued to
+// third-party OAuth clients are rejected.
+func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
+	cfg := h.tokenConfig()
+	cfg.FirstPartyOnly = true
+	return authmw.Authenticate(cfg)
+}
+
+// tokenConfig describes how auth-service validates its own access tokens.
+func (h *AuthHandler) tokenConfig() authmw.Config {
+	return authmw.Config{
+		Keyfunc:      h.Keys.Keyfunc,
+		Issuer:       h.Issuer,
+		Audience:     h.Audience,
+		CheckSession: authmw.RegistrySessionChecker(h.Sessions),
+	}
+}
+
+// ListSessions returns the authenticated user's active sessions, most recently used first.
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	currentSessionID := c.MustGet("sessionID").(uuid.UUID)
+
+	sessions, err := h.Sessions.ListActive(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing sessions for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list sessions"))
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession revokes a single session of the authenticated user, e.g. a lost phone.
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid session ID format"))
+		return
+	}
+
+	err = h.Sessions.Revoke(c.Request.Context(), userID, sessionID)
+	if err == repository.ErrSessionNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeSessionNotFound, "Session not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error revoking session %s: %v", sessionID, err)
+		apierror.Abort(c, apierror.Internal("Failed to revoke session"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeAllSessions revokes every session of the authenticated user, including the current one.
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	if err := h.Sessions.RevokeAll(c.Request.Context(), userID); err != nil {
+		log.Printf("Error revoking all sessions for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to revoke sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions revoked"})
+}
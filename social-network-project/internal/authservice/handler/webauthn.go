@@ -0,0 +1,407 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// DefaultWebAuthnCeremonyTTL is how long a passkey registration or login ceremony can be finished after it begins.
+const DefaultWebAuthnCeremonyTTL = 5 * time.Minute
+
+// Kinds of WebAuthn ceremonies.
+const (
+	ceremonyRegistration = "registration"
+	ceremonyLogin        = "login"
+)
+
+var errInvalidCeremony = errors.New("invalid or expired webauthn ceremony")
+
+// webauthnUser adapts a user and their stored passkeys to webauthn.User.
+// The user handle is the raw 16 bytes of the (random, v4) user ID.
+type webauthnUser struct {
+	user        *models.User
+	credentials []webauthn.Credential
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return u.user.Username
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	if u.user.DisplayName != "" {
+		return u.user.DisplayName
+	}
+	return u.user.Username
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// loadWebAuthnUser reads an active user and their passkeys. It returns repository.ErrNotFound
+// for unknown and deactivated users.
+func (h *AuthHandler) loadWebAuthnUser(ctx context.Context, userID uuid.UUID) (*webauthnUser, error) {
+	user, err := h.Users.GetByID(ctx, userID)
+	if err == nil && !user.IsActive {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := h.Passkeys.Credentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]webauthn.Credential, len(stored))
+	for i, sc := range stored {
+		cred := webauthn.Credential{
+			ID:              sc.CredentialID,
+			PublicKey:       sc.PublicKey,
+			AttestationType: sc.AttestationType,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(sc.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       sc.AAGUID,
+				SignCount:    sc.SignCount,
+				CloneWarning: sc.CloneWarning,
+			},
+		}
+		for _, t := range sc.Transports {
+			cred.Transport = append(cred.Transport, protocol.AuthenticatorTransport(t))
+		}
+		credentials[i] = cred
+	}
+
+	return &webauthnUser{user: user, credentials: credentials}, nil
+}
+
+// saveCeremony keeps the ceremony state server-side; the client only gets its ID.
+func (h *AuthHandler) saveCeremony(ctx context.Context, kind string, userID *uuid.UUID, session *webauthn.SessionData) (uuid.UUID, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	now := time.Now().UTC()
+	ceremony := repository.WebAuthnCeremony{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Kind:        kind,
+		SessionData: data,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(h.WebAuthnCeremonyTTL),
+	}
+	if err := h.Passkeys.SaveCeremony(ctx, &ceremony); err != nil {
+		return uuid.Nil, err
+	}
+	return ceremony.ID, nil
+}
+
+// takeCeremony consumes a ceremony so it can only be finished once. It returns the user the
+// ceremony was begun for, nil for logins.
+func (h *AuthHandler) takeCeremony(ctx context.Context, id uuid.UUID, kind string) (*uuid.UUID, *webauthn.SessionData, error) {
+	ceremony, err := h.Passkeys.TakeCeremony(ctx, id, kind)
+	if err == repository.ErrCeremonyNotFound {
+		return nil, nil, errInvalidCeremony
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal(ceremony.SessionData, &session); err != nil {
+		return nil, nil, err
+	}
+	return ceremony.UserID, &session, nil
+}
+
+// requireWebAuthn answers 503 when passkeys aren't configured.
+func (h *AuthHandler) requireWebAuthn(c *gin.Context) bool {
+	if h.WebAuthn == nil {
+		apierror.Abort(c, apierror.Unavailable(apierror.CodePasskeysDisabled, "Passkeys are not enabled"))
+		return false
+	}
+	return true
+}
+
+// BeginPasskeyRegistration starts registering a new passkey for the authenticated user.
+// Passkeys must be discoverable and user-verifying, so they can later be used without a username or password.
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	if !h.requireWebAuthn(c) {
+		return
+	}
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	wu, err := h.loadWebAuthnUser(c.Request.Context(), userID)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading passkeys for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to start passkey registration"))
+		return
+	}
+
+	creation, session, err := h.WebAuthn.BeginRegistration(wu,
+		webauthn.WithAuthenticatorSelection(protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		}),
+		// Don't register the same authenticator twice.
+		webauthn.WithExclusions(webauthn.Credentials(wu.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		log.Printf("Error beginning passkey registration for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to start passkey registration"))
+		return
+	}
+
+	ceremonyID, err := h.saveCeremony(c.Request.Context(), ceremonyRegistration, &userID, session)
+	if err != nil {
+		log.Printf("Error storing passkey registration for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to start passkey registration"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WebAuthnBeginResponse{CeremonyID: ceremonyID, Options: creation})
+}
+
+// FinishPasskeyRegistration verifies the authenticator's attestation and stores the new passkey.
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	if !h.requireWebAuthn(c) {
+		return
+	}
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.WebAuthnRegisterRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidPasskeyCredential, "Invalid passkey credential"))
+		return
+	}
+
+	ceremonyUserID, session, err := h.takeCeremony(c.Request.Context(), req.CeremonyID, ceremonyRegistration)
+	if err == errInvalidCeremony || (err == nil && (ceremonyUserID == nil || *ceremonyUserID != userID)) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodePasskeyCeremonyExpired, "Invalid or expired passkey registration, please start again"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading passkey registration for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to register passkey"))
+		return
+	}
+
+	wu, err := h.loadWebAuthnUser(c.Request.Context(), userID)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading passkeys for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to register passkey"))
+		return
+	}
+
+	cred, err := h.WebAuthn.CreateCredential(wu, *session, parsed)
+	if err != nil {
+		log.Printf("Passkey attestation rejected for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.BadRequest(apierror.CodePasskeyVerificationFailed, "Passkey verification failed"))
+		return
+	}
+
+	transports := make([]string, len(cred.Transport))
+	for i, t := range cred.Transport {
+		transports[i] = string(t)
+	}
+	name := req.Name
+	if name == "" {
+		name = "Passkey"
+	}
+	passkey := models.Passkey{
+		ID:             uuid.New(),
+		Name:           name,
+		BackupEligible: cred.Flags.BackupEligible,
+		CreatedAt:      time.Now().UTC(),
+	}
+	err = h.Passkeys.Create(c.Request.Context(), &repository.PasskeyCredential{
+		Passkey:         passkey,
+		UserID:          userID,
+		CredentialID:    cred.ID,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Transports:      transports,
+		Flags:           uint8(cred.Flags.ProtocolValue()),
+		AAGUID:          cred.Authenticator.AAGUID,
+		SignCount:       cred.Authenticator.SignCount,
+	})
+	if err == repository.ErrPasskeyExists {
+		apierror.Abort(c, apierror.Conflict(apierror.CodePasskeyAlreadyRegistered, "This passkey is already registered"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error storing passkey for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to register passkey"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, passkey)
+}
+
+// BeginPasskeyLogin starts a username-less login: the browser lets the user pick any passkey
+// registered for this site and the user is identified from the assertion's user handle.
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	if !h.requireWebAuthn(c) {
+		return
+	}
+
+	assertion, session, err := h.WebAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		log.Printf("Error beginning passkey login: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to start passkey login"))
+		return
+	}
+
+	ceremonyID, err := h.saveCeremony(c.Request.Context(), ceremonyLogin, nil, session)
+	if err != nil {
+		log.Printf("Error storing passkey login: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to start passkey login"))
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WebAuthnBeginResponse{CeremonyID: ceremonyID, Options: assertion})
+}
+
+// FinishPasskeyLogin verifies the assertion and issues tokens exactly like Login. A user-verifying
+// passkey already combines possession and a PIN or biometric, so no TOTP challenge follows it.
+func (h *AuthHandler) FinishPasskeyLogin(c *gin.Context) {
+	if !h.requireWebAuthn(c) {
+		return
+	}
+
+	var req models.WebAuthnLoginRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidPasskeyCredential, "Invalid passkey credential"))
+		return
+	}
+
+	_, session, err := h.takeCeremony(c.Request.Context(), req.CeremonyID, ceremonyLogin)
+	if err == errInvalidCeremony {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodePasskeyCeremonyExpired, "Invalid or expired passkey login, please start again"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading passkey login: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+
+	found, cred, err := h.WebAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		wu, err := h.loadWebAuthnUser(c.Request.Context(), userID)
+		if err != nil {
+			return nil, err
+		}
+		return wu, nil
+	}, *session, parsed)
+	if err != nil {
+		log.Printf("Passkey assertion rejected: %v", err)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodePasskeyVerificationFailed, "Passkey verification failed"))
+		return
+	}
+	wu := found.(*webauthnUser)
+
+	// A counter that didn't move forward means the private key may have been copied.
+	err = h.Passkeys.RecordUse(c.Request.Context(), wu.user.ID, cred.ID, cred.Authenticator.SignCount, cred.Authenticator.CloneWarning)
+	if err != nil {
+		log.Printf("Error updating passkey for user %s: %v", wu.user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to process login"))
+		return
+	}
+	if cred.Authenticator.CloneWarning {
+		log.Printf("Passkey for user %s failed the sign counter check, possible cloned authenticator", wu.user.ID)
+		apierror.Abort(c, apierror.Unauthorized(apierror.CodePasskeyVerificationFailed, "Passkey verification failed"))
+		return
+	}
+
+	if wu.user.PasswordResetRequired {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodePasswordResetRequired, "Password reset required"))
+		return
+	}
+
+	resp, err := h.issueTokens(c.Request.Context(), wu.user, deviceFromRequest(c, req.DeviceName))
+	if err != nil {
+		log.Printf("Error generating tokens: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to generate token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListPasskeys lists the authenticated user's passkeys.
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	passkeys, err := h.Passkeys.List(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("Error listing passkeys for user %s: %v", userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list passkeys"))
+		return
+	}
+
+	c.JSON(http.StatusOK, passkeys)
+}
+
+// DeletePasskey removes one of the authenticated user's passkeys.
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	passkeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid passkey ID format"))
+		return
+	}
+
+	err = h.Passkeys.Delete(c.Request.Context(), userID, passkeyID)
+	if err == repository.ErrPasskeyNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodePasskeyNotFound, "Passkey not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error deleting passkey %s for user %s: %v", passkeyID, userID, err)
+		apierror.Abort(c, apierror.Internal("Failed to delete passkey"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey deleted"})
+}
@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+)
+
+const (
+	testRPID   = "example.com"
+	testOrigin = "https://example.com"
+)
+
+// Authenticator data flags (WebAuthn section 6.1).
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+var b64 = base64.RawURLEncoding
+
+// softAuthenticator is a user-verifying platform authenticator holding one ES256 passkey, as a
+// browser would drive it through navigator.credentials.
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	credentialID := make([]byte, 32)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("generating credential ID: %v", err)
+	}
+	return &softAuthenticator{t: t, key: key, credentialID: credentialID}
+}
+
+// authenticatorData builds authenticator data for the test RP, with attested credential data when attested is set.
+func (a *softAuthenticator) authenticatorData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// clientData returns the clientDataJSON of a ceremony of the given type for challenge.
+func (a *softAuthenticator) clientData(ceremonyType, challenge string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"type": ceremonyType, "challenge": challenge, "origin": testOrigin})
+	if err != nil {
+		a.t.Fatalf("encoding client data: %v", err)
+	}
+	return data
+}
+
+// create answers the options of BeginPasskeyRegistration with a "none" attestation.
+func (a *softAuthenticator) create(options map[string]interface{}) json.RawMessage {
+	a.t.Helper()
+	publicKey := options["publicKey"].(map[string]interface{})
+	user := publicKey["user"].(map[string]interface{})
+	userHandle, err := b64.DecodeString(user["id"].(string))
+	if err != nil {
+		a.t.Fatalf("decoding user handle: %v", err)
+	}
+	a.userHandle = userHandle
+
+	coseKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{KeyType: int64(webauthncose.EllipticKey), Algorithm: int64(webauthncose.AlgES256)},
+		Curve:         1, // P-256
+		XCoord:        a.key.X.FillBytes(make([]byte, 32)),
+		YCoord:        a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatalf("encoding public key: %v", err)
+	}
+	attested := make([]byte, 16) // Zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authenticatorData(flagUserPresent|flagUserVerified|flagAttestedData, attested),
+	})
+	if err != nil {
+		a.t.Fatalf("encoding attestation object: %v", err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    b64.EncodeToString(a.clientData("webauthn.create", publicKey["challenge"].(string))),
+		"attestationObject": b64.EncodeToString(attestationObject),
+		"transports":        []string{"internal"},
+	})
+}
+
+// get answers the options of BeginPasskeyLogin, signing with signCount. A real authenticator
+// increments it before every assertion.
+func (a *softAuthenticator) get(options map[string]interface{}) json.RawMessage {
+	a.t.Helper()
+	publicKey := options["publicKey"].(map[string]interface{})
+	authData := a.authenticatorData(flagUserPresent|flagUserVerified, nil)
+	clientData := a.clientData("webauthn.get", publicKey["challenge"].(string))
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatalf("signing assertion: %v", err)
+	}
+
+	return a.credential(map[string]interface{}{
+		"clientDataJSON":    b64.EncodeToString(clientData),
+		"authenticatorData": b64.EncodeToString(authData),
+		"signature":         b64.EncodeToString(signature),
+		"userHandle":        b64.EncodeToString(a.userHandle),
+	})
+}
+
+// credential wraps an authenticator response in a PublicKeyCredential.
+func (a *softAuthenticator) credential(response map[string]interface{}) json.RawMessage {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       b64.EncodeToString(a.credentialID),
+		"rawId":    b64.EncodeToString(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		a.t.Fatalf("encoding credential: %v", err)
+	}
+	return data
+}
+
+// newPasskeyHandler returns an AuthHandler with passkeys enabled for the test RP.
+func newPasskeyHandler(t *testing.T) (*AuthHandler, *models.User) {
+	t.Helper()
+	h, repos := newTestHandler(t)
+	var err error
+	h.WebAuthn, err = webauthn.New(&webauthn.Config{RPID: testRPID, RPDisplayName: "Test", RPOrigins: []string{testOrigin}})
+	if err != nil {
+		t.Fatalf("configuring WebAuthn: %v", err)
+	}
+	return h, newTestUser(t, repos, "alice")
+}
+
+// beginCeremony calls a Begin handler and returns the ceremony ID and options.
+func beginCeremony(t *testing.T, userID uuid.UUID, handler gin.HandlerFunc) (uuid.UUID, map[string]interface{}) {
+	t.Helper()
+	w := serveJSON(userID, http.MethodPost, "/begin", nil, handler)
+	if w.Code != http.StatusOK {
+		t.Fatalf("begin: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp struct {
+		CeremonyID uuid.UUID              `json:"ceremony_id"`
+		Options    map[string]interface{} `json:"options"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding begin response: %v", err)
+	}
+	return resp.CeremonyID, resp.Options
+}
+
+// registerPasskey runs a registration ceremony for userID with the authenticator.
+func registerPasskey(t *testing.T, h *AuthHandler, userID uuid.UUID, a *softAuthenticator) {
+	t.Helper()
+	ceremonyID, options := beginCeremony(t, userID, h.BeginPasskeyRegistration)
+	req := models.WebAuthnRegisterRequest{CeremonyID: ceremonyID, Name: "Laptop", Credential: a.create(options)}
+	w := serveJSON(userID, http.MethodPost, "/finish", req, h.FinishPasskeyRegistration)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("finish registration: status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+}
+
+// loginWithPasskey runs a login ceremony with the authenticator.
+func loginWithPasskey(t *testing.T, h *AuthHandler, a *softAuthenticator) *httptest.ResponseRecorder {
+	t.Helper()
+	ceremonyID, options := beginCeremony(t, uuid.Nil, h.BeginPasskeyLogin)
+	req := models.WebAuthnLoginRequest{CeremonyID: ceremonyID, DeviceName: "Laptop", Credential: a.get(options)}
+	return serveJSON(uuid.Nil, http.MethodPost, "/finish", req, h.FinishPasskeyLogin)
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	ctx := context.Background()
+	h, user := newPasskeyHandler(t)
+	a := newSoftAuthenticator(t)
+
+	registerPasskey(t, h, user.ID, a)
+
+	stored, err := h.Passkeys.Credentials(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("loading passkeys: %v", err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("stored %d passkeys, want 1", len(stored))
+	}
+	if string(stored[0].CredentialID) != string(a.credentialID) || stored[0].Name != "Laptop" || stored[0].SignCount != 0 {
+		t.Errorf("stored passkey = %+v", stored[0])
+	}
+
+	for _, count := range []uint32{1, 2} {
+		a.signCount = count
+		w := loginWithPasskey(t, h, a)
+		if w.Code != http.StatusOK {
+			t.Fatalf("login with counter %d: status = %d, want %d: %s", count, w.Code, http.StatusOK, w.Body.String())
+		}
+		stored, err := h.Passkeys.Credentials(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("loading passkeys: %v", err)
+		}
+		if stored[0].SignCount != count || stored[0].CloneWarning || stored[0].LastUsedAt == nil {
+			t.Errorf("after login with counter %d: sign count = %d, clone warning = %v, last used = %v",
+				count, stored[0].SignCount, stored[0].CloneWarning, stored[0].LastUsedAt)
+		}
+	}
+}
+
+func TestPasskeyLoginRejectsCounterRegression(t *testing.T) {
+	ctx := context.Background()
+	h, user := newPasskeyHandler(t)
+	a := newSoftAuthenticator(t)
+	registerPasskey(t, h, user.ID, a)
+
+	a.signCount = 5
+	if w := loginWithPasskey(t, h, a); w.Code != http.StatusOK {
+		t.Fatalf("login: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	// A copy of the key that has signed less often than the original.
+	a.signCount = 3
+	w := loginWithPasskey(t, h, a)
+	if w.Code != http.StatusUnauthorized || problemCode(t, w) != apierror.CodePasskeyVerificationFailed {
+		t.Fatalf("login with regressed counter: status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+	stored, err := h.Passkeys.Credentials(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("loading passkeys: %v", err)
+	}
+	if !stored[0].CloneWarning || stored[0].SignCount != 5 {
+		t.Errorf("clone warning = %v, sign count = %d; want true, 5", stored[0].CloneWarning, stored[0].SignCount)
+	}
+
+	// The warning sticks: the passkey stays unusable even with a counter ahead of both copies.
+	a.signCount = 10
+	if w := loginWithPasskey(t, h, a); w.Code != http.StatusUnauthorized {
+		t.Errorf("login after clone warning: status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+}
+
+func TestPasskeyLoginIssuesLoginTokens(t *testing.T) {
+	ctx := context.Background()
+	h, user := newPasskeyHandler(t)
+	a := newSoftAuthenticator(t)
+	registerPasskey(t, h, user.ID, a)
+
+	a.signCount = 1
+	w := loginWithPasskey(t, h, a)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp models.LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.ExpiresIn != int64(h.AccessTokenTTL.Seconds()) || resp.User == nil || resp.User.ID != user.ID {
+		t.Errorf("response = %+v, want access and refresh tokens for %s", resp, user.ID)
+	}
+
+	// Like a password login, the tokens belong to a registered session: the access token passes
+	// AuthMiddleware and the refresh token is stored for it.
+	sessions, err := h.Sessions.ListActive(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("listing sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].DeviceName != "Laptop" {
+		t.Fatalf("sessions = %+v, want one named Laptop", sessions)
+	}
+	refresh, err := h.Sessions.GetRefreshToken(ctx, opaquetoken.Hash(resp.RefreshToken))
+	if err != nil || refresh.SessionID != sessions[0].ID {
+		t.Errorf("refresh token: %+v, %v; want one in session %s", refresh, err, sessions[0].ID)
+	}
+
+	router := gin.New()
+	router.GET("/me", h.AuthMiddleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("userID"), "session_id": c.MustGet("sessionID")})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+resp.Token)
+	me := httptest.NewRecorder()
+	router.ServeHTTP(me, req)
+	if me.Code != http.StatusOK {
+		t.Fatalf("AuthMiddleware: status = %d, want %d: %s", me.Code, http.StatusOK, me.Body.String())
+	}
+	var claims struct {
+		UserID    uuid.UUID `json:"user_id"`
+		SessionID uuid.UUID `json:"session_id"`
+	}
+	if err := json.Unmarshal(me.Body.Bytes(), &claims); err != nil {
+		t.Fatalf("decoding claims: %v", err)
+	}
+	if claims.UserID != user.ID || claims.SessionID != sessions[0].ID {
+		t.Errorf("authenticated as %+v, want user %s in session %s", claims, user.ID, sessions[0].ID)
+	}
+}
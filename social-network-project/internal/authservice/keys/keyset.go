@@ -0,0 +1,170 @@
+// Package keys manages the asymmetric keys auth-service signs JWTs with.
+//
+// Keys are PEM-encoded PKCS#8 (or PKCS#1 for RSA) private keys stored one per file in a
+// directory; the file name without its extension is the key ID ("kid"). Every key in the
+// directory is published in the JWKS and accepted for verification, but only the active
+// one signs. Rotation is therefore staged:
+//
+//  1. add the new key file and restart, so downstream JWKS caches pick it up;
+//  2. point JWT_SIGNING_KID at the new key and restart, so new tokens use it;
+//  3. once tokens signed by the old key have expired, delete its file and restart.
+package keys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/jwks"
+)
+
+// SigningKey is a private key together with its key ID and JWT signing method.
+type SigningKey struct {
+	ID      string
+	Private crypto.Signer
+	Method  jwt.SigningMethod
+}
+
+// KeySet holds every known signing key and which one is currently active.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// newSigningKey picks the JWT signing method that matches the private key type.
+func newSigningKey(kid string, private interface{}) (*SigningKey, error) {
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{ID: kid, Private: key, Method: jwt.SigningMethodRS256}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{ID: kid, Private: key, Method: jwt.SigningMethodEdDSA}, nil
+	default:
+		return nil, fmt.Errorf("keys: unsupported private key type %T for kid %q", private, kid)
+	}
+}
+
+// LoadDir loads every *.pem file in dir and marks activeKID as the signing key.
+func LoadDir(dir, activeKID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("keys: listing %s: %w", dir, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("keys: no *.pem files found in %s", dir)
+	}
+
+	ks := &KeySet{keys: map[string]*SigningKey{}}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("keys: reading %s: %w", path, err)
+		}
+		key, err := parsePrivateKeyPEM(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		ks.keys[kid] = key
+	}
+
+	active, ok := ks.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("keys: active key %q not found in %s", activeKID, dir)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+func parsePrivateKeyPEM(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("keys: no PEM block found for kid %q", kid)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("keys: parsing kid %q: %w", kid, err)
+		}
+		return newSigningKey(kid, private)
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("keys: parsing kid %q: %w", kid, err)
+		}
+		return newSigningKey(kid, private)
+	default:
+		return nil, fmt.Errorf("keys: unsupported PEM block %q for kid %q", block.Type, kid)
+	}
+}
+
+// GenerateEphemeral creates a KeySet with a single random Ed25519 key.
+// Intended for local development only: tokens stop verifying when the process restarts.
+func GenerateEphemeral() (*KeySet, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	key, err := newSigningKey("dev-"+uuid.NewString(), private)
+	if err != nil {
+		return nil, err
+	}
+	return &KeySet{active: key, keys: map[string]*SigningKey{key.ID: key}}, nil
+}
+
+// ActiveKID returns the ID of the key new tokens are signed with.
+func (ks *KeySet) ActiveKID() string {
+	return ks.active.ID
+}
+
+// Sign signs the claims with the active key and sets the "kid" header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.Method, claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.Private)
+}
+
+// Keyfunc is a jwt.Keyfunc verifying tokens against any key in the set, active or not.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, jwks.ErrUnknownKey
+	}
+	public := key.Private.Public()
+	if err := jwks.CheckMethod(token, public); err != nil {
+		return nil, err
+	}
+	return public, nil
+}
+
+// JWKS returns the public half of every key, sorted by kid for stable output.
+func (ks *KeySet) JWKS() (jwks.Set, error) {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	set := jwks.Set{Keys: make([]jwks.JSONWebKey, 0, len(kids))}
+	for _, kid := range kids {
+		jwk, err := jwks.FromPublicKey(kid, ks.keys[kid].Private.Public())
+		if err != nil {
+			return jwks.Set{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
@@ -0,0 +1,80 @@
+// Package notify delivers account notifications (verification links, password resets, ...)
+// to users. Handlers depend on the Notifier interface so the channel can be swapped.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/social-network/pkg/mailer"
+)
+
+// Notifier sends account notifications to a single recipient address.
+type Notifier interface {
+	// VerifyEmail sends the link that confirms ownership of an email address.
+	VerifyEmail(ctx context.Context, to, link string, ttl time.Duration) error
+	// PasswordReset sends the single-use link for choosing a new password.
+	PasswordReset(ctx context.Context, to, username, link string, ttl time.Duration) error
+	// AccountLocked warns that logins were locked after too many failed attempts.
+	AccountLocked(ctx context.Context, to, username string, until time.Time) error
+	// EmailChangeRequested warns the current address that a change to newEmail was requested.
+	EmailChangeRequested(ctx context.Context, to, username, newEmail string) error
+	// EmailInUse tells the owner of an address that someone tried to use it for another account.
+	EmailInUse(ctx context.Context, to, username string) error
+}
+
+// EmailNotifier delivers notifications as plain-text emails.
+type EmailNotifier struct {
+	Mailer mailer.Mailer
+}
+
+// VerifyEmail implements Notifier.
+func (n *EmailNotifier) VerifyEmail(ctx context.Context, to, link string, ttl time.Duration) error {
+	return n.Mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Confirm this email address for your account by opening the link below:\n\n%s\n\nThe link expires in %s. If you didn't request this, you can ignore this email.\n",
+			link, ttl),
+	})
+}
+
+// PasswordReset implements Notifier.
+func (n *EmailNotifier) PasswordReset(ctx context.Context, to, username, link string, ttl time.Duration) error {
+	return n.Mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Someone asked to reset the password for the account %q. To choose a new password, open the link below:\n\n%s\n\nThe link can be used once and expires in %s. If you didn't request this, you can ignore this email; your password has not been changed.\n",
+			username, link, ttl),
+	})
+}
+
+// AccountLocked implements Notifier.
+func (n *EmailNotifier) AccountLocked(ctx context.Context, to, username string, until time.Time) error {
+	return n.Mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: "Your account was temporarily locked",
+		Body: fmt.Sprintf("There were too many failed login attempts for the account %q, so logging in is blocked until %s.\n\nIf this wasn't you, someone may be trying to guess your password. Consider changing it once you can log in again, and turning on two-factor authentication.\n",
+			username, until.UTC().Format(time.RFC1123)),
+	})
+}
+
+// EmailChangeRequested implements Notifier.
+func (n *EmailNotifier) EmailChangeRequested(ctx context.Context, to, username, newEmail string) error {
+	return n.Mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: "Your email address is about to change",
+		Body: fmt.Sprintf("Someone asked to change the email address of the account %q to %s. The change takes effect once the link sent to that address is opened; until then this address stays on the account.\n\nIf this wasn't you, someone knows your password. Change it now and consider turning on two-factor authentication.\n",
+			username, newEmail),
+	})
+}
+
+// EmailInUse implements Notifier.
+func (n *EmailNotifier) EmailInUse(ctx context.Context, to, username string) error {
+	return n.Mailer.Send(ctx, mailer.Message{
+		To:      to,
+		Subject: "You already have an account",
+		Body: fmt.Sprintf("Someone tried to use this email address for another account, but it already belongs to your account %q. If that was you, log in to that account instead, or reset its password if you forgot it.\n\nIf it wasn't you, you can ignore this email; nothing was changed.\n",
+			username),
+	})
+}
@@ -0,0 +1,85 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// PrefixLength is the number of hex characters of the SHA-1 hash sent to a RangeSource.
+const PrefixLength = 5
+
+// RangeSource looks up breached passwords by k-anonymity, as the Pwned Passwords range API does:
+// callers only reveal the first PrefixLength hex characters of the password's SHA-1 hash and
+// compare the returned suffixes themselves, so the source never learns which password was checked.
+type RangeSource interface {
+	// Range returns the uppercase hex suffixes of every known hash starting with prefix.
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// IsBreached reports whether the password's hash is among those known to source.
+func IsBreached(ctx context.Context, source RangeSource, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	suffixes, err := source.Range(ctx, hash[:PrefixLength])
+	if err != nil {
+		return false, err
+	}
+	_, found := slices.BinarySearch(suffixes, hash[PrefixLength:])
+	return found, nil
+}
+
+// Corpus is an in-memory RangeSource loaded from a file.
+type Corpus struct {
+	ranges map[string][]string // Sorted suffixes by prefix
+	size   int
+}
+
+// LoadCorpus reads a breached-password corpus in the Pwned Passwords download format: one SHA-1
+// hash per line in hex, optionally followed by ":count". Blank lines and lines starting with # are skipped.
+func LoadCorpus(path string) (*Corpus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	corpus := &Corpus{ranges: map[string][]string{}}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(entry, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("%s:%d: not a SHA-1 hash", path, line)
+		}
+		prefix := hash[:PrefixLength]
+		corpus.ranges[prefix] = append(corpus.ranges[prefix], hash[PrefixLength:])
+		corpus.size++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, suffixes := range corpus.ranges {
+		slices.Sort(suffixes)
+	}
+	return corpus, nil
+}
+
+// Len returns the number of hashes in the corpus.
+func (c *Corpus) Len() int {
+	return c.size
+}
+
+// Range implements RangeSource.
+func (c *Corpus) Range(_ context.Context, prefix string) ([]string, error) {
+	return c.ranges[strings.ToUpper(prefix)], nil
+}
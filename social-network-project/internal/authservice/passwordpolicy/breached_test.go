@@ -0,0 +1,105 @@
+package passwordpolicy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// passwordSHA1 is the SHA-1 hash of "password", as listed in the Pwned Passwords corpus.
+const passwordSHA1 = "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8"
+
+// writeCorpus writes a corpus file with the given content and returns its path.
+func writeCorpus(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing corpus: %v", err)
+	}
+	return path
+}
+
+// failingSource is a RangeSource that is always unavailable.
+type failingSource struct{}
+
+func (failingSource) Range(ctx context.Context, prefix string) ([]string, error) {
+	return nil, errors.New("range source unavailable")
+}
+
+func TestLoadCorpus(t *testing.T) {
+	corpus, err := LoadCorpus(writeCorpus(t, "# Pwned Passwords sample\n"+
+		passwordSHA1+":9545824\n"+
+		"\n"+
+		"7c4a8d09ca3762af61e59520943dc26494f8941b\n"+ // "123456", lowercase and without a count
+		"5BAA6FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1\n"))
+	if err != nil {
+		t.Fatalf("LoadCorpus: %v", err)
+	}
+	if corpus.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", corpus.Len())
+	}
+
+	suffixes, err := corpus.Range(context.Background(), "5baa6")
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if want := []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"}; !slices.Equal(suffixes, want) {
+		t.Errorf("Range(5baa6) = %v, want %v", suffixes, want)
+	}
+	if suffixes, _ := corpus.Range(context.Background(), "00000"); len(suffixes) != 0 {
+		t.Errorf("Range(00000) = %v, want none", suffixes)
+	}
+}
+
+func TestLoadCorpusRejectsMalformedLines(t *testing.T) {
+	for _, line := range []string{
+		"not a hash",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD",   // 39 characters
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8A", // 41 characters
+		"ZBAA61E4C9B93F3F0682250B6CF8331B7EE68FD8",
+	} {
+		if _, err := LoadCorpus(writeCorpus(t, passwordSHA1+"\n"+line+"\n")); err == nil {
+			t.Errorf("LoadCorpus accepted %q", line)
+		}
+	}
+	if _, err := LoadCorpus(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadCorpus accepted a missing file")
+	}
+}
+
+func TestCheckBreached(t *testing.T) {
+	corpus, err := LoadCorpus(writeCorpus(t, passwordSHA1+"\n7C4A8D09CA3762AF61E59520943DC26494F8941B\n"))
+	if err != nil {
+		t.Fatalf("LoadCorpus: %v", err)
+	}
+	tests := []struct {
+		name     string
+		source   RangeSource
+		password string
+		want     bool
+	}{
+		{"breached", corpus, "password", true},
+		{"breached digits", corpus, "123456", true},
+		{"different case", corpus, "Password", false},
+		{"not breached", corpus, "correct horse battery staple", false},
+		{"empty is not looked up", corpus, "", false},
+		// An unavailable source must not stop people from choosing a password.
+		{"source failing", failingSource{}, "password", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{Breached: tt.source}
+			got := slices.Contains(rules(p.Check(context.Background(), tt.password, Account{})), RuleBreached)
+			if got != tt.want {
+				t.Errorf("Check(%q): breached = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := IsBreached(context.Background(), failingSource{}, "password"); err == nil {
+		t.Error("IsBreached hid the source's error")
+	}
+}
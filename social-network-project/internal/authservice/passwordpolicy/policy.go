@@ -0,0 +1,133 @@
+// Package passwordpolicy decides whether a new password is acceptable: length limits (including
+// bcrypt's 72-byte input limit), character classes, similarity to the account's username or email,
+// and whether the password is known from a data breach.
+package passwordpolicy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBytes is the longest password bcrypt hashes in full. Anything past it would be silently ignored.
+const MaxBytes = 72
+
+// Rules reported in a Violation.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleMaxBytes         = "max_bytes"
+	RuleCharacterClasses = "character_classes"
+	RuleUserSimilarity   = "user_similarity"
+	RuleBreached         = "breached"
+)
+
+// Violation is one rule a password failed. Rule is stable for clients to match on; Message is for display.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Account identifies whose password is being checked, for the similarity rule.
+type Account struct {
+	Username string
+	Email    string
+}
+
+// Policy configures the rules. Lengths count characters, not bytes.
+type Policy struct {
+	MinLength           int         // Minimum number of characters
+	MaxLength           int         // Maximum number of characters; zero means only MaxBytes applies
+	MinCharacterClasses int         // How many of lowercase, uppercase, digits and symbols must appear
+	RejectUserSimilar   bool        // Reject passwords containing the username or email local part
+	Breached            RangeSource // Known-breached password hashes; nil disables the check
+}
+
+// Default returns the policy auth-service uses unless configured otherwise. The breach check is
+// only enabled once a corpus is loaded.
+func Default() *Policy {
+	return &Policy{
+		MinLength:           8,
+		MaxLength:           100,
+		MinCharacterClasses: 2,
+		RejectUserSimilar:   true,
+	}
+}
+
+// Check returns every rule the password violates; an empty result means it is acceptable.
+// A failing breach lookup is logged and skipped rather than blocking the user.
+func (p *Policy) Check(ctx context.Context, password string, account Account) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("Password must be at least %d characters long", p.MinLength)})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{RuleMaxLength, fmt.Sprintf("Password must be at most %d characters long", p.MaxLength)})
+	}
+	if len(password) > MaxBytes {
+		violations = append(violations, Violation{RuleMaxBytes, fmt.Sprintf("Password must be at most %d bytes long", MaxBytes)})
+	}
+	if classes := characterClasses(password); classes < p.MinCharacterClasses {
+		violations = append(violations, Violation{RuleCharacterClasses,
+			fmt.Sprintf("Password must use at least %d of: lowercase letters, uppercase letters, digits, symbols", p.MinCharacterClasses)})
+	}
+	if p.RejectUserSimilar && similarToAccount(password, account) {
+		violations = append(violations, Violation{RuleUserSimilarity, "Password must not contain your username or email address"})
+	}
+
+	if p.Breached != nil && length > 0 {
+		breached, err := IsBreached(ctx, p.Breached, password)
+		if err != nil {
+			log.Printf("Error checking password against breach corpus: %v", err)
+		} else if breached {
+			violations = append(violations, Violation{RuleBreached, "This password has appeared in a data breach and can't be used"})
+		}
+	}
+	return violations
+}
+
+// characterClasses counts which of lowercase, uppercase, digits and anything else appear.
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			n++
+		}
+	}
+	return n
+}
+
+// similarToAccount reports whether the password contains the username or the email's local part,
+// ignoring case. Parts shorter than 3 characters are too likely to match by accident.
+func similarToAccount(password string, account Account) bool {
+	password = strings.ToLower(password)
+	parts := []string{account.Username}
+	if local, _, ok := strings.Cut(account.Email, "@"); ok {
+		parts = append(parts, local)
+	}
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if utf8.RuneCountInString(part) >= 3 && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package passwordpolicy
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// rules returns the rules of the violations, in order.
+func rules(violations []Violation) []string {
+	var r []string
+	for _, v := range violations {
+		r = append(r, v.Rule)
+	}
+	return r
+}
+
+func TestCheckLength(t *testing.T) {
+	p := &Policy{MinLength: 8, MaxLength: 20}
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"empty", "", []string{RuleMinLength}},
+		{"one short", "abcdefg", []string{RuleMinLength}},
+		{"minimum", "abcdefgh", nil},
+		{"maximum", strings.Repeat("a", 20), nil},
+		{"one long", strings.Repeat("a", 21), []string{RuleMaxLength}},
+		// Lengths count characters: eight two-byte characters are long enough.
+		{"multibyte minimum", strings.Repeat("é", 8), nil},
+		{"multibyte short", strings.Repeat("é", 7), []string{RuleMinLength}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(p.Check(context.Background(), tt.password, Account{})); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMaxBytes(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxLength int
+		password  string
+		want      []string
+	}{
+		{"72 bytes", 0, strings.Repeat("a", MaxBytes), nil},
+		{"73 bytes", 0, strings.Repeat("a", MaxBytes+1), []string{RuleMaxBytes}},
+		// 40 characters are within MaxLength, but 80 bytes would be cut off by bcrypt.
+		{"multibyte", 100, strings.Repeat("é", 40), []string{RuleMaxBytes}},
+		{"both limits", 50, strings.Repeat("a", 51) + strings.Repeat("é", 11), []string{RuleMaxLength, RuleMaxBytes}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{MaxLength: tt.maxLength}
+			if got := rules(p.Check(context.Background(), tt.password, Account{})); !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%d bytes) = %v, want %v", len(tt.password), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCharacterClasses(t *testing.T) {
+	p := &Policy{MinCharacterClasses: 3}
+	tests := []struct {
+		password string
+		classes  int
+	}{
+		{"abcdefgh", 1},
+		{"abcdEFGH", 2},
+		{"abcdEF12", 3},
+		{"abCD12!?", 4},
+		{"ab cd 12", 3}, // A space counts as a symbol
+		{"ÄÖÜäöü12", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := characterClasses(tt.password); got != tt.classes {
+				t.Errorf("characterClasses(%q) = %d, want %d", tt.password, got, tt.classes)
+			}
+			violated := slices.Contains(rules(p.Check(context.Background(), tt.password, Account{})), RuleCharacterClasses)
+			if violated != (tt.classes < 3) {
+				t.Errorf("Check(%q): character_classes violated = %v, want %v", tt.password, violated, tt.classes < 3)
+			}
+		})
+	}
+}
+
+func TestCheckUserSimilarity(t *testing.T) {
+	account := Account{Username: "alice_smith", Email: "Wonderland@example.com"}
+	tests := []struct {
+		name     string
+		account  Account
+		password string
+		want     bool
+	}{
+		{"unrelated", account, "correct horse battery", false},
+		{"contains username", account, "my alice_smith pass", true},
+		{"username in other case", account, "ALICE_SMITH!", true},
+		{"contains email local part", account, "down the wonderland hole", true},
+		{"contains email domain only", account, "example.com rocks", false},
+		{"part of username", account, "alice rocks", false},
+		// Usernames shorter than 3 characters would match too many passwords.
+		{"short username", Account{Username: "al"}, "all good things", false},
+		{"no email", Account{Username: "bob"}, "bobsleigh team", true},
+	}
+	p := &Policy{RejectUserSimilar: true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Contains(rules(p.Check(context.Background(), tt.password, tt.account)), RuleUserSimilarity)
+			if got != tt.want {
+				t.Errorf("Check(%q) for %+v: user_similarity violated = %v, want %v", tt.password, tt.account, got, tt.want)
+			}
+		})
+	}
+
+	if got := (&Policy{}).Check(context.Background(), "alice_smith", account); len(got) != 0 {
+		t.Errorf("with RejectUserSimilar off: Check = %v, want no violations", rules(got))
+	}
+}
+
+func TestCheckDefault(t *testing.T) {
+	tests := []struct {
+		password string
+		want     []string
+	}{
+		{"Tr0ub4dor&3", nil},
+		{"short1", []string{RuleMinLength}},
+		{"alllowercase", []string{RuleCharacterClasses}},
+		{"bob12345", []string{RuleUserSimilarity}},
+		{"bob", []string{RuleMinLength, RuleCharacterClasses, RuleUserSimilarity}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got := rules(Default().Check(context.Background(), tt.password, Account{Username: "bob"}))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/throttle"
+	"github.com/yourusername/social-network/pkg/validation"
+)
+
+// Audit log action names.
+const (
+	auditActionDeactivate         = "user.deactivate"
+	auditActionReactivate         = "user.reactivate"
+	auditActionForcePasswordReset = "user.force_password_reset"
+	auditActionAssignRoles        = "user.assign_roles"
+	auditActionUnlock             = "user.unlock"
+	defaultAdminPageSize          = 50
+	maxAdminPageSize              = 200
+)
+
+// RequirePermission allows the request through if any of the caller's roles grants the permission.
+// Permissions are looked up in the database rather than the token so that revoking a role
+// takes effect immediately for the admin API.
+func (h *UserHandler) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.MustGet("userID").(uuid.UUID)
+
+		allowed, err := h.Roles.HasPermission(c.Request.Context(), userID, permission)
+		if err != nil {
+			log.Printf("Error checking permission %s for user %s: %v", permission, userID, err)
+			apierror.Abort(c, apierror.Internal("Failed to check permissions"))
+			return
+		}
+		if !allowed {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeMissingPermission, "Missing permission").With("required_permission", permission))
+			return
+		}
+		c.Next()
+	}
+}
+
+// recordAudit writes an admin action to the audit log. details may be nil.
+func recordAudit(ctx context.Context, audit repository.AuditRepository, actorID uuid.UUID, action string, targetUserID *uuid.UUID, details interface{}) error {
+	entry := models.AuditLogEntry{
+		ID:           uuid.New(),
+		ActorID:      actorID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if details != nil {
+		detailsJSON, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		entry.Details = detailsJSON
+	}
+	return audit.Record(ctx, &entry)
+}
+
+// pageParams reads limit/offset query parameters with sane bounds.
+func pageParams(c *gin.Context, defaultLimit, maxLimit int) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// AdminListUsers lists users, optionally filtered by a search term (?q=) on username,
+// display name and email and by activity (?active=true|false).
+func (h *UserHandler) AdminListUsers(c *gin.Context) {
+	limit, offset := pageParams(c, defaultAdminPageSize, maxAdminPageSize)
+
+	filter := repository.UserFilter{Query: strings.TrimSpace(c.Query("q")), Limit: limit, Offset: offset}
+	if active := c.Query("active"); active != "" {
+		isActive, err := strconv.ParseBool(active)
+		if err != nil {
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest, "Invalid value for active, expected true or false"))
+			return
+		}
+		filter.Active = &isActive
+	}
+
+	found, err := h.Users.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Admin: error listing users: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to list users"))
+		return
+	}
+	userIDs := make([]uuid.UUID, len(found))
+	for i, u := range found {
+		userIDs[i] = u.ID
+	}
+	roles, err := h.Roles.RolesByUser(c.Request.Context(), userIDs)
+	if err != nil {
+		log.Printf("Admin: error listing user roles: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to list users"))
+		return
+	}
+
+	users := make([]models.AdminUserView, len(found))
+	for i, u := range found {
+		users[i] = adminUserView(u, roles[u.ID])
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users, "limit": limit, "offset": offset})
+}
+
+// adminUserView combines a user with their roles; roles may be nil.
+func adminUserView(user models.User, roles []string) models.AdminUserView {
+	if roles == nil {
+		roles = []string{}
+	}
+	return models.AdminUserView{User: user, Roles: roles, PasswordResetRequired: user.PasswordResetRequired}
+}
+
+// fetchAdminUser loads a single user with roles. Returns repository.ErrNotFound if the user doesn't exist.
+func (h *UserHandler) fetchAdminUser(ctx context.Context, userID uuid.UUID) (*models.AdminUserView, error) {
+	user, err := h.Users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := h.Roles.RolesByUser(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+	view := adminUserView(*user, roles[userID])
+	return &view, nil
+}
+
+// adminTargetUserID parses the :userId path parameter, writing a 400 response on failure.
+func adminTargetUserID(c *gin.Context) (uuid.UUID, bool) {
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return uuid.Nil, false
+	}
+	return targetUserID, true
+}
+
+// AdminGetUser returns a single user including roles and account flags.
+func (h *UserHandler) AdminGetUser(c *gin.Context) {
+	targetUserID, ok := adminTargetUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.fetchAdminUser(c.Request.Context(), targetUserID)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Admin: error fetching user %s: %v", targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch user"))
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// grantsAll reports whether every permission in want is in have.
+func grantsAll(have, want []string) bool {
+	for _, permission := range want {
+		if !slices.Contains(have, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkAdminTarget rejects admin actions on the caller's own account and on users holding a role
+// the caller lacks, so support staff can't lock out administrators and nobody can lock themselves
+// out. A role counts as held when the caller's roles grant all of its permissions, so admins can
+// act on support staff.
+func (h *UserHandler) checkAdminTarget(c *gin.Context, actorID, targetUserID uuid.UUID) bool {
+	if targetUserID == actorID {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeCannotManageSelf, "Administrators can't perform this action on their own account"))
+		return false
+	}
+
+	ctx := c.Request.Context()
+	userRoles, err := h.Roles.RolesByUser(ctx, []uuid.UUID{actorID, targetUserID})
+	if err != nil {
+		log.Printf("Admin: error loading roles of %s and %s: %v", actorID, targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return false
+	}
+	if len(userRoles[targetUserID]) == 0 {
+		return true
+	}
+	roles, err := h.Roles.List(ctx)
+	if err != nil {
+		log.Printf("Admin: error listing roles: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return false
+	}
+
+	permissions := map[string][]string{}
+	for _, role := range roles {
+		permissions[role.Name] = role.Permissions
+	}
+	var actorPermissions []string
+	for _, role := range userRoles[actorID] {
+		actorPermissions = append(actorPermissions, permissions[role]...)
+	}
+	var missing []string
+	for _, role := range userRoles[targetUserID] {
+		if !slices.Contains(userRoles[actorID], role) && !grantsAll(actorPermissions, permissions[role]) {
+			missing = append(missing, role)
+		}
+	}
+	if len(missing) > 0 {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeTargetRoleNotHeld, "The user holds a role you don't have").With("roles", missing))
+		return false
+	}
+	return true
+}
+
+// adminUpdateUser runs update against the target user in a transaction together with its audit
+// record, then responds with the updated user. See checkAdminTarget for the users that can't be updated.
+func (h *UserHandler) adminUpdateUser(c *gin.Context, action string, details interface{}, update func(tx *sql.Tx, users repository.UserRepository, target *models.User) error) {
+	actorID := c.MustGet("userID").(uuid.UUID)
+	targetUserID, ok := adminTargetUserID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	tx, err := h.Tx.Begin(ctx)
+	if err != nil {
+		log.Printf("Admin: error starting transaction for %s on %s: %v", action, targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return
+	}
+	defer tx.Rollback()
+
+	users := h.Users.WithTx(tx.SQL())
+	target, err := users.GetByID(ctx, targetUserID)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Admin: error checking user %s: %v", targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return
+	}
+
+	if !h.checkAdminTarget(c, actorID, target.ID) {
+		return
+	}
+
+	if err := update(tx.SQL(), users, target); err != nil {
+		log.Printf("Admin: error performing %s on %s: %v", action, targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return
+	}
+	if err := recordAudit(ctx, h.Audit.WithTx(tx.SQL()), actorID, action, &targetUserID, details); err != nil {
+		log.Printf("Admin: error recording audit entry for %s on %s: %v", action, targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Admin: error committing %s on %s: %v", action, targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update user"))
+		return
+	}
+
+	user, err := h.fetchAdminUser(ctx, targetUserID)
+	if err != nil {
+		log.Printf("Admin: error fetching user %s after %s: %v", targetUserID, action, err)
+		c.JSON(http.StatusOK, gin.H{"message": "User updated"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// AdminDeactivateUser sets is_active to false and signs the user out everywhere.
+func (h *UserHandler) AdminDeactivateUser(c *gin.Context) {
+	h.adminUpdateUser(c, auditActionDeactivate, nil, func(tx *sql.Tx, users repository.UserRepository, target *models.User) error {
+		if err := users.SetActive(c.Request.Context(), target.ID, false); err != nil {
+			return err
+		}
+		return h.Sessions.WithTx(tx).RevokeAll(c.Request.Context(), target.ID)
+	})
+}
+
+// AdminReactivateUser sets is_active back to true.
+func (h *UserHandler) AdminReactivateUser(c *gin.Context) {
+	h.adminUpdateUser(c, auditActionReactivate, nil, func(tx *sql.Tx, users repository.UserRepository, target *models.User) error {
+		return users.SetActive(c.Request.Context(), target.ID, true)
+	})
+}
+
+// AdminForcePasswordReset flags the account so it can't log in until the password is reset,
+// and signs the user out everywhere.
+func (h *UserHandler) AdminForcePasswordReset(c *gin.Context) {
+	h.adminUpdateUser(c, auditActionForcePasswordReset, nil, func(tx *sql.Tx, users repository.UserRepository, target *models.User) error {
+		if err := users.RequirePasswordReset(c.Request.Context(), target.ID); err != nil {
+			return err
+		}
+		return h.Sessions.WithTx(tx).RevokeAll(c.Request.Context(), target.ID)
+	})
+}
+
+// AdminUnlockUser lifts a login lockout on the account and clears its failed attempts.
+func (h *UserHandler) AdminUnlockUser(c *gin.Context) {
+	h.adminUpdateUser(c, auditActionUnlock, nil, func(tx *sql.Tx, users repository.UserRepository, target *models.User) error {
+		return h.LoginThrottle.WithTx(tx).Reset(c.Request.Context(), throttle.UsernameKey(target.Username))
+	})
+}
+
+// AdminAssignRoles replaces the roles assigned to a user. New roles show up in the user's
+// access tokens the next time they are refreshed.
+func (h *UserHandler) AdminAssignRoles(c *gin.Context) {
+	var req models.AssignRolesRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
+
+	unknown, err := h.Roles.Unknown(c.Request.Context(), req.Roles)
+	if err != nil {
+		log.Printf("Admin: error validating roles %v: %v", req.Roles, err)
+		apierror.Abort(c, apierror.Internal("Failed to assign roles"))
+		return
+	}
+	if len(unknown) > 0 {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeUnknownRoles, "Unknown roles").With("roles", unknown))
+		return
+	}
+
+	actorID := c.MustGet("userID").(uuid.UUID)
+	h.adminUpdateUser(c, auditActionAssignRoles, gin.H{"roles": req.Roles}, func(tx *sql.Tx, users repository.UserRepository, target *models.User) error {
+		return h.Roles.WithTx(tx).SetUserRoles(c.Request.Context(), target.ID, req.Roles, actorID)
+	})
+}
+
+// AdminListRoles lists every role with its permissions.
+func (h *UserHandler) AdminListRoles(c *gin.Context) {
+	roles, err := h.Roles.List(c.Request.Context())
+	if err != nil {
+		log.Printf("Admin: error listing roles: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to list roles"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// AdminListAuditLog returns audit entries, newest first, optionally filtered by ?user_id=.
+func (h *UserHandler) AdminListAuditLog(c *gin.Context) {
+	limit, offset := pageParams(c, defaultAdminPageSize, maxAdminPageSize)
+
+	filter := repository.AuditFilter{Limit: limit, Offset: offset}
+	if userIDParam := c.Query("user_id"); userIDParam != "" {
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	entries, err := h.Audit.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Admin: error listing audit log: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to list audit log"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "limit": limit, "offset": offset})
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/throttle"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestUser stores an active user in repos and returns it.
+func newTestUser(t *testing.T, repos *repository.Repositories, username string) *models.User {
+	t.Helper()
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:        uuid.New(),
+		Username:  username,
+		CreatedAt: now,
+		UpdatedAt: now,
+		IsActive:  true,
+	}
+	if err := repos.Users.Create(context.Background(), user); err != nil {
+		t.Fatalf("creating user %s: %v", username, err)
+	}
+	return user
+}
+
+// newTestSession stores an active session for the user.
+func newTestSession(t *testing.T, repos *repository.Repositories, userID uuid.UUID) {
+	t.Helper()
+	now := time.Now().UTC()
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+	if err := repos.Sessions.Create(context.Background(), session); err != nil {
+		t.Fatalf("creating session: %v", err)
+	}
+}
+
+// serveAs sends the request through handlers as if AuthMiddleware had authenticated callerID.
+func serveAs(callerID uuid.UUID, method, route, path string, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(apierror.Middleware(), func(c *gin.Context) {
+		c.Set("userID", callerID)
+		c.Next()
+	})
+	router.Handle(method, route, handlers...)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// problemCode returns the code member of a problem document.
+func problemCode(t *testing.T, w *httptest.ResponseRecorder) apierror.Code {
+	t.Helper()
+	var problem struct {
+		Code apierror.Code `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("decoding problem document %q: %v", w.Body.String(), err)
+	}
+	return problem.Code
+}
+
+func TestAdminDeactivateUserRequiresPermission(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	h := NewUserHandler(repos, nil)
+	caller := newTestUser(t, repos, "caller")
+	target := newTestUser(t, repos, "target")
+
+	w := serveAs(caller.ID, http.MethodPost, "/admin/users/:userId/deactivate", "/admin/users/"+target.ID.String()+"/deactivate",
+		h.RequirePermission(models.PermUsersDeactivate), h.AdminDeactivateUser)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusForbidden, w.Body.String())
+	}
+	if code := problemCode(t, w); code != apierror.CodeMissingPermission {
+		t.Errorf("code = %q, want %q", code, apierror.CodeMissingPermission)
+	}
+	stored, err := repos.Users.GetByID(context.Background(), target.ID)
+	if err != nil {
+		t.Fatalf("loading target: %v", err)
+	}
+	if !stored.IsActive {
+		t.Error("target was deactivated without permission")
+	}
+}
+
+func TestAdminDeactivateUser(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	h := NewUserHandler(repos, nil)
+	admin := newTestUser(t, repos, "admin")
+	target := newTestUser(t, repos, "target")
+	if _, err := repos.Roles.Grant(ctx, admin.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("granting admin role: %v", err)
+	}
+	newTestSession(t, repos, target.ID)
+
+	w := serveAs(admin.ID, http.MethodPost, "/admin/users/:userId/deactivate", "/admin/users/"+target.ID.String()+"/deactivate",
+		h.RequirePermission(models.PermUsersDeactivate), h.AdminDeactivateUser)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var view models.AdminUserView
+	if err := json.Unmarshal(w.Body.Bytes(), &view); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if view.ID != target.ID || view.IsActive {
+		t.Errorf("response = %+v, want target %s inactive", view.User, target.ID)
+	}
+
+	stored, err := repos.Users.GetByID(ctx, target.ID)
+	if err != nil {
+		t.Fatalf("loading target: %v", err)
+	}
+	if stored.IsActive {
+		t.Error("target is still active")
+	}
+	sessions, err := repos.Sessions.ListActive(ctx, target.ID)
+	if err != nil {
+		t.Fatalf("listing sessions: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("target has %d active sessions, want 0", len(sessions))
+	}
+	entries, err := repos.Audit.List(ctx, repository.AuditFilter{UserID: &target.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("listing audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != auditActionDeactivate || entries[0].ActorID != admin.ID {
+		t.Errorf("audit log = %+v, want one %s entry by %s", entries, auditActionDeactivate, admin.ID)
+	}
+}
+
+func TestAdminActionsRejectProtectedTargets(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	h := NewUserHandler(repos, nil)
+	support := newTestUser(t, repos, "support")
+	admin := newTestUser(t, repos, "admin")
+	if _, err := repos.Roles.Grant(ctx, support.ID, models.RoleSupport); err != nil {
+		t.Fatalf("granting support role: %v", err)
+	}
+	if _, err := repos.Roles.Grant(ctx, admin.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("granting admin role: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		action   string
+		handler  gin.HandlerFunc
+		callerID uuid.UUID
+		targetID uuid.UUID
+		wantCode apierror.Code
+	}{
+		{"deactivate self", "deactivate", h.AdminDeactivateUser, support.ID, support.ID, apierror.CodeCannotManageSelf},
+		{"deactivate admin as support", "deactivate", h.AdminDeactivateUser, support.ID, admin.ID, apierror.CodeTargetRoleNotHeld},
+		{"force reset of self", "force-password-reset", h.AdminForcePasswordReset, admin.ID, admin.ID, apierror.CodeCannotManageSelf},
+		{"force reset of admin as support", "force-password-reset", h.AdminForcePasswordReset, support.ID, admin.ID, apierror.CodeTargetRoleNotHeld},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveAs(tt.callerID, http.MethodPost, "/admin/users/:userId/"+tt.action, "/admin/users/"+tt.targetID.String()+"/"+tt.action, tt.handler)
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusForbidden, w.Body.String())
+			}
+			if code := problemCode(t, w); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			stored, err := repos.Users.GetByID(ctx, tt.targetID)
+			if err != nil {
+				t.Fatalf("loading target: %v", err)
+			}
+			if !stored.IsActive || stored.PasswordResetRequired {
+				t.Errorf("target was modified: %+v", stored)
+			}
+		})
+	}
+
+	w := serveAs(admin.ID, http.MethodPost, "/admin/users/:userId/deactivate", "/admin/users/"+support.ID.String()+"/deactivate", h.AdminDeactivateUser)
+	if w.Code != http.StatusOK {
+		t.Errorf("admin deactivating support: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestAdminUnlockUser(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	h := NewUserHandler(repos, nil)
+	admin := newTestUser(t, repos, "admin")
+	target := newTestUser(t, repos, "target")
+	if _, err := repos.Roles.Grant(ctx, admin.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("granting admin role: %v", err)
+	}
+
+	limiter := throttle.NewLimiter(repos.LoginThrottle)
+	limiter.Username.LockoutThreshold = 1
+	attempt, _, err := limiter.Reserve(ctx, "target", "192.0.2.1")
+	if err != nil || attempt == nil {
+		t.Fatalf("Reserve: %v, %v", attempt, err)
+	}
+	if locked, err := limiter.Failure(ctx, attempt); err != nil || !locked {
+		t.Fatalf("Failure: locked = %v, %v; want locked", locked, err)
+	}
+
+	w := serveAs(admin.ID, http.MethodPost, "/admin/users/:userId/unlock", "/admin/users/"+target.ID.String()+"/unlock",
+		h.RequirePermission(models.PermUsersUnlock), h.AdminUnlockUser)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if _, d, err := limiter.Reserve(ctx, "target", "192.0.2.2"); err != nil || !d.Allowed() {
+		t.Errorf("login after unlock: %+v, %v; want allowed", d, err)
+	}
+}
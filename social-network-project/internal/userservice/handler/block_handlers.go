@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+// BlockUser makes the authenticated user block :userId: the two no longer see each other's profiles
+// and follow lists, and the follows and follow requests between them are removed. Blocking someone
+// already blocked succeeds, and so does blocking someone who blocked the user.
+func (h *UserHandler) BlockUser(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return
+	}
+	if targetUserID == currentUserID {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeCannotBlockSelf, "You cannot block yourself"))
+		return
+	}
+	// Not visibleUserParam: users who blocked the user are hidden from them but can be blocked back.
+	target, err := h.Users.GetByID(c.Request.Context(), targetUserID)
+	if err == repository.ErrNotFound || (err == nil && !target.IsActive) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching user %s: %v", targetUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to block user"))
+		return
+	}
+
+	if _, err := h.Blocks.Block(c.Request.Context(), currentUserID, target.ID); err != nil {
+		log.Printf("Error blocking user %s by %s: %v", target.ID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to block user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+// UnblockUser lifts the authenticated user's block of :userId. Follows the block removed stay
+// removed. Unblocking someone not blocked succeeds, and works for deactivated users too.
+func (h *UserHandler) UnblockUser(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	if _, err := h.Blocks.Unblock(c.Request.Context(), currentUserID, targetUserID); err != nil {
+		log.Printf("Error unblocking user %s by %s: %v", targetUserID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unblock user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// MuteUser makes the authenticated user mute :userId. Unlike a block, a mute is one-sided and
+// invisible to the muted user: it keeps follows and profiles as they are, and only filters the muted
+// user's content out of what services show the user (see visibility.Checker.Filter).
+func (h *UserHandler) MuteUser(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	target, _ := h.visibleUserParam(c)
+	if target == nil {
+		return
+	}
+	if target.ID == currentUserID {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeCannotMuteSelf, "You cannot mute yourself"))
+		return
+	}
+
+	if _, err := h.Blocks.Mute(c.Request.Context(), currentUserID, target.ID); err != nil {
+		log.Printf("Error muting user %s by %s: %v", target.ID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to mute user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User muted"})
+}
+
+// UnmuteUser lifts the authenticated user's mute of :userId. Unmuting someone not muted succeeds.
+func (h *UserHandler) UnmuteUser(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	if _, err := h.Blocks.Unmute(c.Request.Context(), currentUserID, targetUserID); err != nil {
+		log.Printf("Error unmuting user %s by %s: %v", targetUserID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unmute user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unmuted"})
+}
+
+// ListBlocks lists the users the authenticated user blocked, most recent first (?limit=, ?offset=).
+func (h *UserHandler) ListBlocks(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	limit, offset := pageParams(c, defaultFollowPageSize, maxFollowPageSize)
+
+	entries, err := h.Blocks.Blocks(c.Request.Context(), currentUserID, limit, offset)
+	if err != nil {
+		log.Printf("Error listing blocks of user %s: %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list blocked users"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"blocks": entries, "limit": limit, "offset": offset})
+}
+
+// ListMutes lists the users the authenticated user muted, most recent first (?limit=, ?offset=).
+func (h *UserHandler) ListMutes(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	limit, offset := pageParams(c, defaultFollowPageSize, maxFollowPageSize)
+
+	entries, err := h.Blocks.Mutes(c.Request.Context(), currentUserID, limit, offset)
+	if err != nil {
+		log.Printf("Error listing mutes of user %s: %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list muted users"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mutes": entries, "limit": limit, "offset": offset})
+}
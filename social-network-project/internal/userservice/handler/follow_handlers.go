@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/visibility"
+)
+
+const (
+	defaultFollowPageSize = 50
+	maxFollowPageSize     = 200
+)
+
+// visibleUserParam parses the :userId path parameter and loads that user together with how much
+// of them the caller may see. It answers 400, or 404 for users that don't exist as far as the
+// caller is concerned (inactive, or blocked either way), and returns nil on failure.
+func (h *UserHandler) visibleUserParam(c *gin.Context) (*models.User, visibility.Level) {
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return nil, visibility.Hidden
+	}
+	user, err := h.Users.GetByID(c.Request.Context(), targetUserID)
+	return h.visibleUser(c, user, err)
+}
+
+// visibleUser finishes a user lookup for visibleUserParam and GetUserByUsername: it answers 404
+// for users that don't exist or are hidden from the caller, and returns nil on failure.
+func (h *UserHandler) visibleUser(c *gin.Context, user *models.User, err error) (*models.User, visibility.Level) {
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return nil, visibility.Hidden
+	}
+	if err != nil {
+		log.Printf("Error fetching user: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch user"))
+		return nil, visibility.Hidden
+	}
+	level, err := h.Visibility.Level(c.Request.Context(), c.MustGet("userID").(uuid.UUID), user)
+	if err != nil {
+		log.Printf("Error checking visibility of user %s: %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch user"))
+		return nil, visibility.Hidden
+	}
+	if level == visibility.Hidden {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return nil, visibility.Hidden
+	}
+	return user, level
+}
+
+// FollowUser makes the authenticated user follow :userId. Following someone already followed succeeds.
+// Following a private account instead asks its owner for approval and answers 202.
+func (h *UserHandler) FollowUser(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	target, level := h.visibleUserParam(c)
+	if target == nil {
+		return
+	}
+	h.follow(c, currentUserID, target, level)
+}
+
+// follow makes currentUserID follow target, which they see at level, or asks to if target is a
+// private account they don't follow yet.
+func (h *UserHandler) follow(c *gin.Context, currentUserID uuid.UUID, target *models.User, level visibility.Level) {
+	if target.ID == currentUserID {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeCannotFollowSelf, "You cannot follow yourself"))
+		return
+	}
+	// Limited means a private account the user doesn't follow yet.
+	if level == visibility.Limited {
+		if _, err := h.Follows.RequestFollow(c.Request.Context(), currentUserID, target.ID); err != nil {
+			log.Printf("Error requesting to follow user %s by %s: %v", target.ID, currentUserID, err)
+			apierror.Abort(c, apierror.Internal("Failed to follow user"))
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "Follow request sent", "status": "requested"})
+		return
+	}
+
+	if _, err := h.Follows.Follow(c.Request.Context(), currentUserID, target.ID); err != nil {
+		log.Printf("Error following user %s by %s: %v", target.ID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to follow user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User followed", "status": "following"})
+}
+
+// UnfollowUser makes the authenticated user stop following :userId, or cancels their pending
+// request to follow. Unfollowing someone not followed succeeds, and works for deactivated users too.
+func (h *UserHandler) UnfollowUser(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	targetUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	if _, err := h.Follows.Unfollow(c.Request.Context(), currentUserID, targetUserID); err != nil {
+		log.Printf("Error unfollowing user %s by %s: %v", targetUserID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unfollow user"))
+		return
+	}
+	if _, err := h.Follows.DeleteFollowRequest(c.Request.Context(), currentUserID, targetUserID); err != nil {
+		log.Printf("Error cancelling follow request to user %s by %s: %v", targetUserID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to unfollow user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unfollowed"})
+}
+
+// ListFollowers lists the users following :userId, most recent first (?limit=, ?offset=). The
+// lists of a private account are only shown to the owner and their followers.
+func (h *UserHandler) ListFollowers(c *gin.Context) {
+	h.listFollows(c, "followers", h.Follows.Followers)
+}
+
+// ListFollowing lists the users :userId follows, most recent first (?limit=, ?offset=).
+func (h *UserHandler) ListFollowing(c *gin.Context) {
+	h.listFollows(c, "following", h.Follows.Following)
+}
+
+// withoutBlocked drops the users blocked either way by the caller from entries. Pages can come out
+// shorter than the limit; the offset of the next page is still offset+limit.
+func (h *UserHandler) withoutBlocked(c *gin.Context, entries []models.FollowEntry) ([]models.FollowEntry, error) {
+	ids := make([]uuid.UUID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	visible, err := h.Visibility.FilterBlocked(c.Request.Context(), c.MustGet("userID").(uuid.UUID), ids)
+	if err != nil || len(visible) == len(ids) {
+		return entries, err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if len(visible) > 0 && visible[0] == e.ID {
+			kept = append(kept, e)
+			visible = visible[1:]
+		}
+	}
+	return kept, nil
+}
+
+func (h *UserHandler) listFollows(c *gin.Context, key string,
+	list func(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error)) {
+	target, level := h.visibleUserParam(c)
+	if target == nil {
+		return
+	}
+	if level != visibility.Full {
+		apierror.Abort(c, apierror.Forbidden(apierror.CodePrivateAccount, "This account is private"))
+		return
+	}
+	limit, offset := pageParams(c, defaultFollowPageSize, maxFollowPageSize)
+
+	entries, err := list(c.Request.Context(), target.ID, limit, offset)
+	if err == nil {
+		entries, err = h.withoutBlocked(c, entries)
+	}
+	if err != nil {
+		log.Printf("Error listing %s of user %s: %v", key, target.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list "+key))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{key: entries, "limit": limit, "offset": offset})
+}
+
+// ListFollowRequests lists the pending requests to follow the authenticated user, oldest first
+// (?limit=, ?offset=).
+func (h *UserHandler) ListFollowRequests(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	limit, offset := pageParams(c, defaultFollowPageSize, maxFollowPageSize)
+
+	requests, err := h.Follows.FollowRequests(c.Request.Context(), currentUserID, limit, offset)
+	if err != nil {
+		log.Printf("Error listing follow requests of user %s: %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to list follow requests"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"follow_requests": requests, "limit": limit, "offset": offset})
+}
+
+// ApproveFollowRequest makes :userId a follower of the authenticated user.
+func (h *UserHandler) ApproveFollowRequest(c *gin.Context) {
+	h.answerFollowRequest(c, "approve", h.Follows.ApproveFollowRequest, "Follow request approved")
+}
+
+// RejectFollowRequest discards :userId's request to follow the authenticated user. The requester
+// isn't told and may ask again.
+func (h *UserHandler) RejectFollowRequest(c *gin.Context) {
+	h.answerFollowRequest(c, "reject", h.Follows.DeleteFollowRequest, "Follow request rejected")
+}
+
+func (h *UserHandler) answerFollowRequest(c *gin.Context, action string,
+	answer func(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error), message string) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	requesterID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeInvalidID, "Invalid user ID format"))
+		return
+	}
+
+	found, err := answer(c.Request.Context(), requesterID, currentUserID)
+	if err != nil {
+		log.Printf("Error trying to %s follow request of %s to %s: %v", action, requesterID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to "+action+" follow request"))
+		return
+	}
+	if !found {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeFollowRequestNotFound, "Follow request not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// followStatus returns where the user the token acts for stands with user, or nil for tokens
+// without a user and for the user themselves.
+func (h *UserHandler) followStatus(c *gin.Context, user *models.User) (*models.FollowStatus, error) {
+	viewerID := c.MustGet("userID").(uuid.UUID)
+	if viewerID == uuid.Nil || viewerID == user.ID {
+		return nil, nil
+	}
+	status := &models.FollowStatus{}
+	var err error
+	if status.IsFollowing, err = h.Follows.IsFollowing(c.Request.Context(), viewerID, user.ID); err != nil {
+		return nil, err
+	}
+	if user.IsPrivate && !status.IsFollowing {
+		if status.FollowRequested, err = h.Follows.HasRequestedFollow(c.Request.Context(), viewerID, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// userProfile returns user's profile as the caller may see it at level: the full public profile
+// with follow counts, or a models.LimitedProfile. For tokens acting for another user it includes
+// where that user stands with user.
+func (h *UserHandler) userProfile(c *gin.Context, user *models.User, level visibility.Level) (interface{}, error) {
+	followers, following, err := h.Follows.Counts(c.Request.Context(), user.ID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := h.followStatus(c, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if level != visibility.Full {
+		return &models.LimitedProfile{
+			UserSummary:    models.UserSummary{ID: user.ID, Username: user.Username, DisplayName: user.DisplayName},
+			IsPrivate:      true,
+			FollowerCount:  followers,
+			FollowingCount: following,
+			FollowStatus:   status,
+		}, nil
+	}
+	profile := &models.UserProfile{User: *user, FollowerCount: followers, FollowingCount: following, FollowStatus: status}
+	profile.Email, profile.QRCodeIdentifier = "", "" // Only shown to the user themselves
+	return profile, nil
+}
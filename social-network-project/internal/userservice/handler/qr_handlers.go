@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/internal/userservice/qrimage"
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/opaquetoken"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/validation"
+	"github.com/yourusername/social-network/pkg/visibility"
+)
+
+// defaultQRInviteTTL is how long QR invites stay valid unless the request says otherwise.
+const defaultQRInviteTTL = 24 * time.Hour
+
+// qrLink returns the deep link a QR code encodes for a user's QR code identifier or an invite token.
+func (h *UserHandler) qrLink(code string) string {
+	return h.QRLinkURL + "?code=" + url.QueryEscape(code)
+}
+
+// GetCurrentUserQR renders the authenticated user's QR code (GET /users/me/qr), a deep link to add
+// them, as a PNG (?format=png, the default, and ?size= in pixels) or SVG (?format=svg) image. With
+// ?invite= it renders one of their QR invites instead.
+func (h *UserHandler) GetCurrentUserQR(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "svg" {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest, "Invalid value for format, expected png or svg"))
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(qrimage.DefaultSize)))
+	if err != nil || size < qrimage.MinSize || size > qrimage.MaxSize {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest,
+			"Invalid value for size, expected "+strconv.Itoa(qrimage.MinSize)+" to "+strconv.Itoa(qrimage.MaxSize)))
+		return
+	}
+
+	var code string
+	if token := c.Query("invite"); token != "" {
+		invite, err := h.QRInvites.Get(c.Request.Context(), opaquetoken.Hash(token))
+		if err == repository.ErrInviteNotFound || (err == nil && invite.UserID != currentUserID) {
+			apierror.Abort(c, apierror.NotFound(apierror.CodeQRCodeNotFound, "QR invite not found or expired"))
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching QR invite of user %s: %v", currentUserID, err)
+			apierror.Abort(c, apierror.Internal("Failed to render QR code"))
+			return
+		}
+		code = token
+	} else {
+		user, err := h.Users.GetByID(c.Request.Context(), currentUserID)
+		if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+			apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "Authenticated user not found"))
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching user %s for QR code: %v", currentUserID, err)
+			apierror.Abort(c, apierror.Internal("Failed to render QR code"))
+			return
+		}
+		code = user.QRCodeIdentifier
+	}
+
+	var (
+		image       []byte
+		contentType string
+	)
+	if format == "svg" {
+		image, err = qrimage.SVG(h.qrLink(code))
+		contentType = "image/svg+xml"
+	} else {
+		image, err = qrimage.PNG(h.qrLink(code), size)
+		contentType = "image/png"
+	}
+	if err != nil {
+		log.Printf("Error rendering QR code for user %s: %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to render QR code"))
+		return
+	}
+	// The code is as good as a password for invites, and rotation must take effect immediately.
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, image)
+}
+
+// RotateQRCode gives the authenticated user a new QR code identifier (POST /users/me/qr/rotate).
+// QR codes showing the old one stop resolving; QR invites are not affected.
+func (h *UserHandler) RotateQRCode(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	identifier := uuid.NewString()
+	err := h.Users.SetQRCodeIdentifier(c.Request.Context(), currentUserID, identifier)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "Authenticated user not found"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error rotating QR code identifier of user %s: %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to rotate QR code"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"qr_code_identifier": identifier, "link": h.qrLink(identifier)})
+}
+
+// CreateQRInvite creates a one-time QR invite for the authenticated user (POST /users/me/qr/invites).
+// Whoever accepts it follows them right away, even if the account is private.
+func (h *UserHandler) CreateQRInvite(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	var req models.CreateQRInviteRequest
+	if c.Request.ContentLength != 0 && !validation.BindJSON(c, &req) {
+		return
+	}
+	ttl := defaultQRInviteTTL
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+
+	token, tokenHash, err := opaquetoken.New()
+	if err != nil {
+		log.Printf("Error generating QR invite token: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to create QR invite"))
+		return
+	}
+	now := time.Now().UTC()
+	invite := &models.QRInvite{TokenHash: tokenHash, UserID: currentUserID, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	if err := h.QRInvites.Create(c.Request.Context(), invite); err != nil {
+		log.Printf("Error storing QR invite of user %s: %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to create QR invite"))
+		return
+	}
+	c.JSON(http.StatusCreated, models.QRInviteResponse{Token: token, Link: h.qrLink(token), ExpiresAt: invite.ExpiresAt})
+}
+
+// qrParam resolves the :identifier path parameter, a user's QR code identifier or a QR invite
+// token, to the user it adds and how much of them the caller may see. invite is nil for QR code
+// identifiers. It answers 404 for unknown, rotated, used and expired codes and for users hidden
+// from the caller, and returns a nil user on failure.
+func (h *UserHandler) qrParam(c *gin.Context) (user *models.User, level visibility.Level, invite *models.QRInvite) {
+	identifier := c.Param("identifier")
+	notFound := func() (*models.User, visibility.Level, *models.QRInvite) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeQRCodeNotFound, "QR code not found or expired"))
+		return nil, visibility.Hidden, nil
+	}
+	internal := func(err error) (*models.User, visibility.Level, *models.QRInvite) {
+		log.Printf("Error resolving QR code: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to resolve QR code"))
+		return nil, visibility.Hidden, nil
+	}
+
+	user, err := h.Users.GetByQRCodeIdentifier(c.Request.Context(), identifier)
+	if err == repository.ErrNotFound {
+		invite, err = h.QRInvites.Get(c.Request.Context(), opaquetoken.Hash(identifier))
+		if err == repository.ErrInviteNotFound {
+			return notFound()
+		}
+		if err != nil {
+			return internal(err)
+		}
+		user, err = h.Users.GetByID(c.Request.Context(), invite.UserID)
+	}
+	if err == repository.ErrNotFound {
+		return notFound()
+	}
+	if err != nil {
+		return internal(err)
+	}
+
+	level, err = h.Visibility.Level(c.Request.Context(), c.MustGet("userID").(uuid.UUID), user)
+	if err != nil {
+		return internal(err)
+	}
+	if level == visibility.Hidden {
+		return notFound()
+	}
+	return user, level, invite
+}
+
+// GetUserByQR returns the profile of the user a scanned QR code adds (GET /users/by-qr/:identifier),
+// as GetUserProfile would. It doesn't use up QR invites.
+func (h *UserHandler) GetUserByQR(c *gin.Context) {
+	user, level, _ := h.qrParam(c)
+	if user == nil {
+		return
+	}
+	profile, err := h.userProfile(c, user, level)
+	if err != nil {
+		log.Printf("Error fetching follow status for user profile (%s): %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch user profile"))
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// FollowUserByQR makes the authenticated user follow the user a scanned QR code adds
+// (POST /users/by-qr/:identifier/follow). With a QR code identifier it works like FollowUser; a QR
+// invite is used up and follows even a private account without a request.
+func (h *UserHandler) FollowUserByQR(c *gin.Context) {
+	currentUserID := c.MustGet("userID").(uuid.UUID)
+	target, level, invite := h.qrParam(c)
+	if target == nil {
+		return
+	}
+	if invite == nil {
+		h.follow(c, currentUserID, target, level)
+		return
+	}
+	if target.ID == currentUserID {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeCannotFollowSelf, "You cannot follow yourself"))
+		return
+	}
+
+	tx, err := h.Tx.Begin(c.Request.Context())
+	if err != nil {
+		log.Printf("Error starting transaction for QR invite of user %s: %v", target.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to follow user"))
+		return
+	}
+	defer tx.Rollback()
+
+	// Another scan may have used the invite since qrParam looked it up.
+	if _, err := h.QRInvites.WithTx(tx.SQL()).Use(c.Request.Context(), invite.TokenHash, currentUserID); err != nil {
+		if err == repository.ErrInviteNotFound {
+			apierror.Abort(c, apierror.NotFound(apierror.CodeQRCodeNotFound, "QR code not found or expired"))
+			return
+		}
+		log.Printf("Error using QR invite of user %s: %v", target.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to follow user"))
+		return
+	}
+	follows := h.Follows.WithTx(tx.SQL())
+	if _, err := follows.Follow(c.Request.Context(), currentUserID, target.ID); err != nil {
+		log.Printf("Error following user %s by %s with a QR invite: %v", target.ID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to follow user"))
+		return
+	}
+	if _, err := follows.DeleteFollowRequest(c.Request.Context(), currentUserID, target.ID); err != nil {
+		log.Printf("Error removing follow request to user %s by %s: %v", target.ID, currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to follow user"))
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing QR invite of user %s: %v", target.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to follow user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User followed", "status": "following"})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 50
+	maxSearchQueryLength  = 100
+)
+
+// encodeSearchCursor makes the opaque next_cursor of a search page.
+func encodeSearchCursor(cursor repository.SearchCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(cursor.Score, 'f', -1, 64) + ":" + cursor.ID.String()))
+}
+
+func decodeSearchCursor(s string) (*repository.SearchCursor, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	score, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, false
+	}
+	var cursor repository.SearchCursor
+	if cursor.Score, err = strconv.ParseFloat(score, 64); err != nil {
+		return nil, false
+	}
+	if cursor.ID, err = uuid.Parse(id); err != nil {
+		return nil, false
+	}
+	return &cursor, true
+}
+
+// SearchUsers finds users by username and display name (GET /users/search?q=), best match first:
+// exact matches, then prefix matches, then similar names, with users the caller follows ranked
+// higher. Users blocked either way are left out. Pages are requested with ?limit= and the previous
+// page's next_cursor as ?cursor=; next_cursor is absent on the last page.
+func (h *UserHandler) SearchUsers(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest, "Missing search query q"))
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest,
+			"Search query q must be at most "+strconv.Itoa(maxSearchQueryLength)+" characters"))
+		return
+	}
+	limit, _ := pageParams(c, defaultSearchPageSize, maxSearchPageSize)
+
+	search := repository.UserSearch{Query: query, ViewerID: c.MustGet("userID").(uuid.UUID), Limit: limit + 1}
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, ok := decodeSearchCursor(cursor)
+		if !ok {
+			apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest, "Invalid cursor"))
+			return
+		}
+		search.After = after
+	}
+
+	results, err := h.Search.SearchUsers(c.Request.Context(), search)
+	if err != nil {
+		log.Printf("Error searching users for %q: %v", query, err)
+		apierror.Abort(c, apierror.Internal("Failed to search users"))
+		return
+	}
+
+	// One result more than the page tells whether there is a next page.
+	resp := gin.H{"limit": limit}
+	if len(results) > limit {
+		results = results[:limit]
+		resp["next_cursor"] = encodeSearchCursor(results[limit-1].Cursor)
+	}
+	users := make([]models.UserSearchResult, len(results))
+	for i, result := range results {
+		users[i] = result.UserSearchResult
+	}
+	resp["users"] = users
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetUserByUsername returns the profile of the user with :username, ignoring case, as
+// GetUserProfile would (GET /users/by-username/:username).
+func (h *UserHandler) GetUserByUsername(c *gin.Context) {
+	user, err := h.Users.GetByUsername(c.Request.Context(), c.Param("username"))
+	user, level := h.visibleUser(c, user, err)
+	if user == nil {
+		return
+	}
+
+	profile, err := h.userProfile(c, user, level)
+	if err != nil {
+		log.Printf("Error fetching follow status for user profile (%s): %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch user profile"))
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
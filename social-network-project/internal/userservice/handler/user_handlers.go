@@ -1,263 +1,228 @@
 package handler
 
 import (
-	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
 	// Adjust the import path based on your go.mod module name
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/authmw"
+	"github.com/yourusername/social-network/pkg/jwks"
 	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+	"github.com/yourusername/social-network/pkg/throttle"
+	"github.com/yourusername/social-network/pkg/validation"
+	"github.com/yourusername/social-network/pkg/visibility"
 )
 
 // UserHandler struct holds dependencies for user service handlers.
 type UserHandler struct {
-	DB           *sql.DB
-	JwtSecretKey []byte
+	Tx            repository.Transactor         // Begins transactions spanning the repositories below
+	Users         repository.UserRepository     // Access to the users table
+	Follows       repository.FollowRepository   // The social graph
+	Blocks        repository.BlockRepository    // Blocks and mutes
+	QRInvites     repository.QRInviteRepository // One-time QR invites
+	Search        repository.SearchRepository   // User search
+	Sessions      repository.SessionRepository  // auth-service's session registry, checked on every request
+	OAuth         repository.OAuthRepository    // auth-service's OAuth clients, whose owners client tokens read as
+	Roles         repository.RoleRepository     // Roles and permissions for the admin API
+	Audit         repository.AuditRepository    // Log of admin actions
+	LoginThrottle throttle.Store                // auth-service's failed login counters, cleared by admins
+	Visibility    *visibility.Checker           // Applies blocks and private accounts; reads the repositories above
+	JWKS          *jwks.Cache                   // Public keys fetched from auth-service, used to verify access tokens
+	Issuer        string                        // Expected "iss" claim; not checked when empty
+	Audience      string                        // Expected "aud" claim; not checked when empty
+	QRLinkURL     string                        // Deep link QR codes encode; the identifier is appended as ?code=
 }
 
 // NewUserHandler creates a new UserHandler.
-func NewUserHandler(db *sql.DB, jwtKey []byte) *UserHandler {
+func NewUserHandler(repos *repository.Repositories, keyCache *jwks.Cache) *UserHandler {
 	return &UserHandler{
-		DB:           db,
-		JwtSecretKey: jwtKey,
+		Tx:            repos.Tx,
+		Users:         repos.Users,
+		Follows:       repos.Follows,
+		Blocks:        repos.Blocks,
+		QRInvites:     repos.QRInvites,
+		Search:        repos.Search,
+		Sessions:      repos.Sessions,
+		OAuth:         repos.OAuth,
+		Roles:         repos.Roles,
+		Audit:         repos.Audit,
+		LoginThrottle: repos.LoginThrottle,
+		Visibility:    &visibility.Checker{Users: repos.Users, Follows: repos.Follows, Blocks: repos.Blocks},
+		JWKS:          keyCache,
+		QRLinkURL:     "http://localhost/add-friend",
 	}
 }
 
-// AuthMiddleware verifies the JWT token.
-// This is a method on UserHandler now, or could be a standalone function if preferred.
-// Making it a method allows it to potentially use dependencies from UserHandler if ever needed, though not currently.
+// AuthMiddleware verifies the JWT token against auth-service's published keys and rejects
+// tokens whose session was revoked through auth-service. Tokens from the client_credentials grant
+// read as the user who registered the client, so blocks and private accounts apply to them as to
+// that user. Claim parsing lives in pkg/authmw.
 func (h *UserHandler) AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
-			c.Abort()
-			return
-		}
-
-		tokenString := parts[1]
-
-		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return h.JwtSecretKey, nil // Use JwtSecretKey from handler
-		})
-
-		if err != nil {
-			log.Printf("Token validation error: %v", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
-			c.Abort()
-			return
-		}
-
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		userIDStr, okUserID := claims["user_id"].(string)
-		username, okUsername := claims["username"].(string)
-
-		if !okUserID || !okUsername {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
-		}
-		
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token claims"})
-			c.Abort()
-			return
-		}
+	return authmw.Authenticate(h.authConfig(h.JWKS.Keyfunc))
+}
 
-		c.Set("userID", userID)
-		c.Set("username", username)
-		c.Next()
+func (h *UserHandler) authConfig(keyfunc jwt.Keyfunc) authmw.Config {
+	return authmw.Config{
+		Keyfunc:      keyfunc,
+		Issuer:       h.Issuer,
+		Audience:     h.Audience,
+		CheckSession: authmw.RegistrySessionChecker(h.Sessions),
+		ClientOwner:  authmw.RegistryClientOwner(h.OAuth),
 	}
 }
 
-// GetUserProfile handles fetching a user's profile by ID.
+// GetUserProfile handles fetching a user's profile by ID, with follow counts and whether the
+// caller follows them. Private accounts only show a limited profile to users who don't follow them,
+// and users blocked either way get 404.
 func (h *UserHandler) GetUserProfile(c *gin.Context) {
-	userIDParam := c.Param("userId")
-	targetUserID, err := uuid.Parse(userIDParam)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+	user, level := h.visibleUserParam(c)
+	if user == nil {
 		return
 	}
 
-	var user models.User
-	err = h.DB.QueryRow("SELECT id, username, display_name, bio, qr_code_identifier, created_at, updated_at, is_active FROM users WHERE id = $1 AND is_active = TRUE", targetUserID).Scan(
-		&user.ID, &user.Username, &user.DisplayName, &user.Bio, &user.QRCodeIdentifier, &user.CreatedAt, &user.UpdatedAt, &user.IsActive,
-	)
-
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-		return
-	}
+	profile, err := h.userProfile(c, user, level)
 	if err != nil {
-		log.Printf("Error fetching user profile by ID (%s): %v", targetUserID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		log.Printf("Error fetching follow status for user profile (%s): %v", user.ID, err)
+		apierror.Abort(c, apierror.Internal("Failed to fetch user profile"))
 		return
 	}
-
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, profile)
 }
 
 // GetCurrentUserProfile handles fetching the currently authenticated user's profile.
 func (h *UserHandler) GetCurrentUserProfile(c *gin.Context) {
 	userIDVal, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
+		apierror.Abort(c, apierror.Internal("User ID not found in context"))
 		return
 	}
 	currentUserID := userIDVal.(uuid.UUID) // Type assertion
-	
-	var user models.User
-	err := h.DB.QueryRow("SELECT id, username, display_name, bio, qr_code_identifier, created_at, updated_at, is_active FROM users WHERE id = $1 AND is_active = TRUE", currentUserID).Scan(
-		&user.ID, &user.Username, &user.DisplayName, &user.Bio, &user.QRCodeIdentifier, &user.CreatedAt, &user.UpdatedAt, &user.IsActive,
-	)
 
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Authenticated user not found"})
+	user, err := h.Users.GetByID(c.Request.Context(), currentUserID)
+	if err == repository.ErrNotFound || (err == nil && !user.IsActive) {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "Authenticated user not found"))
 		return
 	}
 	if err != nil {
 		log.Printf("Error fetching current user profile by ID (%s): %v", currentUserID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch your profile"})
+		apierror.Abort(c, apierror.Internal("Failed to fetch your profile"))
 		return
 	}
 
+	c.Header("ETag", profileETag(user))
 	c.JSON(http.StatusOK, user)
 }
 
-// UpdateUserProfileRequest defines the allowed fields for updating a user profile.
+// UpdateUserProfileRequest is a JSON Merge Patch (RFC 7396) of the profile: absent members are
+// left alone and null clears the field.
 type UpdateUserProfileRequest struct {
-	DisplayName string `json:"display_name,omitempty"`
-	Bio         string `json:"bio,omitempty"`
-	// Add other updatable fields here, e.g., ProfileImageURL, etc.
-	// Do NOT include Username, Password (handled separately), Email (if sensitive, handle separately)
+	DisplayName models.PatchString `json:"display_name" validate:"omitempty,max=100"`
+	Bio         models.PatchString `json:"bio" validate:"omitempty,max=500"`
+	IsPrivate   models.PatchBool   `json:"is_private"` // Making the account public approves pending follow requests
+	// Do NOT include Username, Password (handled separately), Email (handled by auth-service)
 }
 
+// profileETag is the profile's version for If-Match. It changes whenever updated_at does.
+func profileETag(user *models.User) string {
+	return fmt.Sprintf(`"%x"`, user.UpdatedAt.UnixMicro())
+}
 
-// UpdateCurrentUserProfile handles updating the currently authenticated user's profile.
-func (h *UserHandler) UpdateCurrentUserProfile(c *gin.Context) {
-    userIDVal, exists := c.Get("userID")
-    if !exists {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "User ID not found in context"})
-        return
-    }
-    currentUserID := userIDVal.(uuid.UUID)
-
-    var req UpdateUserProfileRequest 
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data: " + err.Error()})
-        return
-    }
-
-    // Simple validation: at least one field must be provided for update.
-    // A more robust validation would check if the provided values are actually different or meet certain criteria.
-    if req.DisplayName == "" && req.Bio == "" { // This logic is too simple, if user wants to clear a field.
-        // Better: check if request body is empty or if all known fields are nil/empty after parsing.
-        // For now, we'll proceed and let the DB update happen.
-        // Consider what happens if a user sends an empty JSON {}
-    }
-
-    // Build the query dynamically based on provided fields.
-    // For simplicity, we'll update both if provided or keep existing if not.
-    // This is NOT ideal. A better way is to fetch user, update fields, then save.
-    // Or, use COALESCE or CASE in SQL, or build query string.
-    // For now, direct update of specific fields:
-    
-    // Fetch current user data first to only update provided fields
-    var currentUserData models.User
-    err := h.DB.QueryRow("SELECT display_name, bio FROM users WHERE id = $1", currentUserID).Scan(&currentUserData.DisplayName, &currentUserData.Bio)
-    if err != nil {
-        log.Printf("Update User: Error fetching current user data for ID (%s): %v", currentUserID, err)
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve current profile data for update"})
-        return
-    }
+// etagMatches reports whether an If-Match header value (RFC 9110 section 13.1.1) matches etag.
+// Weak tags never match: If-Match uses strong comparison.
+func etagMatches(ifMatch, etag string) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
 
-    // If request field is empty, use current value. This is not how PATCH typically works.
-    // Better to use a map[string]interface{} for updates or check explicitly.
-    // For a PATCH-like behavior, only update fields that are explicitly set in the request.
-    // This requires a more complex request model or checking for nil pointers for optional fields.
-    // Let's simplify: if a field is in the request (even if empty string), it's updated.
-    // If you want to allow clearing fields, this is okay. If not, add validation.
+// UpdateCurrentUserProfile applies a JSON Merge Patch to the authenticated user's profile
+// (PATCH /users/me). With If-Match the patch only applies to the version the client last saw,
+// and 412 tells it someone else changed the profile in the meantime.
+func (h *UserHandler) UpdateCurrentUserProfile(c *gin.Context) {
+	userIDVal, exists := c.Get("userID")
+	if !exists {
+		apierror.Abort(c, apierror.Internal("User ID not found in context"))
+		return
+	}
+	currentUserID := userIDVal.(uuid.UUID)
 
-    finalDisplayName := req.DisplayName
-    finalBio := req.Bio
-    // This simplistic approach means if a field is omitted in JSON, it's not updated by this query.
-    // However, if it's sent as an empty string `""`, it WILL be updated to an empty string.
+	if contentType := c.ContentType(); contentType != models.MergePatchContentType && contentType != "application/json" {
+		c.Header("Accept-Patch", models.MergePatchContentType)
+		apierror.Abort(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMediaType,
+			"Profile updates must be sent as "+models.MergePatchContentType))
+		return
+	}
 
-    // Let's refine: we only update what is provided.
-    // This is still basic. A real app might use ORM or more advanced query building.
-    query := "UPDATE users SET updated_at = $1"
-    args := []interface{}{time.Now().UTC()}
-    argId := 2 
+	var req UpdateUserProfileRequest
+	if !validation.BindJSON(c, &req) {
+		return
+	}
 
-    if req.DisplayName != "" { // Only update if display name is provided
-        query += fmt.Sprintf(", display_name = $%d", argId)
-        args = append(args, req.DisplayName)
-        argId++
-    }
-    if req.Bio != "" { // Only update if bio is provided
-         query += fmt.Sprintf(", bio = $%d", argId)
-        args = append(args, req.Bio)
-        argId++
-    }
-    
-    if argId == 2 { // No fields were actually added to update
-        c.JSON(http.StatusBadRequest, gin.H{"error": "No updateable fields (display_name, bio) provided with non-empty values."})
-        return
-    }
+	update := repository.ProfileUpdate{
+		DisplayName: req.DisplayName.Update(),
+		Bio:         req.Bio.Update(),
+		IsPrivate:   req.IsPrivate.Update(),
+	}
+	if update.DisplayName == nil && update.Bio == nil && update.IsPrivate == nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeNoProfileChanges, "No updateable fields (display_name, bio, is_private) provided."))
+		return
+	}
 
-    query += fmt.Sprintf(" WHERE id = $%d", argId)
-    args = append(args, currentUserID)
-    
-    result, err := h.DB.Exec(query, args...)
-    if err != nil {
-        log.Printf("Error updating user profile for ID (%s): %v, Query: %s, Args: %v", currentUserID, err, query, args)
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
-        return
-    }
-    
-    rowsAffected, err := result.RowsAffected()
-    if err != nil {
-        log.Printf("Error getting rows affected for ID (%s): %v", currentUserID, err)
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile (check rows)"})
-        return
-    }
-    if rowsAffected == 0 {
-         c.JSON(http.StatusNotFound, gin.H{"error": "User not found or no changes made"}) // Or 304 Not Modified if no actual change
-        return
-    }
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		current, err := h.Users.GetByID(c.Request.Context(), currentUserID)
+		if err == repository.ErrNotFound {
+			apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching user profile for ID (%s): %v", currentUserID, err)
+			apierror.Abort(c, apierror.Internal("Failed to update profile"))
+			return
+		}
+		if !etagMatches(ifMatch, profileETag(current)) {
+			c.Header("ETag", profileETag(current))
+			apierror.Abort(c, apierror.New(http.StatusPreconditionFailed, apierror.CodePreconditionFailed, "Profile was changed by another request"))
+			return
+		}
+		// The version checked above must still be current when the update is written.
+		update.IfUpdatedAt = &current.UpdatedAt
+	}
 
-    var updatedUser models.User
-    err = h.DB.QueryRow("SELECT id, username, display_name, bio, qr_code_identifier, created_at, updated_at, is_active FROM users WHERE id = $1", currentUserID).Scan(
-		&updatedUser.ID, &updatedUser.Username, &updatedUser.DisplayName, &updatedUser.Bio, &updatedUser.QRCodeIdentifier, &updatedUser.CreatedAt, &updatedUser.UpdatedAt, &updatedUser.IsActive,
-	)
-    if err != nil {
-        log.Printf("Error fetching updated user profile for ID (%s): %v", currentUserID, err)
-        c.JSON(http.StatusOK, gin.H{"message": "Profile updated, but failed to fetch updated data. Please refresh."})
-        return
-    }
-    c.JSON(http.StatusOK, updatedUser)
+	updatedUser, err := h.Users.UpdateProfile(c.Request.Context(), currentUserID, update)
+	if err == repository.ErrNotFound {
+		apierror.Abort(c, apierror.NotFound(apierror.CodeUserNotFound, "User not found"))
+		return
+	}
+	if err == repository.ErrModified {
+		apierror.Abort(c, apierror.New(http.StatusPreconditionFailed, apierror.CodePreconditionFailed, "Profile was changed by another request"))
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating user profile for ID (%s): %v", currentUserID, err)
+		apierror.Abort(c, apierror.Internal("Failed to update profile"))
+		return
+	}
+	// Approve on every patch to public, not only the one that changes it, so repeating a patch
+	// that failed here finishes the job.
+	if update.IsPrivate != nil && !*update.IsPrivate {
+		if _, err := h.Follows.ApproveFollowRequests(c.Request.Context(), currentUserID); err != nil {
+			log.Printf("Error approving follow requests for user %s: %v", currentUserID, err)
+			apierror.Abort(c, apierror.Internal("Failed to approve pending follow requests"))
+			return
+		}
+	}
+	c.Header("ETag", profileETag(updatedUser))
+	c.JSON(http.StatusOK, updatedUser)
 }
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/authmw"
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+func TestProfileETag(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC)
+	user := &models.User{UpdatedAt: updated}
+	etag := profileETag(user)
+	if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) || len(etag) < 3 {
+		t.Fatalf("profileETag = %s, want a quoted strong entity tag", etag)
+	}
+
+	// Only updated_at matters, and it changes the tag at microsecond resolution.
+	same := &models.User{UpdatedAt: updated, DisplayName: "changed", Bio: "elsewhere"}
+	if got := profileETag(same); got != etag {
+		t.Errorf("same updated_at: profileETag = %s, want %s", got, etag)
+	}
+	if got := profileETag(&models.User{UpdatedAt: updated.Add(time.Microsecond)}); got == etag {
+		t.Errorf("updated_at a microsecond later: profileETag = %s, want a different tag", got)
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"5f0a3c"`
+	tests := []struct {
+		ifMatch string
+		want    bool
+	}{
+		{`"5f0a3c"`, true},
+		{`*`, true},
+		{` * `, true},
+		{`"other"`, false},
+		{`"other", "5f0a3c"`, true},
+		{`"other","5f0a3c"`, true},
+		{`"other", "another"`, false},
+		{`W/"5f0a3c"`, false}, // If-Match compares strongly
+		{`W/"5f0a3c", "5f0a3c"`, true},
+		{`5f0a3c`, false}, // Entity tags are quoted
+		{`"5F0A3C"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifMatch, etag); got != tt.want {
+			t.Errorf("etagMatches(%s, %s) = %v, want %v", tt.ifMatch, etag, got, tt.want)
+		}
+	}
+}
+
+// patchProfile sends a merge patch of the caller's profile, with If-Match unless it is empty.
+func patchProfile(h *UserHandler, callerID uuid.UUID, ifMatch, body string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(apierror.Middleware(), func(c *gin.Context) {
+		c.Set("userID", callerID)
+		c.Next()
+	})
+	router.PATCH("/users/me", h.UpdateCurrentUserProfile)
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/me", strings.NewReader(body))
+	req.Header.Set("Content-Type", models.MergePatchContentType)
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// currentETag fetches the caller's profile and returns its ETag.
+func currentETag(t *testing.T, h *UserHandler, callerID uuid.UUID) string {
+	t.Helper()
+	w := serveAs(callerID, http.MethodGet, "/users/me", "/users/me", h.GetCurrentUserProfile)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == "" {
+		t.Fatalf("GET /users/me: status = %d, ETag = %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+	}
+	return w.Header().Get("ETag")
+}
+
+func TestUpdateProfileIfMatch(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	h := NewUserHandler(repos, nil)
+	user := newTestUser(t, repos, "alice")
+	stale := currentETag(t, h, user.ID)
+	w := patchProfile(h, user.ID, stale, `{"display_name": "Alice"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	current := currentETag(t, h, user.ID)
+	if w.Header().Get("ETag") != current || current == stale {
+		t.Fatalf("ETag after the update = %q, GET returns %q; want the same new tag", w.Header().Get("ETag"), current)
+	}
+
+	// Each case builds If-Match from the tag of the version before the first update and the
+	// current one, which changes with every successful case.
+	tests := []struct {
+		name       string
+		ifMatch    func(current string) string
+		wantStatus int
+	}{
+		{"stale tag", func(string) string { return stale }, http.StatusPreconditionFailed},
+		{"weak current tag", func(current string) string { return "W/" + current }, http.StatusPreconditionFailed},
+		{"list without the current tag", func(string) string { return stale + `, "0"` }, http.StatusPreconditionFailed},
+		{"list with the current tag", func(current string) string { return stale + ", " + current }, http.StatusOK},
+		{"any", func(string) string { return "*" }, http.StatusOK},
+		{"current tag", func(current string) string { return current }, http.StatusOK},
+		{"no If-Match", func(string) string { return "" }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := currentETag(t, h, user.ID)
+			before, err := repos.Users.GetByID(context.Background(), user.ID)
+			if err != nil {
+				t.Fatalf("loading user: %v", err)
+			}
+
+			w := patchProfile(h, user.ID, tt.ifMatch(current), `{"bio": "`+tt.name+`"}`)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			after, err := repos.Users.GetByID(context.Background(), user.ID)
+			if err != nil {
+				t.Fatalf("loading user: %v", err)
+			}
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				// The client gets the current tag to retry with.
+				if code := problemCode(t, w); code != apierror.CodePreconditionFailed || w.Header().Get("ETag") != current {
+					t.Errorf("code = %s, ETag = %q; want %s and %s", code, w.Header().Get("ETag"), apierror.CodePreconditionFailed, current)
+				}
+				if after.Bio != before.Bio {
+					t.Errorf("bio = %q after a failed precondition, want %q", after.Bio, before.Bio)
+				}
+			} else if after.Bio != tt.name || w.Header().Get("ETag") != profileETag(after) {
+				t.Errorf("bio = %q, ETag = %q; want %q, %s", after.Bio, w.Header().Get("ETag"), tt.name, profileETag(after))
+			}
+		})
+	}
+}
+
+// racingUsers is a UserRepository on which someone else updates the profile right after the
+// handler has read it.
+type racingUsers struct {
+	repository.UserRepository
+}
+
+func (r racingUsers) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := r.UserRepository.GetByID(ctx, id)
+	if err == nil {
+		bio := "written concurrently"
+		_, err = r.UserRepository.UpdateProfile(ctx, id, repository.ProfileUpdate{Bio: &bio})
+	}
+	return user, err
+}
+
+func TestUpdateProfileIfMatchRace(t *testing.T) {
+	repos := repository.NewMemoryRepositories()
+	user := newTestUser(t, repos, "alice")
+	etag := profileETag(user)
+	repos.Users = racingUsers{repos.Users}
+	h := NewUserHandler(repos, nil)
+
+	// The tag matches when the handler checks it, but the update must still only apply to that version.
+	w := patchProfile(h, user.ID, etag, `{"display_name": "Alice"}`)
+	if w.Code != http.StatusPreconditionFailed || problemCode(t, w) != apierror.CodePreconditionFailed {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusPreconditionFailed, w.Body.String())
+	}
+	stored, err := repos.Users.(racingUsers).UserRepository.GetByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("loading user: %v", err)
+	}
+	if stored.DisplayName != "" || stored.Bio != "written concurrently" {
+		t.Errorf("profile = %q, %q; want only the concurrent write", stored.DisplayName, stored.Bio)
+	}
+}
+
+func TestUpdateProfileMergePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantCode    apierror.Code
+		wantDisplay string
+		wantBio     string
+		wantPrivate bool
+	}{
+		{"absent members are kept", `{"display_name": "Alice"}`, http.StatusOK, "", "Alice", "old bio", true},
+		{"null clears", `{"bio": null}`, http.StatusOK, "", "old name", "", true},
+		{"null clears a bool", `{"is_private": null}`, http.StatusOK, "", "old name", "old bio", false},
+		{"empty string clears", `{"bio": ""}`, http.StatusOK, "", "old name", "", true},
+		{"several members", `{"display_name": "A", "bio": null, "is_private": false}`, http.StatusOK, "", "A", "", false},
+		{"no members", `{}`, http.StatusBadRequest, apierror.CodeNoProfileChanges, "old name", "old bio", true},
+		{"only unknown members", `{"username": "mallory"}`, http.StatusBadRequest, apierror.CodeNoProfileChanges, "old name", "old bio", true},
+		{"wrong type", `{"display_name": 5}`, http.StatusBadRequest, apierror.CodeValidationFailed, "old name", "old bio", true},
+		{"too long", `{"bio": "` + strings.Repeat("x", 501) + `"}`, http.StatusBadRequest, apierror.CodeValidationFailed, "old name", "old bio", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := repository.NewMemoryRepositories()
+			h := NewUserHandler(repos, nil)
+			user := newTestUser(t, repos, "alice")
+			displayName, bio, private := "old name", "old bio", true
+			if _, err := repos.Users.UpdateProfile(ctx, user.ID, repository.ProfileUpdate{DisplayName: &displayName, Bio: &bio, IsPrivate: &private}); err != nil {
+				t.Fatalf("setting up profile: %v", err)
+			}
+
+			w := patchProfile(h, user.ID, "", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantCode != "" && problemCode(t, w) != tt.wantCode {
+				t.Errorf("code = %s, want %s", problemCode(t, w), tt.wantCode)
+			}
+			stored, err := repos.Users.GetByID(ctx, user.ID)
+			if err != nil {
+				t.Fatalf("loading user: %v", err)
+			}
+			if stored.DisplayName != tt.wantDisplay || stored.Bio != tt.wantBio || stored.IsPrivate != tt.wantPrivate {
+				t.Errorf("profile = %q, %q, private %v; want %q, %q, private %v",
+					stored.DisplayName, stored.Bio, stored.IsPrivate, tt.wantDisplay, tt.wantBio, tt.wantPrivate)
+			}
+		})
+	}
+}
+
+func TestClientTokenReadsAsOwner(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	h := NewUserHandler(repos, nil)
+	owner := newTestUser(t, repos, "partner")
+	blocker := newTestUser(t, repos, "bob")
+	other := newTestUser(t, repos, "carol")
+	if _, err := repos.Blocks.Block(ctx, blocker.ID, owner.ID); err != nil {
+		t.Fatalf("blocking: %v", err)
+	}
+
+	now := time.Now()
+	client := &models.OAuthClient{ID: uuid.NewString(), Name: "Partner", GrantTypes: []string{models.GrantClientCredentials}, Scopes: []string{models.ScopeProfileRead}, CreatedAt: now}
+	if err := repos.OAuth.CreateClient(ctx, client, "secret hash", owner.ID); err != nil {
+		t.Fatalf("registering client: %v", err)
+	}
+	jti := uuid.New()
+	if err := repos.Sessions.AddClientToken(ctx, jti, client.ID, now, now.Add(time.Hour)); err != nil {
+		t.Fatalf("recording client token: %v", err)
+	}
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, models.AuthTokenClaims{
+		Scope:    models.ScopeProfileRead,
+		ClientID: client.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti.String(),
+			Subject:   client.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}).SignedString(private)
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(apierror.Middleware(), authmw.Authenticate(h.authConfig(func(*jwt.Token) (any, error) { return public, nil })))
+	router.GET("/users/:userId", authmw.RequireScope(models.ScopeProfileRead), h.GetUserProfile)
+	get := func(userID uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/users/"+userID.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Users who blocked the client's owner don't exist for the client either.
+	if w := get(blocker.ID); w.Code != http.StatusNotFound {
+		t.Errorf("profile of a user blocking the owner: status = %d, want %d: %s", w.Code, http.StatusNotFound, w.Body.String())
+	}
+	if w := get(other.ID); w.Code != http.StatusOK {
+		t.Errorf("profile of another user: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+
+	if err := repos.OAuth.DeleteClient(ctx, client.ID, owner.ID); err != nil {
+		t.Fatalf("deleting client: %v", err)
+	}
+	if w := get(other.ID); w.Code != http.StatusUnauthorized {
+		t.Errorf("after deleting the client: status = %d, want %d: %s", w.Code, http.StatusUnauthorized, w.Body.String())
+	}
+}
@@ -0,0 +1,53 @@
+// Package qrimage renders QR codes as PNG or SVG images.
+package qrimage
+
+import (
+	"bytes"
+	"fmt"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// level is the error correction level: medium recovers from about 15% damage, enough for codes
+// shown on screens and printed at a reasonable size.
+const level = qrcode.Medium
+
+const (
+	// DefaultSize is the width and height of PNG images, in pixels, unless the caller asks otherwise.
+	DefaultSize = 256
+	// MinSize and MaxSize bound the PNG sizes callers may ask for.
+	MinSize = 64
+	MaxSize = 1024
+)
+
+// PNG renders content as a size×size PNG image, quiet zone included.
+func PNG(content string, size int) ([]byte, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	return code.PNG(size)
+}
+
+// SVG renders content as an SVG image with one unit per module, quiet zone included, which scales
+// to any size.
+func SVG(content string) ([]byte, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := code.Bitmap()
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]d %[1]d" shape-rendering="crispEdges">`, len(bitmap))
+	fmt.Fprintf(&buf, `<rect width="%[1]d" height="%[1]d" fill="#fff"/><path fill="#000" d="`, len(bitmap))
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes(), nil
+}
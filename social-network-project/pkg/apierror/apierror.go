@@ -0,0 +1,161 @@
+// Package apierror gives every service the same error responses: RFC 7807 problem documents
+// (application/problem+json) carrying a stable machine-readable code and the request ID, so
+// clients can branch on codes instead of English messages and support can find the request in logs.
+//
+// Handlers report failures with Abort and a typed *Error; Middleware assigns request IDs and
+// renders errors attached to the context with c.Error.
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ContentType is the media type of problem documents (RFC 7807 section 3).
+const ContentType = "application/problem+json"
+
+// HeaderRequestID carries the request ID, from the gateway to the services and back to the client.
+const HeaderRequestID = "X-Request-ID"
+
+// ContextRequestID is the Gin context key holding the request ID.
+const ContextRequestID = "requestID"
+
+// Error is an error with everything needed to answer the client. Detail is shown to clients,
+// so it must never contain internal information such as database or library errors.
+type Error struct {
+	Status     int
+	Code       Code
+	Detail     string
+	Extensions map[string]interface{} // Extra members of the problem document, e.g. "fields"
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+// With adds an extension member to the problem document and returns e.
+func (e *Error) With(key string, value interface{}) *Error {
+	if e.Extensions == nil {
+		e.Extensions = map[string]interface{}{}
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+// New returns an Error with the given status, code and client-facing detail.
+func New(status int, code Code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// BadRequest returns a 400 Error.
+func BadRequest(code Code, detail string) *Error {
+	return New(http.StatusBadRequest, code, detail)
+}
+
+// Unauthorized returns a 401 Error.
+func Unauthorized(code Code, detail string) *Error {
+	return New(http.StatusUnauthorized, code, detail)
+}
+
+// Forbidden returns a 403 Error.
+func Forbidden(code Code, detail string) *Error {
+	return New(http.StatusForbidden, code, detail)
+}
+
+// NotFound returns a 404 Error.
+func NotFound(code Code, detail string) *Error {
+	return New(http.StatusNotFound, code, detail)
+}
+
+// Conflict returns a 409 Error.
+func Conflict(code Code, detail string) *Error {
+	return New(http.StatusConflict, code, detail)
+}
+
+// Unavailable returns a 503 Error.
+func Unavailable(code Code, detail string) *Error {
+	return New(http.StatusServiceUnavailable, code, detail)
+}
+
+// Internal returns a 500 Error. Log the underlying error before returning it; detail only says what failed.
+func Internal(detail string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, detail)
+}
+
+// Abort answers the request with err and stops the handler chain. Errors that aren't an *Error
+// are logged and answered with a generic 500, so their text never reaches the client.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	render(c, err)
+	c.Abort()
+}
+
+// RequestID returns the ID Middleware assigned to the request, or "" outside of it.
+func RequestID(c *gin.Context) string {
+	return c.GetString(ContextRequestID)
+}
+
+// validRequestID limits IDs accepted from upstream so they can't inject anything into logs or headers.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Middleware assigns every request an ID, taken from the gateway's X-Request-ID header when present,
+// and echoes it in the response. Errors attached with c.Error by handlers that didn't write a
+// response are rendered as problem documents once the handler chain returns.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(HeaderRequestID)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(ContextRequestID, requestID)
+		c.Header(HeaderRequestID, requestID)
+
+		c.Next()
+
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			render(c, c.Errors.Last().Err)
+		}
+	}
+}
+
+// Recovery turns panics into a 500 problem document instead of an empty response.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		Abort(c, Internal("Internal server error"))
+	})
+}
+
+// render writes err as a problem document (RFC 7807 section 3.1), with "code" and "request_id" as
+// extension members.
+func render(c *gin.Context, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		log.Printf("Unhandled error in %s %s (request %s): %v", c.Request.Method, c.Request.URL.Path, RequestID(c), err)
+		apiErr = Internal("Internal server error")
+	}
+
+	problem := gin.H{}
+	for key, value := range apiErr.Extensions {
+		problem[key] = value
+	}
+	problem["type"] = "about:blank"
+	problem["title"] = http.StatusText(apiErr.Status)
+	problem["status"] = apiErr.Status
+	problem["code"] = apiErr.Code
+	if apiErr.Detail != "" {
+		problem["detail"] = apiErr.Detail
+	}
+	problem["instance"] = c.Request.URL.Path
+	if requestID := RequestID(c); requestID != "" {
+		problem["request_id"] = requestID
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.JSON(apiErr.Status, problem)
+}
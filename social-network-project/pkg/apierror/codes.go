@@ -0,0 +1,106 @@
+package apierror
+
+// Code identifies an error for clients. Codes are part of the API: once released, never rename or
+// reuse one. The detail message next to it may change freely.
+type Code string
+
+// General codes, used when nothing more specific applies.
+const (
+	CodeBadRequest           Code = "bad_request"
+	CodeValidationFailed     Code = "validation_failed" // "fields" lists the invalid fields
+	CodeMalformedBody        Code = "malformed_body"
+	CodeInvalidID            Code = "invalid_id"
+	CodeNotFound             Code = "not_found"
+	CodePreconditionFailed   Code = "precondition_failed" // If-Match didn't match the current ETag
+	CodeUnsupportedMediaType Code = "unsupported_media_type"
+	CodeRateLimited          Code = "rate_limited" // "retry_after" is the wait in seconds
+	CodeInternal             Code = "internal_error"
+	CodeServiceUnavailable   Code = "service_unavailable"
+)
+
+// Authentication and authorization.
+const (
+	CodeAuthenticationRequired Code = "authentication_required"
+	CodeInvalidToken           Code = "invalid_token"
+	CodeSessionRevoked         Code = "session_revoked"
+	CodeFirstPartyOnly         Code = "first_party_only"
+	CodeUserTokenRequired      Code = "user_token_required"
+	CodeInsufficientRole       Code = "insufficient_role"
+	CodeInsufficientScope      Code = "insufficient_scope"  // "required_scope" names the scope
+	CodeMissingPermission      Code = "missing_permission"  // "required_permission" names the permission
+	CodeInvalidCredentials     Code = "invalid_credentials" // Wrong username or password
+	CodeIncorrectPassword      Code = "incorrect_password"  // Wrong password when re-authenticating
+	CodeAccountDeactivated     Code = "account_deactivated"
+	CodePasswordResetRequired  Code = "password_reset_required"
+	CodeInvalidRefreshToken    Code = "invalid_refresh_token"
+)
+
+// Accounts and passwords.
+const (
+	CodeUserNotFound             Code = "user_not_found"
+	CodeUsernameTaken            Code = "username_taken"
+	CodeEmailTaken               Code = "email_taken"
+	CodeInvalidEmail             Code = "invalid_email"
+	CodeEmailRequired            Code = "email_required"
+	CodePasswordPolicy           Code = "password_policy" // "violations" lists the failed rules
+	CodePasswordUnchanged        Code = "password_unchanged"
+	CodeInvalidResetToken        Code = "invalid_reset_token"
+	CodeInvalidVerificationToken Code = "invalid_verification_token"
+	CodeNoProfileChanges         Code = "no_profile_changes"
+	CodeUnknownRoles             Code = "unknown_roles" // "roles" lists the unknown roles
+	CodeSessionNotFound          Code = "session_not_found"
+	CodeCannotManageSelf         Code = "cannot_manage_self"
+	CodeTargetRoleNotHeld        Code = "target_role_not_held" // "roles" lists the target's roles the caller lacks
+)
+
+// Two-factor authentication and passkeys.
+const (
+	CodeSecondFactorRequired      Code = "second_factor_required"
+	CodeInvalidSecondFactor       Code = "invalid_second_factor"
+	CodeInvalidMFAToken           Code = "invalid_mfa_token"
+	CodeMFAAlreadyEnabled         Code = "mfa_already_enabled"
+	CodeMFANoPendingEnrollment    Code = "mfa_no_pending_enrollment"
+	CodePasskeysDisabled          Code = "passkeys_disabled"
+	CodeInvalidPasskeyCredential  Code = "invalid_passkey_credential"
+	CodePasskeyCeremonyExpired    Code = "passkey_ceremony_expired"
+	CodePasskeyVerificationFailed Code = "passkey_verification_failed"
+	CodePasskeyAlreadyRegistered  Code = "passkey_already_registered"
+	CodePasskeyNotFound           Code = "passkey_not_found"
+)
+
+// Sign-in with external identity providers.
+const (
+	CodeUnknownProvider         Code = "unknown_provider"
+	CodeInvalidSignInState      Code = "invalid_sign_in_state"
+	CodeProviderSignInFailed    Code = "provider_sign_in_failed"
+	CodeEmailBelongsToAccount   Code = "email_belongs_to_account"
+	CodeProviderAlreadyLinked   Code = "provider_already_linked"
+	CodeIdentityLinkedElsewhere Code = "identity_linked_elsewhere"
+	CodeIdentityNotLinked       Code = "identity_not_linked"
+	CodeLastSignInMethod        Code = "last_sign_in_method"
+)
+
+// OAuth client management. Errors of the OAuth protocol endpoints themselves keep the RFC 6749 format.
+const (
+	CodeUnknownClient      Code = "unknown_client"
+	CodeInvalidRedirectURI Code = "invalid_redirect_uri"
+	CodeInvalidGrantTypes  Code = "invalid_grant_types"
+	CodeUnknownScope       Code = "unknown_scope"     // "allowed_scopes" lists the valid scopes
+	CodeScopeNotAllowed    Code = "scope_not_allowed" // "allowed_scopes" lists the scopes the client may hold
+	CodeClientNotFound     Code = "client_not_found"
+	CodeConsentNotFound    Code = "consent_not_found"
+)
+
+// Social graph.
+const (
+	CodeCannotFollowSelf      Code = "cannot_follow_self"
+	CodeCannotBlockSelf       Code = "cannot_block_self"
+	CodeCannotMuteSelf        Code = "cannot_mute_self"
+	CodePrivateAccount        Code = "private_account" // Only approved followers may see this
+	CodeFollowRequestNotFound Code = "follow_request_not_found"
+)
+
+// QR codes.
+const (
+	CodeQRCodeNotFound Code = "qr_code_not_found" // Unknown, rotated, used or expired
+)
@@ -0,0 +1,209 @@
+// Package authmw provides the Gin middleware every service uses to authenticate requests
+// with auth-service access tokens, plus role and scope guards for individual routes.
+package authmw
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/jwks"
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// Context keys set by Authenticate. userID/username are kept for handlers written before typed claims.
+// userID is the client's owner for client_credentials tokens when Config.ClientOwner is set.
+const (
+	ContextClaims    = "claims"
+	ContextUserID    = "userID"
+	ContextUsername  = "username"
+	ContextSessionID = "sessionID"
+)
+
+// SessionChecker is called after the token signature and registered claims are verified.
+// Returning ErrSessionRevoked rejects the token with 401; any other error results in a 500.
+type SessionChecker func(claims *models.AuthTokenClaims) error
+
+// ClientOwnerResolver returns the user who registered the client of a client_credentials token.
+// Returning ErrSessionRevoked rejects the token with 401; any other error results in a 500.
+type ClientOwnerResolver func(claims *models.AuthTokenClaims) (uuid.UUID, error)
+
+// ErrInvalidToken is returned by Verify for tokens that fail signature or claims validation.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Config configures Authenticate.
+type Config struct {
+	Keyfunc        jwt.Keyfunc    // Resolves the verification key, e.g. (*jwks.Cache).Keyfunc
+	Issuer         string         // Expected "iss"; not checked when empty
+	Audience       string         // Expected "aud"; not checked when empty
+	CheckSession   SessionChecker // Optional revocation check
+	FirstPartyOnly bool           // Reject tokens issued to third-party OAuth clients, e.g. for account management
+
+	// ClientOwner, if set, makes client_credentials tokens see users the way the client's owner
+	// does, so that their blocks apply. The claims still carry no user, so RequireUser rejects them.
+	ClientOwner ClientOwnerResolver
+}
+
+func (cfg Config) parser() *jwt.Parser {
+	opts := []jwt.ParserOption{jwt.WithValidMethods(jwks.ValidMethods), jwt.WithExpirationRequired()}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	return jwt.NewParser(opts...)
+}
+
+// Verify validates a raw access token the way Authenticate does, for tokens that don't arrive in
+// the Authorization header (e.g. OAuth token introspection). It returns ErrInvalidToken,
+// ErrSessionRevoked, or another error if the session check failed.
+func Verify(cfg Config, rawToken string) (*models.AuthTokenClaims, error) {
+	return verify(cfg.parser(), cfg, rawToken)
+}
+
+func verify(parser *jwt.Parser, cfg Config, rawToken string) (*models.AuthTokenClaims, error) {
+	claims := &models.AuthTokenClaims{}
+	token, err := parser.ParseWithClaims(rawToken, claims, cfg.Keyfunc)
+	if err != nil || !token.Valid {
+		log.Printf("Token validation error: %v", err)
+		return nil, ErrInvalidToken
+	}
+	if claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	if cfg.CheckSession != nil {
+		if err := cfg.CheckSession(claims); err != nil {
+			return nil, err
+		}
+	}
+	return claims, nil
+}
+
+// Authenticate parses the Bearer token into models.AuthTokenClaims and stores them in the context.
+func Authenticate(cfg Config) gin.HandlerFunc {
+	parser := cfg.parser()
+
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeAuthenticationRequired, "Authorization header required"))
+			return
+		}
+
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "Authorization header format must be Bearer {token}"))
+			return
+		}
+
+		claims, err := verify(parser, cfg, parts[1])
+		if err == ErrInvalidToken {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeInvalidToken, "Invalid token"))
+			return
+		}
+		if err == ErrSessionRevoked {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeSessionRevoked, "Session has been revoked"))
+			return
+		}
+		if err != nil {
+			log.Printf("Error checking session for token: %v", err)
+			apierror.Abort(c, apierror.Internal("Failed to verify session"))
+			return
+		}
+		if cfg.FirstPartyOnly && claims.IsThirdParty() {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeFirstPartyOnly, "Not available to third-party applications"))
+			return
+		}
+
+		userID := claims.UserID
+		if !claims.HasUser() && cfg.ClientOwner != nil {
+			userID, err = cfg.ClientOwner(claims)
+			if err == ErrSessionRevoked {
+				apierror.Abort(c, apierror.Unauthorized(apierror.CodeSessionRevoked, "Session has been revoked"))
+				return
+			}
+			if err != nil {
+				log.Printf("Error resolving owner of client %s: %v", claims.ClientID, err)
+				apierror.Abort(c, apierror.Internal("Failed to verify session"))
+				return
+			}
+		}
+
+		c.Set(ContextClaims, claims)
+		c.Set(ContextUserID, userID)
+		c.Set(ContextUsername, claims.Username)
+		c.Set(ContextSessionID, claims.SessionID)
+		c.Next()
+	}
+}
+
+// Claims returns the claims stored by Authenticate.
+func Claims(c *gin.Context) (*models.AuthTokenClaims, bool) {
+	v, ok := c.Get(ContextClaims)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*models.AuthTokenClaims)
+	return claims, ok
+}
+
+// RequireRole allows the request through if the token carries any of the given roles.
+// It must run after Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := Claims(c)
+		if !ok {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeAuthenticationRequired, "Authentication required"))
+			return
+		}
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				c.Next()
+				return
+			}
+		}
+		apierror.Abort(c, apierror.Forbidden(apierror.CodeInsufficientRole, "Insufficient role"))
+	}
+}
+
+// RequireUser rejects tokens that don't act on behalf of a user, i.e. client_credentials tokens.
+// It must run after Authenticate.
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := Claims(c)
+		if !ok {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeAuthenticationRequired, "Authentication required"))
+			return
+		}
+		if !claims.HasUser() {
+			apierror.Abort(c, apierror.Forbidden(apierror.CodeUserTokenRequired, "A user token is required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope allows the request through only if the token was granted every given scope.
+// It must run after Authenticate.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := Claims(c)
+		if !ok {
+			apierror.Abort(c, apierror.Unauthorized(apierror.CodeAuthenticationRequired, "Authentication required"))
+			return
+		}
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				apierror.Abort(c, apierror.Forbidden(apierror.CodeInsufficientScope, "Insufficient scope").With("required_scope", scope))
+				return
+			}
+		}
+		c.Next()
+	}
+}
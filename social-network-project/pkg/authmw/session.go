@@ -0,0 +1,62 @@
+package authmw
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+// ErrSessionRevoked is returned by a SessionChecker when the token's session is no longer active.
+var ErrSessionRevoked = errors.New("session revoked")
+
+// RegistrySessionChecker checks the token's jti against the session registry kept by auth-service,
+// rejects tokens issued before the user's last password change, and bumps the session's last-seen
+// time. Tokens from the OAuth client_credentials grant have no session and are checked against the
+// client's tokens instead.
+func RegistrySessionChecker(sessions repository.SessionRepository) SessionChecker {
+	return func(claims *models.AuthTokenClaims) error {
+		jti, err := uuid.Parse(claims.ID)
+		if err != nil || claims.IssuedAt == nil {
+			return ErrSessionRevoked
+		}
+		ctx := context.Background()
+
+		if !claims.HasUser() {
+			err := sessions.CheckClientToken(ctx, jti, claims.ClientID)
+			if err == repository.ErrTokenNotFound {
+				return ErrSessionRevoked
+			}
+			return err
+		}
+
+		sessionID, err := sessions.CheckAccessToken(ctx, jti, claims.UserID, claims.IssuedAt.Time)
+		if err == repository.ErrSessionNotFound {
+			return ErrSessionRevoked
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := sessions.Touch(ctx, sessionID); err != nil {
+			log.Printf("Error updating last_seen_at for session %s: %v", sessionID, err)
+		}
+		return nil
+	}
+}
+
+// RegistryClientOwner looks up the owner of a client_credentials token's client among the OAuth
+// clients registered with auth-service. Deleted clients revoke the token.
+func RegistryClientOwner(oauth repository.OAuthRepository) ClientOwnerResolver {
+	return func(claims *models.AuthTokenClaims) (uuid.UUID, error) {
+		ownerID, err := oauth.ClientOwner(context.Background(), claims.ClientID)
+		if err == repository.ErrClientNotFound {
+			return uuid.Nil, ErrSessionRevoked
+		}
+		return ownerID, err
+	}
+}
@@ -0,0 +1,127 @@
+package jwks
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRefreshInterval bounds how often an unknown "kid" can trigger a refetch,
+// so a flood of forged tokens can't turn into a flood of requests to auth-service.
+const minRefreshInterval = 10 * time.Second
+
+// Cache fetches a JWKS document over HTTP and keeps the keys in memory.
+// Keys are refreshed after TTL, or early when a token references a kid we haven't seen yet
+// (which is what happens right after auth-service starts signing with a newly rotated key).
+type Cache struct {
+	URL        string
+	TTL        time.Duration
+	HTTPClient *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time // Last successful fetch
+	attemptedAt time.Time // Last fetch attempt, successful or not
+}
+
+// NewCache creates a Cache for the JWKS document at url.
+func NewCache(url string, ttl time.Duration) *Cache {
+	return &Cache{
+		URL:        url,
+		TTL:        ttl,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		keys:       map[string]crypto.PublicKey{},
+	}
+}
+
+// Keyfunc is a jwt.Keyfunc that resolves the token's "kid" header against the cached keys.
+func (c *Cache) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, fmt.Errorf("token has no kid header")
+	}
+
+	key, err := c.key(kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckMethod(token, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (c *Cache) key(kid string) (crypto.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > c.TTL
+	recentlyFetched := time.Since(c.attemptedAt) < minRefreshInterval
+	c.mu.RUnlock()
+
+	if ok && (!stale || recentlyFetched) {
+		return key, nil
+	}
+	if !ok && recentlyFetched {
+		return nil, ErrUnknownKey
+	}
+
+	if err := c.Refresh(); err != nil {
+		if ok {
+			// auth-service is unreachable; keep verifying with the key we already trust.
+			log.Printf("JWKS refresh failed, using cached key %q: %v", kid, err)
+			return key, nil
+		}
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if key, ok := c.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// Refresh fetches the JWKS document and replaces the cached keys.
+func (c *Cache) Refresh() error {
+	c.mu.Lock()
+	c.attemptedAt = time.Now()
+	c.mu.Unlock()
+
+	resp, err := c.HTTPClient.Get(c.URL)
+	if err != nil {
+		return fmt.Errorf("jwks: fetching %s: %w", c.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks: fetching %s: unexpected status %d", c.URL, resp.StatusCode)
+	}
+
+	var set Set
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("jwks: decoding %s: %w", c.URL, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			log.Printf("Skipping JWKS key: %v", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
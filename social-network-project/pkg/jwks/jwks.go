@@ -0,0 +1,110 @@
+// Package jwks converts between public keys and JSON Web Keys (RFC 7517) and provides a
+// cached JWKS client so downstream services can verify auth-service tokens without
+// ever holding the signing key.
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JSONWebKey is the subset of RFC 7517 fields needed for RSA and Ed25519 public keys.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Set is the document served at /.well-known/jwks.json.
+type Set struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// FromPublicKey builds the JWK for an RSA or Ed25519 public key.
+func FromPublicKey(kid string, pub crypto.PublicKey) (JSONWebKey, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JSONWebKey{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	default:
+		return JSONWebKey{}, fmt.Errorf("jwks: unsupported public key type %T", pub)
+	}
+}
+
+// PublicKey decodes the JWK back into an *rsa.PublicKey or ed25519.PublicKey.
+func (k JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: invalid RSA modulus for kid %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: invalid RSA exponent for kid %q: %w", k.Kid, err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("jwks: unsupported OKP curve %q for kid %q", k.Crv, k.Kid)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("jwks: invalid Ed25519 key for kid %q", k.Kid)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("jwks: unsupported key type %q for kid %q", k.Kty, k.Kid)
+	}
+}
+
+// ErrUnknownKey is returned when a token's "kid" is not in the key set.
+var ErrUnknownKey = errors.New("jwks: unknown signing key")
+
+// CheckMethod makes sure the token was signed with the algorithm family matching the key,
+// so an attacker can't, for example, present an HS256 token "signed" with a public key.
+func CheckMethod(token *jwt.Token, key crypto.PublicKey) error {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); ok {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+}
+
+// ValidMethods lists the algorithms tokens may be signed with, for jwt.WithValidMethods.
+var ValidMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
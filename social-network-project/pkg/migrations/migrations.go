@@ -0,0 +1,104 @@
+// Package migrations versions the database schema shared by auth-service and user-service.
+//
+// Migrations are pairs of SQL files in sql/, named <version>_<name>.up.sql and
+// <version>_<name>.down.sql, embedded in every binary. Applied versions are recorded in the
+// schema_migrations table together with a checksum of their up script, so an edited migration is
+// detected instead of silently diverging between databases. cmd/migrate applies them; the services
+// only check that the schema is current and refuse to start otherwise.
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// Migration is one schema change. Each runs in its own transaction, so it must not contain
+// statements Postgres refuses inside one, such as CREATE INDEX CONCURRENTLY.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // Hex SHA-256 of Up
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// fileName matches migration files, e.g. "0003_create_password_reset_tokens.up.sql".
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Embedded returns the migrations built into the binary, oldest first.
+func Embedded() ([]Migration, error) {
+	dir, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return Load(dir)
+}
+
+// Load reads the migrations in the root of fsys, oldest first. Every version needs both an up and
+// a down file.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %q is not named <version>_<name>.(up|down).sql", entry.Name())
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration file %q has an invalid version", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			m.Up, m.Checksum = string(content), hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m)
+		}
+		if m.Down == "" {
+			return nil, fmt.Errorf("migration %s has no down script", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
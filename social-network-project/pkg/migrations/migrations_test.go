@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// files returns a filesystem holding up and down scripts for each migration name, e.g. "0002_b".
+func files(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys[name+".up.sql"] = &fstest.MapFile{Data: []byte("-- up " + name)}
+		fsys[name+".down.sql"] = &fstest.MapFile{Data: []byte("-- down " + name)}
+	}
+	return fsys
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	// Read in name order, "10_" sorts before "2_"; versions must still come out numerically.
+	migrations, err := Load(files("10_ten", "2_two", "0001_one"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	var got []string
+	for _, m := range migrations {
+		got = append(got, m.String())
+	}
+	if want := "0001_one 0002_two 0010_ten"; strings.Join(got, " ") != want {
+		t.Errorf("Load = %v, want %s", got, want)
+	}
+
+	sum := sha256.Sum256([]byte("-- up 2_two"))
+	if m := migrations[1]; m.Up != "-- up 2_two" || m.Down != "-- down 2_two" || m.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("migration 2 = %+v, want its scripts and the SHA-256 of the up script", m)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+		want string
+	}{
+		{"bad name", fstest.MapFS{"create_users.up.sql": {}}, "is not named"},
+		{"version zero", files("0000_zero"), "invalid version"},
+		{"duplicate version", files("0001_one", "0001_uno"), "is used by both"},
+		{"no down script", fstest.MapFS{"0001_one.up.sql": {Data: []byte("--")}}, "has no down script"},
+		{"no up script", fstest.MapFS{"0001_one.down.sql": {Data: []byte("--")}}, "has no up script"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.fsys)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load: err = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatalf("Embedded: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d is %s, want versions numbered from 1 without gaps", i, m)
+		}
+	}
+}
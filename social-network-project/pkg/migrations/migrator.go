@@ -0,0 +1,344 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrOutdated is returned by Check when migrations built into the binary haven't been applied.
+var ErrOutdated = errors.New("migrations: database schema is outdated")
+
+// ErrModified is returned when an applied migration's up script no longer matches its checksum.
+// Applied migrations must never be edited; add a new migration instead.
+var ErrModified = errors.New("migrations: applied migration was modified")
+
+// lockID is the pg_advisory_lock key held while migrating, so replicas starting together or a
+// migrate run racing a deploy apply each migration once.
+const lockID int64 = 7294618305521
+
+// State is where a migration stands in a database.
+type State string
+
+const (
+	StatePending  State = "pending"
+	StateApplied  State = "applied"
+	StateModified State = "modified" // Applied, but the up script changed since
+	StateUnknown  State = "unknown"  // Applied by a newer binary that has migrations this one lacks
+)
+
+// Status describes one migration, known to the binary or recorded in the database.
+type Status struct {
+	Version   int
+	Name      string
+	State     State
+	AppliedAt time.Time // Zero when pending
+}
+
+// Migrator applies and reverts migrations against a database.
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration // Oldest first
+}
+
+// New creates a Migrator for the migrations built into the binary.
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Embedded()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// Latest returns the newest version known to the binary, or 0 if there are no migrations.
+func (m *Migrator) Latest() int {
+	if len(m.Migrations) == 0 {
+		return 0
+	}
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Status reports every known migration and every applied one the binary doesn't know, by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	done, err := readApplied(ctx, m.DB)
+	if err != nil {
+		return nil, err
+	}
+	return m.statuses(done), nil
+}
+
+// statuses compares the known migrations with the applied ones in done, which it empties.
+func (m *Migrator) statuses(done map[int]appliedMigration) []Status {
+	statuses := make([]Status, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, State: StatePending}
+		if record, ok := done[migration.Version]; ok {
+			status.State, status.AppliedAt = StateApplied, record.appliedAt
+			if record.checksum != migration.Checksum {
+				status.State = StateModified
+			}
+			delete(done, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for version, record := range done {
+		statuses = append(statuses, Status{Version: version, Name: record.name, State: StateUnknown, AppliedAt: record.appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// Check returns ErrOutdated if a known migration is pending and ErrModified if one was edited after
+// being applied. Migrations applied by a newer binary are fine, so old replicas keep running while
+// a deploy rolls out.
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return checkStatuses(statuses)
+}
+
+func checkStatuses(statuses []Status) error {
+	var pending, modified []string
+	for _, status := range statuses {
+		switch status.State {
+		case StatePending:
+			pending = append(pending, fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		case StateModified:
+			modified = append(modified, fmt.Sprintf("%04d_%s", status.Version, status.Name))
+		}
+	}
+	if len(modified) > 0 {
+		return fmt.Errorf("%w: %s", ErrModified, strings.Join(modified, ", "))
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s not applied", ErrOutdated, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// startupTimeout bounds CheckCurrent, including the wait for another replica's migration lock.
+const startupTimeout = 5 * time.Minute
+
+// CheckCurrent is the schema check the services run before serving: it fails with ErrOutdated or
+// ErrModified unless db has every migration built into the binary. With migrate set (the services'
+// MIGRATE_ON_START) pending migrations are applied first, which is convenient in development; the
+// advisory lock keeps replicas from racing. Otherwise cmd/migrate must run before deploying.
+func CheckCurrent(db *sql.DB, migrate bool) error {
+	migrator, err := New(db)
+	if err != nil {
+		return fmt.Errorf("loading migrations: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), startupTimeout)
+	defer cancel()
+
+	if migrate {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Printf("Applied migration %s", migration)
+		}
+		if err != nil {
+			return fmt.Errorf("migrating database: %w", err)
+		}
+	}
+	if err := migrator.Check(ctx); err != nil {
+		return err
+	}
+	log.Printf("Database schema is at migration %d.", migrator.Latest())
+	return nil
+}
+
+// Up applies every pending migration and returns those it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.migrate(ctx, func(applied []int) (int, error) { return m.Latest(), nil })
+}
+
+// Down reverts the latest steps applied migrations and returns those it reverted, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	return m.migrate(ctx, func(applied []int) (int, error) {
+		if steps > len(applied) {
+			return 0, fmt.Errorf("only %d migrations are applied", len(applied))
+		}
+		if steps == len(applied) {
+			return 0, nil
+		}
+		return applied[len(applied)-1-steps], nil
+	})
+}
+
+// To reverts every migration newer than version and applies every pending one up to it, returning
+// the migrations it reverted and applied, in that order.
+func (m *Migrator) To(ctx context.Context, version int) ([]Migration, error) {
+	return m.migrate(ctx, func(applied []int) (int, error) {
+		if version == 0 {
+			return 0, nil
+		}
+		if _, ok := m.find(version); !ok {
+			return 0, fmt.Errorf("unknown migration version %d", version)
+		}
+		return version, nil
+	})
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.Migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// migrate moves the schema to the version target picks from the applied versions (ascending),
+// holding the advisory lock throughout. Each migration commits on its own, so after an error the
+// schema is left at the last one that succeeded.
+func (m *Migrator) migrate(ctx context.Context, target func(applied []int) (int, error)) ([]Migration, error) {
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return nil, fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	_, err = conn.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	);`)
+	if err != nil {
+		return nil, fmt.Errorf("creating schema_migrations table: %w", err)
+	}
+
+	// Read what's applied only once the lock is held: another replica may just have migrated.
+	done, err := readApplied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	revert, apply, err := m.plan(done, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []Migration
+	for _, migration := range revert {
+		if err := run(ctx, conn, migration.Down, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+			return changed, fmt.Errorf("reverting %s: %w", migration, err)
+		}
+		changed = append(changed, migration)
+	}
+	for _, migration := range apply {
+		err := run(ctx, conn, migration.Up, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			migration.Version, migration.Name, migration.Checksum)
+		if err != nil {
+			return changed, fmt.Errorf("applying %s: %w", migration, err)
+		}
+		changed = append(changed, migration)
+	}
+	return changed, nil
+}
+
+// plan works out how to move the schema from the migrations applied in done to the version target
+// picks: the migrations to revert, newest first, then those to apply, oldest first.
+func (m *Migrator) plan(done map[int]appliedMigration, target func(applied []int) (int, error)) (revert, apply []Migration, err error) {
+	applied := make([]int, 0, len(done))
+	for version := range done {
+		if migration, ok := m.find(version); ok && done[version].checksum != migration.Checksum {
+			return nil, nil, fmt.Errorf("%w: %s", ErrModified, migration)
+		}
+		applied = append(applied, version)
+	}
+	sort.Ints(applied)
+
+	version, err := target(applied)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := len(applied) - 1; i >= 0 && applied[i] > version; i-- {
+		migration, ok := m.find(applied[i])
+		if !ok {
+			return nil, nil, fmt.Errorf("migration %04d_%s was applied by a newer binary and can't be reverted by this one",
+				applied[i], done[applied[i]].name)
+		}
+		revert = append(revert, migration)
+	}
+	for _, migration := range m.Migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, ok := done[migration.Version]; !ok {
+			apply = append(apply, migration)
+		}
+	}
+	return revert, apply, nil
+}
+
+// run executes a migration script and the statement recording it in one transaction.
+func run(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Rollback is a no-op if Commit succeeds
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type appliedMigration struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// readApplied returns the recorded migrations by version. A database that was never migrated has
+// no schema_migrations table and so no applied migrations.
+func readApplied(ctx context.Context, db queryer) (map[int]appliedMigration, error) {
+	done := map[int]appliedMigration{}
+	var exists bool
+	if err := db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return done, nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			version int
+			record  appliedMigration
+		)
+		if err := rows.Scan(&version, &record.name, &record.checksum, &record.appliedAt); err != nil {
+			return nil, err
+		}
+		done[version] = record
+	}
+	return done, rows.Err()
+}
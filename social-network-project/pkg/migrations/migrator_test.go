@@ -0,0 +1,141 @@
+package migrations
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestMigrator returns a Migrator, without a database, for migrations with the names given.
+func newTestMigrator(t *testing.T, names ...string) *Migrator {
+	t.Helper()
+	migrations, err := Load(files(names...))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return &Migrator{Migrations: migrations}
+}
+
+// applied records the migrations the way schema_migrations does.
+func applied(migrations ...Migration) map[int]appliedMigration {
+	done := map[int]appliedMigration{}
+	for _, m := range migrations {
+		done[m.Version] = appliedMigration{name: m.Name, checksum: m.Checksum}
+	}
+	return done
+}
+
+// versions returns the versions of the migrations, in order.
+func versions(migrations []Migration) []int {
+	v := []int{}
+	for _, m := range migrations {
+		v = append(v, m.Version)
+	}
+	return v
+}
+
+func TestPlan(t *testing.T) {
+	m := newTestMigrator(t, "0001_a", "0002_b", "0003_c", "0004_d")
+	all := m.Migrations
+	up := func([]int) (int, error) { return m.Latest(), nil }
+	to := func(version int) func([]int) (int, error) {
+		return func([]int) (int, error) { return version, nil }
+	}
+	newer := applied(all[0])
+	newer[5] = appliedMigration{name: "e", checksum: "unknown to this binary"}
+
+	tests := []struct {
+		name        string
+		done        map[int]appliedMigration
+		target      func([]int) (int, error)
+		wantRevert  []int
+		wantApply   []int
+		wantErrText string
+	}{
+		{"up from empty", applied(), up, []int{}, []int{1, 2, 3, 4}, ""},
+		{"up skips applied", applied(all[0], all[1]), up, []int{}, []int{3, 4}, ""},
+		{"up fills a gap", applied(all[0], all[2]), up, []int{}, []int{2, 4}, ""},
+		{"up when current", applied(all...), up, []int{}, []int{}, ""},
+		{"down to a version", applied(all...), to(1), []int{4, 3, 2}, []int{}, ""},
+		{"down to zero", applied(all[0], all[1]), to(0), []int{2, 1}, []int{}, ""},
+		{"to an older version applies up to it", applied(all[0], all[3]), to(2), []int{4}, []int{2}, ""},
+		{"newer binary's migration", newer, to(1), nil, nil, "applied by a newer binary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revert, apply, err := m.plan(tt.done, tt.target)
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("plan: err = %v, want one containing %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("plan: %v", err)
+			}
+			if got := versions(revert); !slices.Equal(got, tt.wantRevert) {
+				t.Errorf("revert = %v, want %v", got, tt.wantRevert)
+			}
+			if got := versions(apply); !slices.Equal(got, tt.wantApply) {
+				t.Errorf("apply = %v, want %v", got, tt.wantApply)
+			}
+		})
+	}
+}
+
+func TestPlanRejectsModifiedMigration(t *testing.T) {
+	m := newTestMigrator(t, "0001_a", "0002_b")
+	done := applied(m.Migrations...)
+	record := done[1]
+	record.checksum = "edited since"
+	done[1] = record
+
+	_, _, err := m.plan(done, func([]int) (int, error) { return m.Latest(), nil })
+	if !errors.Is(err, ErrModified) || !strings.Contains(err.Error(), "0001_a") {
+		t.Errorf("plan: err = %v, want ErrModified naming 0001_a", err)
+	}
+}
+
+func TestStatusesAndCheck(t *testing.T) {
+	m := newTestMigrator(t, "0001_a", "0002_b", "0003_c")
+	all := m.Migrations
+	modified := applied(all...)
+	modified[2] = appliedMigration{name: "b", checksum: "edited since"}
+	newer := applied(all...)
+	newer[4] = appliedMigration{name: "d", checksum: "unknown to this binary"}
+
+	tests := []struct {
+		name      string
+		done      map[int]appliedMigration
+		want      []State
+		wantErr   error
+		wantNamed string // Migration the error names
+	}{
+		{"current", applied(all...), []State{StateApplied, StateApplied, StateApplied}, nil, ""},
+		{"pending", applied(all[0]), []State{StateApplied, StatePending, StatePending}, ErrOutdated, "0002_b, 0003_c"},
+		{"modified", modified, []State{StateApplied, StateModified, StateApplied}, ErrModified, "0002_b"},
+		// Old replicas keep running while a deploy with a new migration rolls out.
+		{"applied by a newer binary", newer, []State{StateApplied, StateApplied, StateApplied, StateUnknown}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := m.statuses(tt.done)
+			var got []State
+			for i, status := range statuses {
+				got = append(got, status.State)
+				if status.Version != i+1 {
+					t.Errorf("status %d is for version %d, want %d", i, status.Version, i+1)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("states = %v, want %v", got, tt.want)
+			}
+
+			err := checkStatuses(statuses)
+			if !errors.Is(err, tt.wantErr) || (err != nil && !strings.Contains(err.Error(), tt.wantNamed)) {
+				t.Errorf("checkStatuses: err = %v, want %v naming %s", err, tt.wantErr, tt.wantNamed)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+-- Accounts, shared by auth-service and user-service.
+CREATE TABLE IF NOT EXISTS users (
+	id UUID PRIMARY KEY,
+	username VARCHAR(255) UNIQUE NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	display_name VARCHAR(255),
+	bio TEXT,
+	qr_code_identifier VARCHAR(255) UNIQUE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	is_active BOOLEAN DEFAULT TRUE,
+	password_reset_required BOOLEAN NOT NULL DEFAULT FALSE,
+	email VARCHAR(255),
+	email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+	password_changed_at TIMESTAMPTZ
+);
+
+-- Databases created before migrations existed may have an older users table.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
@@ -0,0 +1,9 @@
+-- Single-use email verification tokens.
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+	token_hash VARCHAR(64) PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	email VARCHAR(255) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
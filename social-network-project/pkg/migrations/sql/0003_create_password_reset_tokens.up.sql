@@ -0,0 +1,9 @@
+-- Single-use password reset tokens.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+	token_hash VARCHAR(64) PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
@@ -0,0 +1,29 @@
+-- The role/permission model, seeded with the built-in roles. Roles are read by auth-service when
+-- issuing tokens and managed through user-service's admin API.
+CREATE TABLE IF NOT EXISTS roles (
+	name VARCHAR(64) PRIMARY KEY,
+	description TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS role_permissions (
+	role VARCHAR(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+	permission VARCHAR(64) NOT NULL,
+	PRIMARY KEY (role, permission)
+);
+CREATE TABLE IF NOT EXISTS user_roles (
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	role VARCHAR(64) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+	granted_by UUID,
+	granted_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (user_id, role)
+);
+
+INSERT INTO roles (name, description) VALUES
+	('admin', 'Full access to the admin API'),
+	('support', 'Support staff: view, deactivate, unlock and force password resets')
+ON CONFLICT DO NOTHING;
+INSERT INTO role_permissions (role, permission) VALUES
+	('admin', 'users:read'), ('admin', 'users:deactivate'), ('admin', 'users:reset_password'),
+	('admin', 'users:unlock'), ('admin', 'roles:assign'), ('admin', 'audit:read'),
+	('support', 'users:read'), ('support', 'users:deactivate'), ('support', 'users:reset_password'),
+	('support', 'users:unlock')
+ON CONFLICT DO NOTHING;
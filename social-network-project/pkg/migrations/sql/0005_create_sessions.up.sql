@@ -0,0 +1,26 @@
+-- The server-side session registry. session_tokens maps every issued access token's jti to its
+-- session so tokens can be revoked before they expire.
+CREATE TABLE IF NOT EXISTS sessions (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	device_name VARCHAR(255) NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_address VARCHAR(64) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	last_seen_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ,
+	client_id VARCHAR(64),
+	scope TEXT
+);
+-- Databases created before migrations existed may have an older sessions table.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS scope TEXT;
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_client_id ON sessions (client_id) WHERE client_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS session_tokens (
+	jti UUID PRIMARY KEY,
+	session_id UUID NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+	expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,26 @@
+-- TOTP two-factor authentication: per-user secrets, hashed recovery codes and the short-lived
+-- challenges handed out by a password-only login.
+CREATE TABLE IF NOT EXISTS user_totp (
+	user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	secret VARCHAR(64) NOT NULL,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	confirmed_at TIMESTAMPTZ
+);
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	code_hash VARCHAR(64) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	used_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes (user_id);
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+	token_hash VARCHAR(64) PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	device_name VARCHAR(255) NOT NULL DEFAULT '',
+	attempts INT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
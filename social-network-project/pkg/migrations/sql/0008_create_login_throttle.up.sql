@@ -0,0 +1,7 @@
+-- Failed-login counters used by pkg/throttle, keyed by "user:<username>" or "ip:<address>".
+CREATE TABLE IF NOT EXISTS login_throttle (
+	key VARCHAR(320) PRIMARY KEY,
+	failures INT NOT NULL DEFAULT 0,
+	last_failure_at TIMESTAMPTZ NOT NULL,
+	locked_until TIMESTAMPTZ
+);
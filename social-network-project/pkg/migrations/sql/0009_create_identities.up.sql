@@ -0,0 +1,22 @@
+-- External (OIDC) sign-in: identities linked to users, and the state of authorization requests
+-- in progress.
+CREATE TABLE IF NOT EXISTS user_identities (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	provider VARCHAR(64) NOT NULL,
+	subject VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	last_login_at TIMESTAMPTZ,
+	UNIQUE (provider, subject),
+	UNIQUE (user_id, provider)
+);
+CREATE TABLE IF NOT EXISTS oidc_states (
+	state_hash VARCHAR(64) PRIMARY KEY,
+	provider VARCHAR(64) NOT NULL,
+	user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+	code_verifier VARCHAR(128) NOT NULL,
+	nonce VARCHAR(64) NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL
+);
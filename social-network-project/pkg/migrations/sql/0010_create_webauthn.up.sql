@@ -0,0 +1,27 @@
+-- Passkeys: registered credentials with their sign counters, and the state of registration/login
+-- ceremonies between their begin and finish calls.
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name VARCHAR(255) NOT NULL DEFAULT '',
+	credential_id BYTEA UNIQUE NOT NULL,
+	public_key BYTEA NOT NULL,
+	attestation_type VARCHAR(64) NOT NULL DEFAULT '',
+	transports TEXT[] NOT NULL DEFAULT '{}',
+	flags SMALLINT NOT NULL DEFAULT 0,
+	backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+	aaguid BYTEA,
+	sign_count BIGINT NOT NULL DEFAULT 0,
+	clone_warning BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	last_used_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials (user_id);
+CREATE TABLE IF NOT EXISTS webauthn_ceremonies (
+	id UUID PRIMARY KEY,
+	user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+	kind VARCHAR(16) NOT NULL,
+	session_data JSONB NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,42 @@
+-- Acting as an OAuth 2.0 authorization server: registered third-party clients, the consent users
+-- gave them, single-use authorization codes, and the jti of client_credentials tokens, which have
+-- no session.
+CREATE TABLE IF NOT EXISTS oauth_clients (
+	id VARCHAR(64) PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	secret_hash VARCHAR(64),
+	redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	grant_types TEXT[] NOT NULL DEFAULT '{}',
+	owner_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	revoked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_clients_owner_user_id ON oauth_clients (owner_user_id);
+CREATE TABLE IF NOT EXISTS oauth_consents (
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	updated_at TIMESTAMPTZ DEFAULT NOW(),
+	PRIMARY KEY (user_id, client_id)
+);
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+	code_hash VARCHAR(64) PRIMARY KEY,
+	client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	redirect_uri TEXT NOT NULL,
+	scope TEXT NOT NULL,
+	code_challenge VARCHAR(128) NOT NULL,
+	session_id UUID REFERENCES sessions(id) ON DELETE SET NULL,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ
+);
+CREATE TABLE IF NOT EXISTS oauth_client_tokens (
+	jti UUID PRIMARY KEY,
+	client_id VARCHAR(64) NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	revoked_at TIMESTAMPTZ
+);
@@ -0,0 +1,10 @@
+-- Every admin API action taken through user-service.
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+	id UUID PRIMARY KEY,
+	actor_id UUID NOT NULL,
+	action VARCHAR(64) NOT NULL,
+	target_user_id UUID,
+	details JSONB,
+	created_at TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target_user_id ON admin_audit_log (target_user_id);
@@ -0,0 +1,2 @@
+-- The original case of usernames is not restored.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_lowercase;
@@ -0,0 +1,15 @@
+-- Usernames are case-insensitive and stored lower-cased (models.NormalizeUsername), so the
+-- existing unique constraint on username also rejects names differing only in case.
+DO $$
+DECLARE
+	duplicates TEXT;
+BEGIN
+	SELECT string_agg(name, ', ') INTO duplicates
+	FROM (SELECT lower(username) AS name FROM users GROUP BY lower(username) HAVING count(*) > 1) AS d;
+	IF duplicates IS NOT NULL THEN
+		RAISE EXCEPTION 'usernames differing only in case must be renamed before migrating: %', duplicates;
+	END IF;
+END $$;
+
+UPDATE users SET username = lower(username) WHERE username <> lower(username);
+ALTER TABLE users ADD CONSTRAINT users_username_lowercase CHECK (username = lower(username));
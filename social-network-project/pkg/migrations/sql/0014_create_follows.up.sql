@@ -0,0 +1,10 @@
+-- The social graph: follower_id follows followee_id.
+CREATE TABLE follows (
+	follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (follower_id, followee_id),
+	CHECK (follower_id <> followee_id)
+);
+-- The primary key serves "following" lists; this index serves "followers" lists.
+CREATE INDEX idx_follows_followee_id ON follows (followee_id, created_at DESC);
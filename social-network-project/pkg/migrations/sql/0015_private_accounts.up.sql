@@ -0,0 +1,11 @@
+-- Private accounts approve their followers: following one creates a request instead of a follow.
+ALTER TABLE users ADD COLUMN is_private BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE follow_requests (
+	requester_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	target_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (requester_id, target_id),
+	CHECK (requester_id <> target_id)
+);
+CREATE INDEX idx_follow_requests_target_id ON follow_requests (target_id, created_at DESC);
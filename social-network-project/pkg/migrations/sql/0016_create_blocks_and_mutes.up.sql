@@ -0,0 +1,19 @@
+-- Blocks hide two users from each other entirely; mutes only hide the muted user's content from
+-- the muter, who stays visible to them.
+CREATE TABLE user_blocks (
+	blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (blocker_id, blocked_id),
+	CHECK (blocker_id <> blocked_id)
+);
+-- The primary key serves block lists; this index serves checks from the blocked side.
+CREATE INDEX idx_user_blocks_blocked_id ON user_blocks (blocked_id);
+
+CREATE TABLE user_mutes (
+	muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (muter_id, muted_id),
+	CHECK (muter_id <> muted_id)
+);
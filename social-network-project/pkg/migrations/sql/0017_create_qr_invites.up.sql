@@ -0,0 +1,11 @@
+-- One-time QR invites: scanning one and accepting it follows the inviter without a follow
+-- request, so they can be handed out in person even by private accounts.
+CREATE TABLE qr_invites (
+	token_hash VARCHAR(64) PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	used_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_qr_invites_user_id ON qr_invites (user_id);
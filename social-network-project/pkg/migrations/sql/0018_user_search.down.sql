@@ -0,0 +1,3 @@
+-- pg_trgm stays installed: dropping it could break anything else that came to rely on it.
+DROP INDEX IF EXISTS idx_users_display_name_trgm;
+DROP INDEX IF EXISTS idx_users_username_trgm;
@@ -0,0 +1,5 @@
+-- Trigram indexes for user search: they serve both prefix (LIKE 'q%') and fuzzy (%) matches.
+-- pg_trgm is a trusted extension, so the database owner may create it without superuser rights.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX idx_users_username_trgm ON users USING gin (username gin_trgm_ops);
+CREATE INDEX idx_users_display_name_trgm ON users USING gin (lower(display_name) gin_trgm_ops);
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles that can be assigned through the admin API, in addition to RoleUser which everyone has.
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+// Permissions checked by the admin API. Roles are granted permissions in the role_permissions table.
+const (
+	PermUsersRead          = "users:read"
+	PermUsersDeactivate    = "users:deactivate"
+	PermUsersResetPassword = "users:reset_password"
+	PermUsersUnlock        = "users:unlock"
+	PermRolesAssign        = "roles:assign"
+	PermAuditRead          = "audit:read"
+)
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// AdminUserView is the admin API's view of a user, including fields hidden from regular profiles.
+type AdminUserView struct {
+	User
+	Roles                 []string `json:"roles"`
+	PasswordResetRequired bool     `json:"password_reset_required"`
+}
+
+// AssignRolesRequest replaces the set of roles assigned to a user.
+type AssignRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+// AuditLogEntry records one action taken through the admin API.
+type AuditLogEntry struct {
+	ID           uuid.UUID       `json:"id"`
+	ActorID      uuid.UUID       `json:"actor_id"`
+	Action       string          `json:"action"`
+	TargetUserID *uuid.UUID      `json:"target_user_id,omitempty"`
+	Details      json.RawMessage `json:"details,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// BlockEntry is a user in the list of users someone blocked.
+type BlockEntry struct {
+	UserSummary
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// MuteEntry is a user in the list of users someone muted.
+type MuteEntry struct {
+	UserSummary
+	MutedAt time.Time `json:"muted_at"`
+}
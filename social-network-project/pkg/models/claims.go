@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// RoleUser is carried in every access token. Other roles (see admin.go) come from the user_roles table.
+const RoleUser = "user"
+
+// OAuth-style scopes carried in access tokens. First-party logins get DefaultScopes.
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeFollowsRead  = "follows:read"  // Followers and following lists, and the user's blocks and mutes
+	ScopeFollowsWrite = "follows:write" // Following, blocking and muting on the user's behalf
+)
+
+// DefaultScopes are granted to tokens issued by a regular username/password login.
+var DefaultScopes = []string{ScopeProfileRead, ScopeProfileWrite, ScopeFollowsRead, ScopeFollowsWrite}
+
+// AuthTokenClaims represents the JWT claims. It is the single claims type auth-service
+// signs and every other service parses (see pkg/authmw).
+type AuthTokenClaims struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	SessionID uuid.UUID `json:"sid"`
+	Roles     []string  `json:"roles,omitempty"`
+	Scope     string    `json:"scope,omitempty"`     // Space-delimited, as in OAuth 2.0 (RFC 6749 section 3.3)
+	ClientID  string    `json:"client_id,omitempty"` // Set on tokens issued to third-party OAuth clients
+	jwt.RegisteredClaims
+}
+
+// Scopes returns the individual scopes granted to the token.
+func (c *AuthTokenClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether the token was granted the given scope.
+func (c *AuthTokenClaims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsThirdParty reports whether the token was issued to a third-party OAuth client rather than our own apps.
+func (c *AuthTokenClaims) IsThirdParty() bool {
+	return c.ClientID != ""
+}
+
+// HasUser reports whether the token acts on behalf of a user. Tokens from the client_credentials
+// grant only identify the client.
+func (c *AuthTokenClaims) HasUser() bool {
+	return c.UserID != uuid.Nil
+}
+
+// HasRole reports whether the token carries the given role.
+func (c *AuthTokenClaims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserSummary is the short form of a user shown in lists.
+type UserSummary struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name,omitempty"`
+}
+
+// FollowEntry is a user in a followers or following list.
+type FollowEntry struct {
+	UserSummary
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// FollowRequest is a pending request to follow a private account.
+type FollowRequest struct {
+	UserSummary
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// FollowStatus tells the caller where they stand with a user.
+type FollowStatus struct {
+	IsFollowing     bool `json:"is_following"`
+	FollowRequested bool `json:"follow_requested"` // A request to follow the private account is pending
+}
+
+// UserProfile is a user's profile as seen by another user.
+type UserProfile struct {
+	User
+	FollowerCount  int `json:"follower_count"`
+	FollowingCount int `json:"following_count"`
+	*FollowStatus      // Absent for tokens without a user
+}
+
+// LimitedProfile is what a private account shows to users who aren't its approved followers.
+type LimitedProfile struct {
+	UserSummary
+	IsPrivate      bool `json:"is_private"`
+	FollowerCount  int  `json:"follower_count"`
+	FollowingCount int  `json:"following_count"`
+	*FollowStatus       // Absent for tokens without a user
+}
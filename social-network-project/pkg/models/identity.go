@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Identity is an external OpenID Connect account linked to a user.
+type Identity struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email,omitempty"` // Email reported by the provider when the identity was linked
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OIDCStartResponse starts a sign-in or link with an external provider. The client sends the user
+// to AuthorizationURL and should check that the state it gets back on its redirect URL matches State.
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OIDCCallbackRequest carries the code and state the provider appended to the client's redirect URL.
+type OIDCCallbackRequest struct {
+	Code       string `json:"code" binding:"required"`
+	State      string `json:"state" binding:"required"`
+	DeviceName string `json:"device_name,omitempty"` // Optional: shown in the session list
+}
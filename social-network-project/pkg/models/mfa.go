@@ -0,0 +1,66 @@
+package models
+
+// Second-factor methods a login challenge can be completed with.
+const (
+	MFAMethodTOTP         = "totp"
+	MFAMethodRecoveryCode = "recovery_code"
+)
+
+// MFAChallengeResponse is returned by /auth/login instead of tokens when the account has
+// two-factor authentication enabled. MFAToken is exchanged at /auth/login/mfa.
+type MFAChallengeResponse struct {
+	MFARequired bool     `json:"mfa_required"`
+	MFAToken    string   `json:"mfa_token"`
+	ExpiresIn   int64    `json:"expires_in"` // Lifetime of MFAToken in seconds
+	Methods     []string `json:"methods"`    // Second factors accepted for this account
+}
+
+// MFACode carries a second factor: either a code from the authenticator app or one of the
+// recovery codes handed out at enrollment. Exactly one of the two must be set.
+type MFACode struct {
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// MFALoginRequest completes a login that returned an MFAChallengeResponse.
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	MFACode
+}
+
+// TOTPEnrollResponse holds a new, not yet confirmed TOTP secret. OTPAuthURI is what
+// authenticator apps import, usually rendered as a QR code by the client.
+type TOTPEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// TOTPConfirmRequest proves the authenticator app was set up by sending its current code.
+type TOTPConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFARequest turns two-factor authentication off. Both the password and a second
+// factor are needed so a stolen session alone can't remove it.
+type DisableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	MFACode
+}
+
+// RegenerateRecoveryCodesRequest replaces the recovery codes. Like DisableMFARequest it needs
+// the password as well as a second factor.
+type RegenerateRecoveryCodesRequest struct {
+	Password string `json:"password" binding:"required"`
+	MFACode
+}
+
+// RecoveryCodesResponse lists freshly generated recovery codes. They are only shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatus describes the authenticated user's two-factor setup.
+type MFAStatus struct {
+	TOTPEnabled            bool `json:"totp_enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
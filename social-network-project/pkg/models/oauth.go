@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// OAuth 2.0 grant types supported by /oauth/token.
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// OAuthScopes are the scopes third-party clients can be registered for and request.
+var OAuthScopes = []string{ScopeProfileRead, ScopeProfileWrite, ScopeFollowsRead, ScopeFollowsWrite}
+
+// ClientCredentialsScopes are the only scopes a client registered for the client_credentials grant
+// may hold. Its tokens act for no user: user-service lets them read profiles, search, QR codes and
+// follower lists as the user who registered the client would see them, blocks included, and turns
+// them away from /users/me and everything that changes data.
+var ClientCredentialsScopes = []string{ScopeProfileRead, ScopeFollowsRead}
+
+// OAuthClient is a third-party application registered to use the social network's API on behalf of users.
+type OAuthClient struct {
+	ID           string    `json:"client_id"`
+	Name         string    `json:"name"`
+	Confidential bool      `json:"confidential"` // Confidential clients authenticate with a secret; public ones (mobile, SPA) can't keep one
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"` // Scopes the client may request
+	GrantTypes   []string  `json:"grant_types"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RegisterOAuthClientRequest registers a new client owned by the authenticated user.
+type RegisterOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris"` // Required for the authorization_code grant
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	GrantTypes   []string `json:"grant_types"` // Defaults to authorization_code and refresh_token
+	Confidential bool     `json:"confidential"`
+}
+
+// RegisterOAuthClientResponse is returned once at registration; the secret can't be retrieved again.
+type RegisterOAuthClientResponse struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthAuthorizeRequest holds the authorization request parameters (RFC 6749 section 4.1.1) the
+// client put on the link to our consent page, which forwards them to /oauth/authorize.
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// OAuthConsentRequest is the user's answer to an OAuthConsentPrompt.
+type OAuthConsentRequest struct {
+	OAuthAuthorizeRequest
+	Approve bool `json:"approve"`
+}
+
+// OAuthConsentPrompt asks the user to approve a client's access before a code is issued.
+type OAuthConsentPrompt struct {
+	ConsentRequired bool     `json:"consent_required"`
+	ClientID        string   `json:"client_id"`
+	ClientName      string   `json:"client_name"`
+	Scopes          []string `json:"scopes"`
+}
+
+// OAuthRedirect tells the consent page where to send the browser: the client's redirect URI with
+// either an authorization code or an error appended.
+type OAuthRedirect struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// OAuthConsent is a client the user has authorized, as listed under /oauth/consents.
+type OAuthConsent struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// OAuthTokenResponse is the successful /oauth/token response (RFC 6749 section 5.1).
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// IntrospectionResponse describes a token to the client it was issued to (RFC 7662 section 2.2).
+// Only Active is set for tokens that are invalid, expired, revoked or belong to another client.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+}
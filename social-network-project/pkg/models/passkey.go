@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Passkey is a WebAuthn credential registered by a user, as shown in their passkey list.
+type Passkey struct {
+	ID             uuid.UUID  `json:"id"`
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backup_eligible"` // Synced passkey (e.g. iCloud Keychain, Google Password Manager)
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnBeginResponse starts a registration or login ceremony. Options is passed to
+// navigator.credentials.create() or .get() as is; CeremonyID goes back with the result.
+type WebAuthnBeginResponse struct {
+	CeremonyID uuid.UUID   `json:"ceremony_id"`
+	Options    interface{} `json:"options"`
+}
+
+// WebAuthnRegisterRequest finishes a registration ceremony with the authenticator's attestation.
+type WebAuthnRegisterRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id" binding:"required"`
+	Name       string          `json:"name,omitempty" binding:"max=255"` // Label shown in the passkey list, e.g. "YubiKey"
+	Credential json.RawMessage `json:"credential" binding:"required"`    // PublicKeyCredential returned by the browser
+}
+
+// WebAuthnLoginRequest finishes a login ceremony with the authenticator's assertion.
+type WebAuthnLoginRequest struct {
+	CeremonyID uuid.UUID       `json:"ceremony_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"` // PublicKeyCredential returned by the browser
+	DeviceName string          `json:"device_name,omitempty"`         // Optional: shown in the session list
+}
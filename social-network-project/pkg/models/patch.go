@@ -0,0 +1,69 @@
+package models
+
+import "encoding/json"
+
+// MergePatchContentType is the media type of JSON Merge Patch documents (RFC 7396).
+const MergePatchContentType = "application/merge-patch+json"
+
+// Patch is a member of a JSON Merge Patch document, which tells apart a member that is absent
+// (leave the field alone), null (reset it to the zero value) and a value (set it). pkg/validation
+// applies the member's validate tags to the value only.
+type Patch[T any] struct {
+	Set   bool // The member was present
+	Null  bool // The member was null
+	Value T
+
+	invalid bool
+}
+
+// PatchString and PatchBool are the Patch types request models use.
+type (
+	PatchString = Patch[string]
+	PatchBool   = Patch[bool]
+)
+
+// PatchMember is implemented by every Patch type, so pkg/validation can handle them without
+// knowing the value type.
+type PatchMember interface {
+	// Invalid reports whether the member was neither null nor of the value type.
+	Invalid() bool
+	// ValidationValue returns the value to validate: the zero value unless the member set a value.
+	ValidationValue() interface{}
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is only called for members that are present.
+// A member of the wrong type is marked Invalid instead of failing, because encoding/json doesn't say
+// which member an Unmarshaler's error is about; pkg/validation reports it with the member's name.
+func (p *Patch[T]) UnmarshalJSON(data []byte) error {
+	*p = Patch[T]{Set: true}
+	if string(data) == "null" {
+		p.Null = true
+		return nil
+	}
+	if json.Unmarshal(data, &p.Value) != nil {
+		p.invalid = true
+	}
+	return nil
+}
+
+// Invalid implements PatchMember.
+func (p Patch[T]) Invalid() bool {
+	return p.invalid
+}
+
+// ValidationValue implements PatchMember.
+func (p Patch[T]) ValidationValue() interface{} {
+	if p.Set && !p.Null {
+		return p.Value
+	}
+	var zero T
+	return zero
+}
+
+// Update returns the new value for a field: nil if the member was absent, the zero value if it was null.
+func (p Patch[T]) Update() *T {
+	if !p.Set {
+		return nil
+	}
+	return &p.Value
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPatchString(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantSet     bool
+		wantNull    bool
+		wantInvalid bool
+		wantUpdate  *string // nil: leave the field alone
+	}{
+		{"absent", `{}`, false, false, false, nil},
+		{"null", `{"v": null}`, true, true, false, ptr("")},
+		{"value", `{"v": "hello"}`, true, false, false, ptr("hello")},
+		{"empty string", `{"v": ""}`, true, false, false, ptr("")},
+		{"wrong type", `{"v": 5}`, true, false, true, ptr("")},
+		{"object", `{"v": {"a": 1}}`, true, false, true, ptr("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc struct {
+				V PatchString `json:"v"`
+			}
+			if err := json.Unmarshal([]byte(tt.body), &doc); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			p := doc.V
+			if p.Set != tt.wantSet || p.Null != tt.wantNull || p.Invalid() != tt.wantInvalid {
+				t.Errorf("Set, Null, Invalid = %v, %v, %v; want %v, %v, %v", p.Set, p.Null, p.Invalid(), tt.wantSet, tt.wantNull, tt.wantInvalid)
+			}
+			update := p.Update()
+			if (update == nil) != (tt.wantUpdate == nil) || (update != nil && *update != *tt.wantUpdate) {
+				t.Errorf("Update() = %v, want %v", deref(update), deref(tt.wantUpdate))
+			}
+			// Validation sees the value only when one was set, so "omitempty" skips absent and null members.
+			want := ""
+			if tt.wantSet && !tt.wantNull {
+				want = p.Value
+			}
+			if got := p.ValidationValue(); got != want {
+				t.Errorf("ValidationValue() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestPatchBool(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantInvalid bool
+		wantUpdate  *bool
+	}{
+		{"absent", `{}`, false, nil},
+		{"null resets to false", `{"v": null}`, false, ptr(false)},
+		{"true", `{"v": true}`, false, ptr(true)},
+		{"false", `{"v": false}`, false, ptr(false)},
+		{"string", `{"v": "true"}`, true, ptr(false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc struct {
+				V PatchBool `json:"v"`
+			}
+			if err := json.Unmarshal([]byte(tt.body), &doc); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if doc.V.Invalid() != tt.wantInvalid {
+				t.Errorf("Invalid() = %v, want %v", doc.V.Invalid(), tt.wantInvalid)
+			}
+			update := doc.V.Update()
+			if (update == nil) != (tt.wantUpdate == nil) || (update != nil && *update != *tt.wantUpdate) {
+				t.Errorf("Update() = %v, want %v", deref(update), deref(tt.wantUpdate))
+			}
+		})
+	}
+}
+
+// TestPatchReuse checks that decoding into a used Patch forgets the previous member.
+func TestPatchReuse(t *testing.T) {
+	var p PatchString
+	if err := json.Unmarshal([]byte(`5`), &p); err != nil || !p.Invalid() {
+		t.Fatalf("Unmarshal(5): %v, Invalid() = %v", err, p.Invalid())
+	}
+	if err := json.Unmarshal([]byte(`null`), &p); err != nil {
+		t.Fatalf("Unmarshal(null): %v", err)
+	}
+	if !p.Set || !p.Null || p.Invalid() {
+		t.Errorf("after null: %+v, Invalid() = %v; want a valid null member", p, p.Invalid())
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func deref[T any](p *T) interface{} {
+	if p == nil {
+		return "<nil>"
+	}
+	return *p
+}
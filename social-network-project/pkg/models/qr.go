@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QRInvite is a one-time QR code that makes whoever accepts it a follower of UserID, without a
+// follow request even if the account is private. Only the token's hash is stored.
+type QRInvite struct {
+	TokenHash string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	UsedBy    *uuid.UUID
+}
+
+// CreateQRInviteRequest optionally sets how long a new QR invite stays valid.
+type CreateQRInviteRequest struct {
+	ExpiresIn int `json:"expires_in,omitempty" validate:"omitempty,min=60,max=604800"` // Seconds; defaults to a day
+}
+
+// QRInviteResponse is returned once, when the invite is created: the token can't be recovered later.
+type QRInviteResponse struct {
+	Token     string    `json:"token"`
+	Link      string    `json:"link"` // The deep link to show as a QR code, see GET /users/me/qr?invite=
+	ExpiresAt time.Time `json:"expires_at"`
+}
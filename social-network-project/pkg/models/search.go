@@ -0,0 +1,8 @@
+package models
+
+// UserSearchResult is a user found by GET /users/search.
+type UserSearchResult struct {
+	UserSummary
+	IsPrivate   bool `json:"is_private"`
+	IsFollowing bool `json:"is_following"` // Whether the caller follows them
+}
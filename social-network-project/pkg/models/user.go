@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,40 +10,84 @@ import (
 // User represents a user in the system.
 type User struct {
 	ID               uuid.UUID `json:"id"`
-	Username         string    `json:"username"`
+	Username         string    `json:"username"`        // Lower-cased, see NormalizeUsername
+	Email            string    `json:"email,omitempty"` // Only returned to the user themselves
+	EmailVerified    bool      `json:"email_verified"`
 	PasswordHash     string    `json:"-"` // Do not expose password hash in JSON responses
 	DisplayName      string    `json:"display_name,omitempty"`
 	Bio              string    `json:"bio,omitempty"`
-	QRCodeIdentifier string    `json:"qr_code_identifier,omitempty"` // Identifier for QR code generation
+	QRCodeIdentifier string    `json:"qr_code_identifier,omitempty"` // Encoded in the user's QR code; only returned to the user themselves
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 	IsActive         bool      `json:"is_active"`
+	IsPrivate        bool      `json:"is_private"` // Followers must be approved, see FollowRequest
+
+	PasswordResetRequired bool `json:"-"` // Set by an administrator; shown in the admin API only
+}
+
+// NormalizeUsername returns the form usernames are stored and looked up in. Usernames are
+// case-insensitive, so "Alice" and "alice" are the same account.
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
 }
 
 // RegistrationRequest represents the data needed for a new user registration.
 type RegistrationRequest struct {
-	Username    string `json:"username" validate:"required,min=3,max=50"`
-	Password    string `json:"password" validate:"required,min=8,max=100"`
-	DisplayName string `json:"display_name,omitempty"`
+	Username    string `json:"username" validate:"required,min=3,max=50,username"` // ASCII letters, digits and underscores
+	Password    string `json:"password" validate:"required"`                       // Length and strength rules are auth-service's password policy
+	Email       string `json:"email,omitempty" validate:"omitempty,email,max=255"` // Required when auth-service runs with REQUIRE_EMAIL=true
+	DisplayName string `json:"display_name,omitempty" validate:"omitempty,max=100"`
 }
 
 // LoginRequest represents the data needed for a user to log in.
 type LoginRequest struct {
-	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Username   string `json:"username" validate:"required"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name,omitempty"` // Optional: shown in the session list, e.g. "Pixel 8"
 }
 
 // LoginResponse represents the data returned after a successful login.
 type LoginResponse struct {
 	Token        string `json:"token"`
-	RefreshToken string `json:"refresh_token,omitempty"` // Optional: for refresh token mechanism
+	RefreshToken string `json:"refresh_token,omitempty"` // Opaque token used with /auth/refresh to obtain a new access token
+	ExpiresIn    int64  `json:"expires_in,omitempty"`    // Lifetime of Token in seconds
 	User         *User  `json:"user,omitempty"`          // Optional: return user details on login
 }
 
-// AuthTokenClaims represents the JWT claims.
-type AuthTokenClaims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	// Standard claims - add more as needed (e.g., roles, permissions)
-	// jwt.StandardClaims
+// RefreshTokenRequest carries the opaque refresh token for /auth/refresh and /auth/logout.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// VerifyEmailRequest optionally asks to change the email address; the verification email goes to
+// the new address, which replaces the one on file once confirmed. When Email is empty the
+// verification email goes to the address already on file. A change needs the password, and a
+// second factor when two-factor authentication is on, so a stolen session can't redirect
+// password resets to another mailbox.
+type VerifyEmailRequest struct {
+	Email    string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	Password string `json:"password,omitempty"`
+	MFACode
+}
+
+// ConfirmEmailRequest carries the token from the verification email.
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPasswordRequest starts a password reset for the account with this (verified) email address.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ResetPasswordRequest completes a password reset with the token from the reset email.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required" validate:"required"`
+}
+
+// ChangePasswordRequest changes the authenticated user's password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required" validate:"required"`
 }
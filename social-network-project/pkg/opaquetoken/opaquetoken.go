@@ -0,0 +1,27 @@
+// Package opaquetoken creates the random bearer tokens the services hand out, such as refresh
+// tokens, emailed links, OAuth codes and QR invites, and the hashes stored for them. Only the hash
+// is persisted, so a leaked table can't be replayed.
+package opaquetoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// New returns a new random token of 256 bits, URL-safe, and its Hash.
+func New() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, Hash(token), nil
+}
+
+// Hash returns the hex SHA-256 of the token, the form it is stored and looked up in.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
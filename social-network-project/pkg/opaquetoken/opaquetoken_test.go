@@ -0,0 +1,34 @@
+package opaquetoken
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	token, hash, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if raw, err := base64.RawURLEncoding.DecodeString(token); err != nil || len(raw) != 32 {
+		t.Errorf("token %q is not 32 bytes of URL-safe base64: %v", token, err)
+	}
+	if hash != Hash(token) {
+		t.Errorf("hash = %s, want Hash(token) = %s", hash, Hash(token))
+	}
+
+	other, _, err := New()
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if other == token {
+		t.Error("New returned the same token twice")
+	}
+}
+
+func TestHash(t *testing.T) {
+	// SHA-256 of "abc" (FIPS 180-2 appendix B.1).
+	if got, want := Hash("abc"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("Hash(abc) = %s, want %s", got, want)
+	}
+}
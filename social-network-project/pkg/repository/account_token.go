@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountToken is a single-use email verification or password reset token. Only its hash is stored.
+type AccountToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Email     string // The address being verified; empty for password resets
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// AccountTokenRepository stores the tokens mailed out to verify email addresses and reset passwords.
+type AccountTokenRepository interface {
+	// CreateEmailVerification stores a new email verification token.
+	CreateEmailVerification(ctx context.Context, token *AccountToken) error
+	// GetEmailVerification returns the email verification token, used or expired alike, or
+	// ErrTokenNotFound. In a transaction the Postgres implementation locks it until commit.
+	GetEmailVerification(ctx context.Context, tokenHash string) (*AccountToken, error)
+	// UseEmailVerifications marks every unused email verification token of the user used.
+	UseEmailVerifications(ctx context.Context, userID uuid.UUID) error
+
+	// CreatePasswordReset stores a new password reset token.
+	CreatePasswordReset(ctx context.Context, token *AccountToken) error
+	// GetPasswordReset returns the password reset token, used or expired alike, or
+	// ErrTokenNotFound. In a transaction the Postgres implementation locks it until commit.
+	GetPasswordReset(ctx context.Context, tokenHash string) (*AccountToken, error)
+	// UsePasswordResets marks every unused password reset token of the user used.
+	UsePasswordResets(ctx context.Context, userID uuid.UUID) error
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) AccountTokenRepository
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryAccountTokenRepository keeps email verification and password reset tokens in process
+// memory, for tests.
+type MemoryAccountTokenRepository struct {
+	mu                 sync.Mutex
+	emailVerifications map[string]AccountToken
+	passwordResets     map[string]AccountToken
+}
+
+// NewMemoryAccountTokenRepository creates an empty MemoryAccountTokenRepository.
+func NewMemoryAccountTokenRepository() *MemoryAccountTokenRepository {
+	return &MemoryAccountTokenRepository{
+		emailVerifications: make(map[string]AccountToken),
+		passwordResets:     make(map[string]AccountToken),
+	}
+}
+
+func (r *MemoryAccountTokenRepository) create(tokens map[string]AccountToken, token *AccountToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := tokens[token.TokenHash]; exists {
+		return ErrConflict
+	}
+	tokens[token.TokenHash] = *token
+	return nil
+}
+
+func (r *MemoryAccountTokenRepository) get(tokens map[string]AccountToken, tokenHash string) (*AccountToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := tokens[tokenHash]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &token, nil
+}
+
+// use marks the unused tokens matching used.
+func (r *MemoryAccountTokenRepository) use(tokens map[string]AccountToken, match func(t *AccountToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	for hash, token := range tokens {
+		if token.UsedAt == nil && match(&token) {
+			token.UsedAt = &now
+			tokens[hash] = token
+		}
+	}
+}
+
+// CreateEmailVerification implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) CreateEmailVerification(ctx context.Context, token *AccountToken) error {
+	return r.create(r.emailVerifications, token)
+}
+
+// GetEmailVerification implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) GetEmailVerification(ctx context.Context, tokenHash string) (*AccountToken, error) {
+	return r.get(r.emailVerifications, tokenHash)
+}
+
+// UseEmailVerifications implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) UseEmailVerifications(ctx context.Context, userID uuid.UUID) error {
+	r.use(r.emailVerifications, func(t *AccountToken) bool { return t.UserID == userID })
+	return nil
+}
+
+// CreatePasswordReset implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) CreatePasswordReset(ctx context.Context, token *AccountToken) error {
+	return r.create(r.passwordResets, token)
+}
+
+// GetPasswordReset implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) GetPasswordReset(ctx context.Context, tokenHash string) (*AccountToken, error) {
+	return r.get(r.passwordResets, tokenHash)
+}
+
+// UsePasswordResets implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) UsePasswordResets(ctx context.Context, userID uuid.UUID) error {
+	r.use(r.passwordResets, func(t *AccountToken) bool { return t.UserID == userID })
+	return nil
+}
+
+// WithTx implements AccountTokenRepository.
+func (r *MemoryAccountTokenRepository) WithTx(tx *sql.Tx) AccountTokenRepository {
+	return r
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// PostgresAccountTokenRepository keeps tokens in the email_verification_tokens and
+// password_reset_tokens tables.
+type PostgresAccountTokenRepository struct {
+	DB DB
+}
+
+// NewPostgresAccountTokenRepository creates a PostgresAccountTokenRepository.
+func NewPostgresAccountTokenRepository(db DB) *PostgresAccountTokenRepository {
+	return &PostgresAccountTokenRepository{DB: db}
+}
+
+func scanAccountToken(row rowScanner) (*AccountToken, error) {
+	var (
+		token  AccountToken
+		usedAt sql.NullTime
+	)
+	err := row.Scan(&token.TokenHash, &token.UserID, &token.Email, &token.CreatedAt, &token.ExpiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return &token, nil
+}
+
+// CreateEmailVerification implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) CreateEmailVerification(ctx context.Context, token *AccountToken) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO email_verification_tokens (token_hash, user_id, email, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		token.TokenHash, token.UserID, token.Email, token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+// GetEmailVerification implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) GetEmailVerification(ctx context.Context, tokenHash string) (*AccountToken, error) {
+	return scanAccountToken(r.DB.QueryRowContext(ctx, `SELECT token_hash, user_id, email, created_at, expires_at, used_at
+		FROM email_verification_tokens WHERE token_hash = $1 FOR UPDATE`, tokenHash))
+}
+
+// UseEmailVerifications implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) UseEmailVerifications(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE email_verification_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID)
+	return err
+}
+
+// CreatePasswordReset implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) CreatePasswordReset(ctx context.Context, token *AccountToken) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		token.TokenHash, token.UserID, token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+// GetPasswordReset implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) GetPasswordReset(ctx context.Context, tokenHash string) (*AccountToken, error) {
+	return scanAccountToken(r.DB.QueryRowContext(ctx, `SELECT token_hash, user_id, '', created_at, expires_at, used_at
+		FROM password_reset_tokens WHERE token_hash = $1 FOR UPDATE`, tokenHash))
+}
+
+// UsePasswordResets implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) UsePasswordResets(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID)
+	return err
+}
+
+// WithTx implements AccountTokenRepository.
+func (r *PostgresAccountTokenRepository) WithTx(tx *sql.Tx) AccountTokenRepository {
+	return &PostgresAccountTokenRepository{DB: tx}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// AuditFilter selects audit log entries for AuditRepository.List.
+type AuditFilter struct {
+	UserID *uuid.UUID // Only entries acting on or performed by this user
+	Limit  int
+	Offset int
+}
+
+// AuditRepository stores the log of admin actions.
+type AuditRepository interface {
+	// Record appends an entry. The caller sets its ID and creation time.
+	Record(ctx context.Context, entry *models.AuditLogEntry) error
+	// List returns the entries matching filter, newest first.
+	List(ctx context.Context, filter AuditFilter) ([]models.AuditLogEntry, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) AuditRepository
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// MemoryAuditRepository keeps the audit log in process memory, for tests.
+type MemoryAuditRepository struct {
+	mu      sync.Mutex
+	entries []models.AuditLogEntry // Oldest first
+}
+
+// NewMemoryAuditRepository creates an empty MemoryAuditRepository.
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{}
+}
+
+// Record implements AuditRepository.
+func (r *MemoryAuditRepository) Record(ctx context.Context, entry *models.AuditLogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+// List implements AuditRepository.
+func (r *MemoryAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditLogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := []models.AuditLogEntry{}
+	skipped := 0
+	for i := len(r.entries) - 1; i >= 0 && len(entries) < filter.Limit; i-- {
+		e := r.entries[i]
+		if filter.UserID != nil && e.ActorID != *filter.UserID && (e.TargetUserID == nil || *e.TargetUserID != *filter.UserID) {
+			continue
+		}
+		if skipped < filter.Offset {
+			skipped++
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// WithTx implements AuditRepository.
+func (r *MemoryAuditRepository) WithTx(tx *sql.Tx) AuditRepository {
+	return r
+}
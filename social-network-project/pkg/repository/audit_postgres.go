@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresAuditRepository keeps the audit log in the admin_audit_log table.
+type PostgresAuditRepository struct {
+	DB DB
+}
+
+// NewPostgresAuditRepository creates a PostgresAuditRepository.
+func NewPostgresAuditRepository(db DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{DB: db}
+}
+
+// Record implements AuditRepository.
+func (r *PostgresAuditRepository) Record(ctx context.Context, entry *models.AuditLogEntry) error {
+	var details []byte
+	if len(entry.Details) > 0 {
+		details = entry.Details
+	}
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO admin_audit_log (id, actor_id, action, target_user_id, details, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		entry.ID, entry.ActorID, entry.Action, entry.TargetUserID, details, entry.CreatedAt)
+	return err
+}
+
+// List implements AuditRepository.
+func (r *PostgresAuditRepository) List(ctx context.Context, filter AuditFilter) ([]models.AuditLogEntry, error) {
+	query := "SELECT id, actor_id, action, target_user_id, details, created_at FROM admin_audit_log"
+	args := []interface{}{}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += " WHERE target_user_id = $1 OR actor_id = $1"
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditLogEntry{}
+	for rows.Next() {
+		var (
+			e       models.AuditLogEntry
+			target  uuid.NullUUID
+			details []byte
+		)
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &target, &details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if target.Valid {
+			e.TargetUserID = &target.UUID
+		}
+		e.Details = details
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// WithTx implements AuditRepository.
+func (r *PostgresAuditRepository) WithTx(tx *sql.Tx) AuditRepository {
+	return &PostgresAuditRepository{DB: tx}
+}
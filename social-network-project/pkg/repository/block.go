@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// BlockRepository reads and writes blocks and mutes. A block hides two users from each other
+// both ways; a mute only hides the muted user's content from the muter. Lists leave out inactive
+// users.
+type BlockRepository interface {
+	// Block records that blockerID blocked blockedID and reports whether it is new. It removes
+	// the follows and pending follow requests between the two, in both directions, in the same
+	// transaction.
+	Block(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+	// Unblock removes the block and reports whether there was one. Follows removed by the block
+	// are not restored.
+	Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+	// IsBlocked reports whether either user blocked the other.
+	IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error)
+	// BlockedAmong returns the users among ids that userID blocked or that blocked userID.
+	BlockedAmong(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error)
+	// Blocks lists the users blockerID blocked, most recent first.
+	Blocks(ctx context.Context, blockerID uuid.UUID, limit, offset int) ([]models.BlockEntry, error)
+
+	// Mute records that muterID muted mutedID and reports whether it is new.
+	Mute(ctx context.Context, muterID, mutedID uuid.UUID) (bool, error)
+	// Unmute removes the mute and reports whether there was one.
+	Unmute(ctx context.Context, muterID, mutedID uuid.UUID) (bool, error)
+	// MutedAmong returns the users among ids that muterID muted.
+	MutedAmong(ctx context.Context, muterID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error)
+	// Mutes lists the users muterID muted, most recent first.
+	Mutes(ctx context.Context, muterID uuid.UUID, limit, offset int) ([]models.MuteEntry, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) BlockRepository
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// pair is a key of MemoryBlockRepository's maps: from blocked or muted to.
+type pair struct {
+	from, to uuid.UUID
+}
+
+// MemoryBlockRepository keeps blocks and mutes in process memory, for tests. Blocking removes
+// follows from a MemoryFollowRepository.
+type MemoryBlockRepository struct {
+	users   *MemoryUserRepository
+	follows *MemoryFollowRepository
+	mu      sync.RWMutex
+	blocks  map[pair]time.Time
+	mutes   map[pair]time.Time
+}
+
+// NewMemoryBlockRepository creates an empty MemoryBlockRepository over users and follows.
+func NewMemoryBlockRepository(users *MemoryUserRepository, follows *MemoryFollowRepository) *MemoryBlockRepository {
+	return &MemoryBlockRepository{users: users, follows: follows, blocks: make(map[pair]time.Time), mutes: make(map[pair]time.Time)}
+}
+
+// Block implements BlockRepository.
+func (r *MemoryBlockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	r.follows.mu.Lock()
+	for _, f := range []follow{{blockerID, blockedID}, {blockedID, blockerID}} {
+		delete(r.follows.follows, f)
+		delete(r.follows.requests, f)
+	}
+	r.follows.mu.Unlock()
+	return r.add(r.blocks, pair{blockerID, blockedID}), nil
+}
+
+// Unblock implements BlockRepository.
+func (r *MemoryBlockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	return r.remove(r.blocks, pair{blockerID, blockedID}), nil
+}
+
+// IsBlocked implements BlockRepository.
+func (r *MemoryBlockRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	blocked, _ := r.BlockedAmong(ctx, userID, []uuid.UUID{otherID})
+	return len(blocked) > 0, nil
+}
+
+// BlockedAmong implements BlockRepository.
+func (r *MemoryBlockRepository) BlockedAmong(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return r.among(ids, func(id uuid.UUID) bool {
+		_, blocked := r.blocks[pair{userID, id}]
+		_, blockedBy := r.blocks[pair{id, userID}]
+		return blocked || blockedBy
+	}), nil
+}
+
+// Blocks implements BlockRepository. A zero limit means no limit.
+func (r *MemoryBlockRepository) Blocks(ctx context.Context, blockerID uuid.UUID, limit, offset int) ([]models.BlockEntry, error) {
+	entries := []models.BlockEntry{}
+	err := r.list(ctx, r.blocks, blockerID, limit, offset, func(summary models.UserSummary, at time.Time) {
+		entries = append(entries, models.BlockEntry{UserSummary: summary, BlockedAt: at})
+	})
+	return entries, err
+}
+
+// Mute implements BlockRepository.
+func (r *MemoryBlockRepository) Mute(ctx context.Context, muterID, mutedID uuid.UUID) (bool, error) {
+	return r.add(r.mutes, pair{muterID, mutedID}), nil
+}
+
+// Unmute implements BlockRepository.
+func (r *MemoryBlockRepository) Unmute(ctx context.Context, muterID, mutedID uuid.UUID) (bool, error) {
+	return r.remove(r.mutes, pair{muterID, mutedID}), nil
+}
+
+// MutedAmong implements BlockRepository.
+func (r *MemoryBlockRepository) MutedAmong(ctx context.Context, muterID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return r.among(ids, func(id uuid.UUID) bool {
+		_, muted := r.mutes[pair{muterID, id}]
+		return muted
+	}), nil
+}
+
+// Mutes implements BlockRepository. A zero limit means no limit.
+func (r *MemoryBlockRepository) Mutes(ctx context.Context, muterID uuid.UUID, limit, offset int) ([]models.MuteEntry, error) {
+	entries := []models.MuteEntry{}
+	err := r.list(ctx, r.mutes, muterID, limit, offset, func(summary models.UserSummary, at time.Time) {
+		entries = append(entries, models.MuteEntry{UserSummary: summary, MutedAt: at})
+	})
+	return entries, err
+}
+
+func (r *MemoryBlockRepository) add(m map[pair]time.Time, key pair) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := m[key]; exists {
+		return false
+	}
+	m[key] = time.Now().UTC()
+	return true
+}
+
+func (r *MemoryBlockRepository) remove(m map[pair]time.Time, key pair) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := m[key]
+	delete(m, key)
+	return exists
+}
+
+func (r *MemoryBlockRepository) among(ids []uuid.UUID, match func(id uuid.UUID) bool) []uuid.UUID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var found []uuid.UUID
+	for _, id := range ids {
+		if match(id) {
+			found = append(found, id)
+		}
+	}
+	return found
+}
+
+// list calls add for each active user that from blocked or muted in m, most recent first.
+func (r *MemoryBlockRepository) list(ctx context.Context, m map[pair]time.Time, from uuid.UUID, limit, offset int,
+	add func(summary models.UserSummary, at time.Time)) error {
+	r.mu.RLock()
+	var keys []pair
+	for p := range m {
+		if p.from == from {
+			keys = append(keys, p)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return m[keys[i]].After(m[keys[j]]) })
+	times := make([]time.Time, len(keys))
+	for i, p := range keys {
+		times[i] = m[p]
+	}
+	r.mu.RUnlock()
+
+	skipped, added := 0, 0
+	for i, p := range keys {
+		if limit > 0 && added == limit {
+			break
+		}
+		u, err := r.users.GetByID(ctx, p.to)
+		if err == ErrNotFound || (err == nil && !u.IsActive) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		add(models.UserSummary{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName}, times[i])
+		added++
+	}
+	return nil
+}
+
+// WithTx implements BlockRepository.
+func (r *MemoryBlockRepository) WithTx(tx *sql.Tx) BlockRepository {
+	return r
+}
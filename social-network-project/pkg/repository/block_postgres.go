@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresBlockRepository keeps blocks and mutes in the user_blocks and user_mutes tables.
+type PostgresBlockRepository struct {
+	DB DB
+}
+
+// NewPostgresBlockRepository creates a PostgresBlockRepository.
+func NewPostgresBlockRepository(db DB) *PostgresBlockRepository {
+	return &PostgresBlockRepository{DB: db}
+}
+
+// Block implements BlockRepository. A single statement is a single transaction, so no follow
+// can be left behind even if the caller isn't in one.
+func (r *PostgresBlockRepository) Block(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	var n int
+	err := r.DB.QueryRowContext(ctx, `WITH blocked AS (
+			INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING 1
+		), unfollowed AS (
+			DELETE FROM follows WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)
+		), unrequested AS (
+			DELETE FROM follow_requests WHERE (requester_id = $1 AND target_id = $2) OR (requester_id = $2 AND target_id = $1)
+		)
+		SELECT count(*) FROM blocked`, blockerID, blockedID).Scan(&n)
+	return n > 0, err
+}
+
+// Unblock implements BlockRepository.
+func (r *PostgresBlockRepository) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2", blockerID, blockedID)
+}
+
+// IsBlocked implements BlockRepository.
+func (r *PostgresBlockRepository) IsBlocked(ctx context.Context, userID, otherID uuid.UUID) (bool, error) {
+	var blocked bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1))`,
+		userID, otherID).Scan(&blocked)
+	return blocked, err
+}
+
+// BlockedAmong implements BlockRepository.
+func (r *PostgresBlockRepository) BlockedAmong(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return r.among(ctx, `SELECT blocked_id FROM user_blocks WHERE blocker_id = $1 AND blocked_id = ANY($2::uuid[])
+		UNION SELECT blocker_id FROM user_blocks WHERE blocked_id = $1 AND blocker_id = ANY($2::uuid[])`, userID, ids)
+}
+
+// Blocks implements BlockRepository.
+func (r *PostgresBlockRepository) Blocks(ctx context.Context, blockerID uuid.UUID, limit, offset int) ([]models.BlockEntry, error) {
+	entries := []models.BlockEntry{}
+	err := r.list(ctx, "user_blocks", "blocked_id", "blocker_id", blockerID, limit, offset, func(summary models.UserSummary, at time.Time) {
+		entries = append(entries, models.BlockEntry{UserSummary: summary, BlockedAt: at})
+	})
+	return entries, err
+}
+
+// Mute implements BlockRepository.
+func (r *PostgresBlockRepository) Mute(ctx context.Context, muterID, mutedID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "INSERT INTO user_mutes (muter_id, muted_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", muterID, mutedID)
+}
+
+// Unmute implements BlockRepository.
+func (r *PostgresBlockRepository) Unmute(ctx context.Context, muterID, mutedID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2", muterID, mutedID)
+}
+
+// MutedAmong implements BlockRepository.
+func (r *PostgresBlockRepository) MutedAmong(ctx context.Context, muterID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return r.among(ctx, "SELECT muted_id FROM user_mutes WHERE muter_id = $1 AND muted_id = ANY($2::uuid[])", muterID, ids)
+}
+
+// Mutes implements BlockRepository.
+func (r *PostgresBlockRepository) Mutes(ctx context.Context, muterID uuid.UUID, limit, offset int) ([]models.MuteEntry, error) {
+	entries := []models.MuteEntry{}
+	err := r.list(ctx, "user_mutes", "muted_id", "muter_id", muterID, limit, offset, func(summary models.UserSummary, at time.Time) {
+		entries = append(entries, models.MuteEntry{UserSummary: summary, MutedAt: at})
+	})
+	return entries, err
+}
+
+func (r *PostgresBlockRepository) changed(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// among runs query, which selects user IDs given a user ID and an array of candidate IDs.
+func (r *PostgresBlockRepository) among(ctx context.Context, query string, userID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	candidates := make([]string, len(ids))
+	for i, id := range ids {
+		candidates[i] = id.String()
+	}
+	rows, err := r.DB.QueryContext(ctx, query, userID, pq.Array(candidates))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found = append(found, id)
+	}
+	return found, rows.Err()
+}
+
+// list calls add for each active user in the listed column of the rows of table whose by column
+// is userID, most recent first.
+func (r *PostgresBlockRepository) list(ctx context.Context, table, listed, by string, userID uuid.UUID, limit, offset int,
+	add func(summary models.UserSummary, at time.Time)) error {
+	rows, err := r.DB.QueryContext(ctx, `SELECT u.id, u.username, COALESCE(u.display_name, ''), t.created_at
+		FROM `+table+` t JOIN users u ON u.id = t.`+listed+`
+		WHERE t.`+by+` = $1 AND u.is_active
+		ORDER BY t.created_at DESC, u.id LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			summary models.UserSummary
+			at      time.Time
+		)
+		if err := rows.Scan(&summary.ID, &summary.Username, &summary.DisplayName, &at); err != nil {
+			return err
+		}
+		add(summary, at)
+	}
+	return rows.Err()
+}
+
+// WithTx implements BlockRepository.
+func (r *PostgresBlockRepository) WithTx(tx *sql.Tx) BlockRepository {
+	return &PostgresBlockRepository{DB: tx}
+}
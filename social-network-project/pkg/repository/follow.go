@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// FollowRepository reads and writes the social graph and the pending requests to follow private
+// accounts. Lists and counts leave out inactive users.
+type FollowRepository interface {
+	// Follow records that followerID follows followeeID and reports whether it is new;
+	// following someone again is not an error.
+	Follow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	// Unfollow removes the follow and reports whether there was one.
+	Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	// IsFollowing reports whether followerID follows followeeID.
+	IsFollowing(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error)
+	// Counts returns how many users follow userID and how many userID follows.
+	Counts(ctx context.Context, userID uuid.UUID) (followers, following int, err error)
+	// Followers lists the users following userID, most recent first.
+	Followers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error)
+	// Following lists the users userID follows, most recent first.
+	Following(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error)
+
+	// RequestFollow records that requesterID asked to follow the private account targetID and
+	// reports whether the request is new.
+	RequestFollow(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error)
+	// DeleteFollowRequest removes a pending request, when it is cancelled or rejected, and reports
+	// whether there was one.
+	DeleteFollowRequest(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error)
+	// HasRequestedFollow reports whether requesterID has a pending request to follow targetID.
+	HasRequestedFollow(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error)
+	// FollowRequests lists the users with a pending request to follow targetID, oldest first.
+	FollowRequests(ctx context.Context, targetID uuid.UUID, limit, offset int) ([]models.FollowRequest, error)
+	// ApproveFollowRequest turns a pending request into a follow and reports whether there was one.
+	ApproveFollowRequest(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error)
+	// ApproveFollowRequests approves every pending request to follow targetID, for when the
+	// account stops being private, and returns how many there were.
+	ApproveFollowRequests(ctx context.Context, targetID uuid.UUID) (int, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) FollowRepository
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// follow is a key of MemoryFollowRepository's follows and requests maps; for requests, follower
+// is the requester and followee the target.
+type follow struct {
+	follower, followee uuid.UUID
+}
+
+// MemoryFollowRepository keeps the social graph in process memory, for tests. It reads users,
+// for lists and to skip inactive ones, from a MemoryUserRepository.
+type MemoryFollowRepository struct {
+	users    *MemoryUserRepository
+	mu       sync.RWMutex
+	follows  map[follow]time.Time
+	requests map[follow]time.Time
+}
+
+// NewMemoryFollowRepository creates an empty MemoryFollowRepository over users.
+func NewMemoryFollowRepository(users *MemoryUserRepository) *MemoryFollowRepository {
+	return &MemoryFollowRepository{users: users, follows: make(map[follow]time.Time), requests: make(map[follow]time.Time)}
+}
+
+// Follow implements FollowRepository.
+func (r *MemoryFollowRepository) Follow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	return r.add(r.follows, follow{followerID, followeeID}), nil
+}
+
+// Unfollow implements FollowRepository.
+func (r *MemoryFollowRepository) Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	return r.remove(r.follows, follow{followerID, followeeID}), nil
+}
+
+// IsFollowing implements FollowRepository.
+func (r *MemoryFollowRepository) IsFollowing(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	return r.contains(r.follows, follow{followerID, followeeID}), nil
+}
+
+func (r *MemoryFollowRepository) add(m map[follow]time.Time, key follow) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := m[key]; exists {
+		return false
+	}
+	m[key] = time.Now().UTC()
+	return true
+}
+
+func (r *MemoryFollowRepository) remove(m map[follow]time.Time, key follow) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := m[key]
+	delete(m, key)
+	return exists
+}
+
+func (r *MemoryFollowRepository) contains(m map[follow]time.Time, key follow) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := m[key]
+	return exists
+}
+
+// Counts implements FollowRepository.
+func (r *MemoryFollowRepository) Counts(ctx context.Context, userID uuid.UUID) (followers, following int, err error) {
+	followerList, err := r.Followers(ctx, userID, 0, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	followingList, err := r.Following(ctx, userID, 0, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(followerList), len(followingList), nil
+}
+
+// Followers implements FollowRepository. A zero limit means no limit.
+func (r *MemoryFollowRepository) Followers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error) {
+	return r.list(ctx, r.follows, func(f follow) (uuid.UUID, bool) { return f.follower, f.followee == userID }, false, limit, offset)
+}
+
+// Following implements FollowRepository. A zero limit means no limit.
+func (r *MemoryFollowRepository) Following(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error) {
+	return r.list(ctx, r.follows, func(f follow) (uuid.UUID, bool) { return f.followee, f.follower == userID }, false, limit, offset)
+}
+
+// RequestFollow implements FollowRepository.
+func (r *MemoryFollowRepository) RequestFollow(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	return r.add(r.requests, follow{requesterID, targetID}), nil
+}
+
+// DeleteFollowRequest implements FollowRepository.
+func (r *MemoryFollowRepository) DeleteFollowRequest(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	return r.remove(r.requests, follow{requesterID, targetID}), nil
+}
+
+// HasRequestedFollow implements FollowRepository.
+func (r *MemoryFollowRepository) HasRequestedFollow(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	return r.contains(r.requests, follow{requesterID, targetID}), nil
+}
+
+// FollowRequests implements FollowRepository. A zero limit means no limit.
+func (r *MemoryFollowRepository) FollowRequests(ctx context.Context, targetID uuid.UUID, limit, offset int) ([]models.FollowRequest, error) {
+	entries, err := r.list(ctx, r.requests, func(f follow) (uuid.UUID, bool) { return f.follower, f.followee == targetID }, true, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]models.FollowRequest, 0, len(entries))
+	for _, e := range entries {
+		requests = append(requests, models.FollowRequest{UserSummary: e.UserSummary, RequestedAt: e.FollowedAt})
+	}
+	return requests, nil
+}
+
+// ApproveFollowRequest implements FollowRepository.
+func (r *MemoryFollowRepository) ApproveFollowRequest(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	n := r.approve(func(f follow) bool { return f == follow{requesterID, targetID} })
+	return n > 0, nil
+}
+
+// ApproveFollowRequests implements FollowRepository.
+func (r *MemoryFollowRepository) ApproveFollowRequests(ctx context.Context, targetID uuid.UUID) (int, error) {
+	return r.approve(func(f follow) bool { return f.followee == targetID }), nil
+}
+
+// approve moves the requests match picks into follows and returns how many it moved.
+func (r *MemoryFollowRepository) approve(match func(f follow) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for f := range r.requests {
+		if !match(f) {
+			continue
+		}
+		delete(r.requests, f)
+		if _, exists := r.follows[f]; !exists {
+			r.follows[f] = time.Now().UTC()
+		}
+		n++
+	}
+	return n
+}
+
+// list returns the active users that listed picks from the entries of m it matches, most recent
+// first or, if oldestFirst, oldest first.
+func (r *MemoryFollowRepository) list(ctx context.Context, m map[follow]time.Time, listed func(f follow) (uuid.UUID, bool), oldestFirst bool, limit, offset int) ([]models.FollowEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []models.FollowEntry{}
+	for f, createdAt := range m {
+		id, ok := listed(f)
+		if !ok {
+			continue
+		}
+		u, err := r.users.GetByID(ctx, id)
+		if err == ErrNotFound || (err == nil && !u.IsActive) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.FollowEntry{
+			UserSummary: models.UserSummary{ID: u.ID, Username: u.Username, DisplayName: u.DisplayName},
+			FollowedAt:  createdAt,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if oldestFirst {
+			return entries[i].FollowedAt.Before(entries[j].FollowedAt)
+		}
+		return entries[i].FollowedAt.After(entries[j].FollowedAt)
+	})
+
+	if offset >= len(entries) {
+		return []models.FollowEntry{}, nil
+	}
+	entries = entries[offset:]
+	if limit > 0 && limit < len(entries) {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// WithTx implements FollowRepository.
+func (r *MemoryFollowRepository) WithTx(tx *sql.Tx) FollowRepository {
+	return r
+}
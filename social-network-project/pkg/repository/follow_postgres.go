@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresFollowRepository keeps the social graph in the follows table.
+type PostgresFollowRepository struct {
+	DB DB
+}
+
+// NewPostgresFollowRepository creates a PostgresFollowRepository.
+func NewPostgresFollowRepository(db DB) *PostgresFollowRepository {
+	return &PostgresFollowRepository{DB: db}
+}
+
+// Follow implements FollowRepository.
+func (r *PostgresFollowRepository) Follow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", followerID, followeeID)
+}
+
+// Unfollow implements FollowRepository.
+func (r *PostgresFollowRepository) Unfollow(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+}
+
+func (r *PostgresFollowRepository) changed(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// IsFollowing implements FollowRepository.
+func (r *PostgresFollowRepository) IsFollowing(ctx context.Context, followerID, followeeID uuid.UUID) (bool, error) {
+	var following bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)",
+		followerID, followeeID).Scan(&following)
+	return following, err
+}
+
+// Counts implements FollowRepository.
+func (r *PostgresFollowRepository) Counts(ctx context.Context, userID uuid.UUID) (followers, following int, err error) {
+	err = r.DB.QueryRowContext(ctx, `SELECT
+		(SELECT count(*) FROM follows f JOIN users u ON u.id = f.follower_id WHERE f.followee_id = $1 AND u.is_active),
+		(SELECT count(*) FROM follows f JOIN users u ON u.id = f.followee_id WHERE f.follower_id = $1 AND u.is_active)`,
+		userID).Scan(&followers, &following)
+	return followers, following, err
+}
+
+// Followers implements FollowRepository.
+func (r *PostgresFollowRepository) Followers(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error) {
+	return r.list(ctx, "follows", "f.follower_id", "f.followee_id", "DESC", userID, limit, offset)
+}
+
+// Following implements FollowRepository.
+func (r *PostgresFollowRepository) Following(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error) {
+	return r.list(ctx, "follows", "f.followee_id", "f.follower_id", "DESC", userID, limit, offset)
+}
+
+// RequestFollow implements FollowRepository.
+func (r *PostgresFollowRepository) RequestFollow(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "INSERT INTO follow_requests (requester_id, target_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", requesterID, targetID)
+}
+
+// DeleteFollowRequest implements FollowRepository.
+func (r *PostgresFollowRepository) DeleteFollowRequest(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	return r.changed(ctx, "DELETE FROM follow_requests WHERE requester_id = $1 AND target_id = $2", requesterID, targetID)
+}
+
+// HasRequestedFollow implements FollowRepository.
+func (r *PostgresFollowRepository) HasRequestedFollow(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	var requested bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM follow_requests WHERE requester_id = $1 AND target_id = $2)",
+		requesterID, targetID).Scan(&requested)
+	return requested, err
+}
+
+// FollowRequests implements FollowRepository.
+func (r *PostgresFollowRepository) FollowRequests(ctx context.Context, targetID uuid.UUID, limit, offset int) ([]models.FollowRequest, error) {
+	entries, err := r.list(ctx, "follow_requests", "f.requester_id", "f.target_id", "ASC", targetID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]models.FollowRequest, 0, len(entries))
+	for _, e := range entries {
+		requests = append(requests, models.FollowRequest{UserSummary: e.UserSummary, RequestedAt: e.FollowedAt})
+	}
+	return requests, nil
+}
+
+// ApproveFollowRequest implements FollowRepository.
+func (r *PostgresFollowRepository) ApproveFollowRequest(ctx context.Context, requesterID, targetID uuid.UUID) (bool, error) {
+	n, err := r.approve(ctx, "requester_id = $1 AND target_id = $2", requesterID, targetID)
+	return n > 0, err
+}
+
+// ApproveFollowRequests implements FollowRepository.
+func (r *PostgresFollowRepository) ApproveFollowRequests(ctx context.Context, targetID uuid.UUID) (int, error) {
+	return r.approve(ctx, "target_id = $1", targetID)
+}
+
+// approve moves the follow requests matching where into follows in one statement, so a request
+// is never both lost and not followed.
+func (r *PostgresFollowRepository) approve(ctx context.Context, where string, args ...interface{}) (int, error) {
+	var n int
+	err := r.DB.QueryRowContext(ctx, `WITH approved AS (
+			DELETE FROM follow_requests WHERE `+where+` RETURNING requester_id, target_id
+		), inserted AS (
+			INSERT INTO follows (follower_id, followee_id) SELECT requester_id, target_id FROM approved ON CONFLICT DO NOTHING
+		)
+		SELECT count(*) FROM approved`, args...).Scan(&n)
+	return n, err
+}
+
+// list returns the active users in the listed column of the rows of table whose by column is
+// userID, ordered by created_at in order.
+func (r *PostgresFollowRepository) list(ctx context.Context, table, listed, by, order string, userID uuid.UUID, limit, offset int) ([]models.FollowEntry, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT u.id, u.username, COALESCE(u.display_name, ''), f.created_at
+		FROM `+table+` f JOIN users u ON u.id = `+listed+`
+		WHERE `+by+` = $1 AND u.is_active
+		ORDER BY f.created_at `+order+`, u.id LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.FollowEntry{}
+	for rows.Next() {
+		var e models.FollowEntry
+		if err := rows.Scan(&e.ID, &e.Username, &e.DisplayName, &e.FollowedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// WithTx implements FollowRepository.
+func (r *PostgresFollowRepository) WithTx(tx *sql.Tx) FollowRepository {
+	return &PostgresFollowRepository{DB: tx}
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrIdentityNotFound is returned when no linked identity matches.
+var ErrIdentityNotFound = errors.New("repository: identity not found")
+
+// ErrIdentityExists is returned by Create when the provider account is linked already, or the user
+// already has an account from the provider linked.
+var ErrIdentityExists = errors.New("repository: identity already linked")
+
+// ErrOIDCStateNotFound is returned by TakeState when no unexpired state of the provider has the hash.
+var ErrOIDCStateNotFound = errors.New("repository: oidc state not found")
+
+// LinkedIdentity is an account at an external OpenID Connect provider linked to a user.
+type LinkedIdentity struct {
+	models.Identity
+	UserID  uuid.UUID
+	Subject string // The provider's ID for the account
+}
+
+// OIDCState is an authorization request sent to an external provider, kept until the user comes
+// back. UserID is set when linking a provider to an existing account.
+type OIDCState struct {
+	StateHash    string
+	Provider     string
+	UserID       *uuid.UUID
+	CodeVerifier string
+	Nonce        string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// IdentityRepository stores the external provider accounts users sign in with and the state of
+// sign-ins in progress.
+type IdentityRepository interface {
+	// Get returns the identity of the provider's account, or ErrIdentityNotFound.
+	Get(ctx context.Context, provider, subject string) (*LinkedIdentity, error)
+	// List returns the identities linked to the user, oldest first.
+	List(ctx context.Context, userID uuid.UUID) ([]models.Identity, error)
+	// Create links an identity, or returns ErrIdentityExists.
+	Create(ctx context.Context, identity *LinkedIdentity) error
+	// RecordLogin sets the identity's last login time to now.
+	RecordLogin(ctx context.Context, provider, subject string) error
+	// Delete unlinks the user's identity at the provider, or returns ErrIdentityNotFound.
+	Delete(ctx context.Context, userID uuid.UUID, provider string) error
+
+	// CreateState stores a new authorization request.
+	CreateState(ctx context.Context, state *OIDCState) error
+	// TakeState deletes and returns an unexpired authorization request, so it can only be
+	// finished once, or returns ErrOIDCStateNotFound.
+	TakeState(ctx context.Context, stateHash, provider string) (*OIDCState, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) IdentityRepository
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// MemoryIdentityRepository keeps identities and authorization requests in process memory, for tests.
+type MemoryIdentityRepository struct {
+	mu         sync.Mutex
+	identities []LinkedIdentity
+	states     map[string]OIDCState
+}
+
+// NewMemoryIdentityRepository creates an empty MemoryIdentityRepository.
+func NewMemoryIdentityRepository() *MemoryIdentityRepository {
+	return &MemoryIdentityRepository{states: make(map[string]OIDCState)}
+}
+
+// Get implements IdentityRepository.
+func (r *MemoryIdentityRepository) Get(ctx context.Context, provider, subject string) (*LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, ErrIdentityNotFound
+}
+
+// List implements IdentityRepository.
+func (r *MemoryIdentityRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Identity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identities := []models.Identity{}
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity.Identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].CreatedAt.Before(identities[j].CreatedAt) })
+	return identities, nil
+}
+
+// Create implements IdentityRepository.
+func (r *MemoryIdentityRepository) Create(ctx context.Context, identity *LinkedIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && (existing.Subject == identity.Subject || existing.UserID == identity.UserID) {
+			return ErrIdentityExists
+		}
+	}
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+// RecordLogin implements IdentityRepository.
+func (r *MemoryIdentityRepository) RecordLogin(ctx context.Context, provider, subject string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	for i, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			r.identities[i].LastLoginAt = &now
+		}
+	}
+	return nil
+}
+
+// Delete implements IdentityRepository.
+func (r *MemoryIdentityRepository) Delete(ctx context.Context, userID uuid.UUID, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, identity := range r.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return nil
+		}
+	}
+	return ErrIdentityNotFound
+}
+
+// CreateState implements IdentityRepository.
+func (r *MemoryIdentityRepository) CreateState(ctx context.Context, state *OIDCState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.states[state.StateHash]; exists {
+		return ErrConflict
+	}
+	r.states[state.StateHash] = *state
+	return nil
+}
+
+// TakeState implements IdentityRepository.
+func (r *MemoryIdentityRepository) TakeState(ctx context.Context, stateHash, provider string) (*OIDCState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, ok := r.states[stateHash]
+	if !ok || state.Provider != provider || !time.Now().Before(state.ExpiresAt) {
+		return nil, ErrOIDCStateNotFound
+	}
+	delete(r.states, stateHash)
+	return &state, nil
+}
+
+// WithTx implements IdentityRepository.
+func (r *MemoryIdentityRepository) WithTx(tx *sql.Tx) IdentityRepository {
+	return r
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresIdentityRepository keeps identities in the user_identities table and authorization
+// requests in oidc_states.
+type PostgresIdentityRepository struct {
+	DB DB
+}
+
+// NewPostgresIdentityRepository creates a PostgresIdentityRepository.
+func NewPostgresIdentityRepository(db DB) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{DB: db}
+}
+
+// Get implements IdentityRepository.
+func (r *PostgresIdentityRepository) Get(ctx context.Context, provider, subject string) (*LinkedIdentity, error) {
+	var (
+		identity    LinkedIdentity
+		lastLoginAt sql.NullTime
+	)
+	err := r.DB.QueryRowContext(ctx, "SELECT user_id, provider, subject, email, created_at, last_login_at FROM user_identities WHERE provider = $1 AND subject = $2",
+		provider, subject).Scan(&identity.UserID, &identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt, &lastLoginAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastLoginAt.Valid {
+		identity.LastLoginAt = &lastLoginAt.Time
+	}
+	return &identity, nil
+}
+
+// List implements IdentityRepository.
+func (r *PostgresIdentityRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Identity, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT provider, email, created_at, last_login_at FROM user_identities WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.Identity{}
+	for rows.Next() {
+		var (
+			identity    models.Identity
+			lastLoginAt sql.NullTime
+		)
+		if err := rows.Scan(&identity.Provider, &identity.Email, &identity.CreatedAt, &lastLoginAt); err != nil {
+			return nil, err
+		}
+		if lastLoginAt.Valid {
+			identity.LastLoginAt = &lastLoginAt.Time
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// Create implements IdentityRepository.
+func (r *PostgresIdentityRepository) Create(ctx context.Context, identity *LinkedIdentity) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO user_identities (id, user_id, provider, subject, email, created_at, last_login_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		uuid.New(), identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt, identity.LastLoginAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrIdentityExists
+	}
+	return err
+}
+
+// RecordLogin implements IdentityRepository.
+func (r *PostgresIdentityRepository) RecordLogin(ctx context.Context, provider, subject string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE user_identities SET last_login_at = NOW() WHERE provider = $1 AND subject = $2", provider, subject)
+	return err
+}
+
+// Delete implements IdentityRepository.
+func (r *PostgresIdentityRepository) Delete(ctx context.Context, userID uuid.UUID, provider string) error {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM user_identities WHERE user_id = $1 AND provider = $2", userID, provider)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+// CreateState implements IdentityRepository.
+func (r *PostgresIdentityRepository) CreateState(ctx context.Context, state *OIDCState) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO oidc_states (state_hash, provider, user_id, code_verifier, nonce, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		state.StateHash, state.Provider, state.UserID, state.CodeVerifier, state.Nonce, state.CreatedAt, state.ExpiresAt)
+	return err
+}
+
+// TakeState implements IdentityRepository.
+func (r *PostgresIdentityRepository) TakeState(ctx context.Context, stateHash, provider string) (*OIDCState, error) {
+	var (
+		state  OIDCState
+		userID uuid.NullUUID
+	)
+	err := r.DB.QueryRowContext(ctx, `DELETE FROM oidc_states WHERE state_hash = $1 AND provider = $2 AND expires_at > NOW()
+		RETURNING state_hash, provider, user_id, code_verifier, nonce, created_at, expires_at`, stateHash, provider).Scan(
+		&state.StateHash, &state.Provider, &userID, &state.CodeVerifier, &state.Nonce, &state.CreatedAt, &state.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrOIDCStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		state.UserID = &userID.UUID
+	}
+	return &state, nil
+}
+
+// WithTx implements IdentityRepository.
+func (r *PostgresIdentityRepository) WithTx(tx *sql.Tx) IdentityRepository {
+	return &PostgresIdentityRepository{DB: tx}
+}
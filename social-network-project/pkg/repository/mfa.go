@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrMFANotFound is returned when the user has no TOTP secret in the requested state.
+var ErrMFANotFound = errors.New("repository: two-factor secret not found")
+
+// ErrMFAEnabled is returned by SetPendingTOTP when the user already confirmed a TOTP secret.
+var ErrMFAEnabled = errors.New("repository: two-factor authentication already enabled")
+
+// ErrChallengeNotFound is returned when no MFA challenge has the hash.
+var ErrChallengeNotFound = errors.New("repository: MFA challenge not found")
+
+// ErrRecoveryCodeNotFound is returned by UseRecoveryCode when the user has no unused code with the hash.
+var ErrRecoveryCodeNotFound = errors.New("repository: recovery code not found")
+
+// TOTPSecret is a user's TOTP secret. LastUsedStep is the time step of the last accepted code,
+// which can't be used again.
+type TOTPSecret struct {
+	Secret       string
+	LastUsedStep int64
+	Confirmed    bool
+}
+
+// MFAChallenge is issued to a user who passed the password check and still has to enter a second
+// factor. Only the hash of its token is stored.
+type MFAChallenge struct {
+	TokenHash  string
+	UserID     uuid.UUID
+	DeviceName string
+	Attempts   int
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	UsedAt     *time.Time
+}
+
+// MFARepository stores two-factor authentication state: TOTP secrets, recovery code hashes and
+// login challenges.
+type MFARepository interface {
+	// Enabled reports whether the user confirmed a TOTP secret, and so has to pass a second factor.
+	Enabled(ctx context.Context, userID uuid.UUID) (bool, error)
+	// Status returns whether TOTP is enabled and how many recovery codes are left.
+	Status(ctx context.Context, userID uuid.UUID) (*models.MFAStatus, error)
+	// SetPendingTOTP stores an unconfirmed secret, replacing a previous unconfirmed one, or returns
+	// ErrMFAEnabled.
+	SetPendingTOTP(ctx context.Context, userID uuid.UUID, secret string) error
+	// GetTOTP returns the user's secret, confirmed or not, or ErrMFANotFound. In a transaction the
+	// Postgres implementation locks it until commit.
+	GetTOTP(ctx context.Context, userID uuid.UUID) (*TOTPSecret, error)
+	// ConfirmTOTP enables the user's pending secret, whose code for step was just accepted.
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) error
+	// SetLastUsedStep records the step of an accepted code.
+	SetLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) error
+	// ReplaceRecoveryCodes discards the user's recovery codes and stores the new hashes.
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	// UseRecoveryCode marks an unused recovery code used, or returns ErrRecoveryCodeNotFound.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+	// Disable deletes the user's secret, recovery codes and challenges.
+	Disable(ctx context.Context, userID uuid.UUID) error
+
+	// CreateChallenge stores a new login challenge.
+	CreateChallenge(ctx context.Context, challenge *MFAChallenge) error
+	// GetChallenge returns the challenge, used or expired alike, or ErrChallengeNotFound. In a
+	// transaction the Postgres implementation locks it until commit.
+	GetChallenge(ctx context.Context, tokenHash string) (*MFAChallenge, error)
+	// RecordChallengeFailure counts a wrong second factor entered for the challenge.
+	RecordChallengeFailure(ctx context.Context, tokenHash string) error
+	// UseChallenge marks the challenge used.
+	UseChallenge(ctx context.Context, tokenHash string) error
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) MFARepository
+}
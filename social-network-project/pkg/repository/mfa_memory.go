@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// memoryRecoveryCode is a recovery code kept by MemoryMFARepository.
+type memoryRecoveryCode struct {
+	hash string
+	used bool
+}
+
+// MemoryMFARepository keeps two-factor state in process memory, for tests.
+type MemoryMFARepository struct {
+	mu            sync.Mutex
+	secrets       map[uuid.UUID]TOTPSecret
+	recoveryCodes map[uuid.UUID][]memoryRecoveryCode
+	challenges    map[string]MFAChallenge
+}
+
+// NewMemoryMFARepository creates an empty MemoryMFARepository.
+func NewMemoryMFARepository() *MemoryMFARepository {
+	return &MemoryMFARepository{
+		secrets:       make(map[uuid.UUID]TOTPSecret),
+		recoveryCodes: make(map[uuid.UUID][]memoryRecoveryCode),
+		challenges:    make(map[string]MFAChallenge),
+	}
+}
+
+// Enabled implements MFARepository.
+func (r *MemoryMFARepository) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.secrets[userID].Confirmed, nil
+}
+
+// Status implements MFARepository.
+func (r *MemoryMFARepository) Status(ctx context.Context, userID uuid.UUID) (*models.MFAStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := models.MFAStatus{TOTPEnabled: r.secrets[userID].Confirmed}
+	for _, code := range r.recoveryCodes[userID] {
+		if !code.used {
+			status.RecoveryCodesRemaining++
+		}
+	}
+	return &status, nil
+}
+
+// SetPendingTOTP implements MFARepository.
+func (r *MemoryMFARepository) SetPendingTOTP(ctx context.Context, userID uuid.UUID, secret string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.secrets[userID].Confirmed {
+		return ErrMFAEnabled
+	}
+	r.secrets[userID] = TOTPSecret{Secret: secret}
+	return nil
+}
+
+// GetTOTP implements MFARepository.
+func (r *MemoryMFARepository) GetTOTP(ctx context.Context, userID uuid.UUID) (*TOTPSecret, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	secret, ok := r.secrets[userID]
+	if !ok {
+		return nil, ErrMFANotFound
+	}
+	return &secret, nil
+}
+
+// ConfirmTOTP implements MFARepository.
+func (r *MemoryMFARepository) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if secret, ok := r.secrets[userID]; ok {
+		secret.Confirmed, secret.LastUsedStep = true, step
+		r.secrets[userID] = secret
+	}
+	return nil
+}
+
+// SetLastUsedStep implements MFARepository.
+func (r *MemoryMFARepository) SetLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if secret, ok := r.secrets[userID]; ok {
+		secret.LastUsedStep = step
+		r.secrets[userID] = secret
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes implements MFARepository.
+func (r *MemoryMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	codes := make([]memoryRecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = memoryRecoveryCode{hash: hash}
+	}
+	r.recoveryCodes[userID] = codes
+	return nil
+}
+
+// UseRecoveryCode implements MFARepository.
+func (r *MemoryMFARepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, code := range r.recoveryCodes[userID] {
+		if code.hash == codeHash && !code.used {
+			r.recoveryCodes[userID][i].used = true
+			return nil
+		}
+	}
+	return ErrRecoveryCodeNotFound
+}
+
+// Disable implements MFARepository.
+func (r *MemoryMFARepository) Disable(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.secrets, userID)
+	delete(r.recoveryCodes, userID)
+	for hash, challenge := range r.challenges {
+		if challenge.UserID == userID {
+			delete(r.challenges, hash)
+		}
+	}
+	return nil
+}
+
+// CreateChallenge implements MFARepository.
+func (r *MemoryMFARepository) CreateChallenge(ctx context.Context, challenge *MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.challenges[challenge.TokenHash]; exists {
+		return ErrConflict
+	}
+	r.challenges[challenge.TokenHash] = *challenge
+	return nil
+}
+
+// GetChallenge implements MFARepository.
+func (r *MemoryMFARepository) GetChallenge(ctx context.Context, tokenHash string) (*MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge, ok := r.challenges[tokenHash]
+	if !ok {
+		return nil, ErrChallengeNotFound
+	}
+	return &challenge, nil
+}
+
+// RecordChallengeFailure implements MFARepository.
+func (r *MemoryMFARepository) RecordChallengeFailure(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if challenge, ok := r.challenges[tokenHash]; ok {
+		challenge.Attempts++
+		r.challenges[tokenHash] = challenge
+	}
+	return nil
+}
+
+// UseChallenge implements MFARepository.
+func (r *MemoryMFARepository) UseChallenge(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if challenge, ok := r.challenges[tokenHash]; ok {
+		now := time.Now().UTC()
+		challenge.UsedAt = &now
+		r.challenges[tokenHash] = challenge
+	}
+	return nil
+}
+
+// WithTx implements MFARepository.
+func (r *MemoryMFARepository) WithTx(tx *sql.Tx) MFARepository {
+	return r
+}
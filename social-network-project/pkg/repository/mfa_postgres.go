@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresMFARepository keeps two-factor state in the user_totp, mfa_recovery_codes and
+// mfa_challenges tables.
+type PostgresMFARepository struct {
+	DB DB
+}
+
+// NewPostgresMFARepository creates a PostgresMFARepository.
+func NewPostgresMFARepository(db DB) *PostgresMFARepository {
+	return &PostgresMFARepository{DB: db}
+}
+
+// Enabled implements MFARepository.
+func (r *PostgresMFARepository) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var enabled bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM user_totp WHERE user_id = $1 AND confirmed_at IS NOT NULL)", userID).Scan(&enabled)
+	return enabled, err
+}
+
+// Status implements MFARepository.
+func (r *PostgresMFARepository) Status(ctx context.Context, userID uuid.UUID) (*models.MFAStatus, error) {
+	var status models.MFAStatus
+	err := r.DB.QueryRowContext(ctx, `SELECT
+		EXISTS (SELECT 1 FROM user_totp WHERE user_id = $1 AND confirmed_at IS NOT NULL),
+		(SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL)`, userID).Scan(
+		&status.TOTPEnabled, &status.RecoveryCodesRemaining,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetPendingTOTP implements MFARepository.
+func (r *PostgresMFARepository) SetPendingTOTP(ctx context.Context, userID uuid.UUID, secret string) error {
+	result, err := r.DB.ExecContext(ctx, `INSERT INTO user_totp (user_id, secret, created_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+		WHERE user_totp.confirmed_at IS NULL`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrMFAEnabled
+	}
+	return nil
+}
+
+// GetTOTP implements MFARepository.
+func (r *PostgresMFARepository) GetTOTP(ctx context.Context, userID uuid.UUID) (*TOTPSecret, error) {
+	var secret TOTPSecret
+	err := r.DB.QueryRowContext(ctx, "SELECT secret, last_used_step, confirmed_at IS NOT NULL FROM user_totp WHERE user_id = $1 FOR UPDATE", userID).Scan(
+		&secret.Secret, &secret.LastUsedStep, &secret.Confirmed,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrMFANotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// ConfirmTOTP implements MFARepository.
+func (r *PostgresMFARepository) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $1 WHERE user_id = $2", step, userID)
+	return err
+}
+
+// SetLastUsedStep implements MFARepository.
+func (r *PostgresMFARepository) SetLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE user_totp SET last_used_step = $1 WHERE user_id = $2", step, userID)
+	return err
+}
+
+// ReplaceRecoveryCodes implements MFARepository.
+func (r *PostgresMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	if _, err := r.DB.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := r.DB.ExecContext(ctx, "INSERT INTO mfa_recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)", uuid.New(), userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode implements MFARepository.
+func (r *PostgresMFARepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	result, err := r.DB.ExecContext(ctx, "UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL", userID, codeHash)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrRecoveryCodeNotFound
+	}
+	return nil
+}
+
+// Disable implements MFARepository.
+func (r *PostgresMFARepository) Disable(ctx context.Context, userID uuid.UUID) error {
+	for _, stmt := range []string{
+		"DELETE FROM user_totp WHERE user_id = $1",
+		"DELETE FROM mfa_recovery_codes WHERE user_id = $1",
+		"DELETE FROM mfa_challenges WHERE user_id = $1",
+	} {
+		if _, err := r.DB.ExecContext(ctx, stmt, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateChallenge implements MFARepository.
+func (r *PostgresMFARepository) CreateChallenge(ctx context.Context, challenge *MFAChallenge) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO mfa_challenges (token_hash, user_id, device_name, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		challenge.TokenHash, challenge.UserID, challenge.DeviceName, challenge.CreatedAt, challenge.ExpiresAt)
+	return err
+}
+
+// GetChallenge implements MFARepository.
+func (r *PostgresMFARepository) GetChallenge(ctx context.Context, tokenHash string) (*MFAChallenge, error) {
+	var (
+		challenge MFAChallenge
+		usedAt    sql.NullTime
+	)
+	err := r.DB.QueryRowContext(ctx, "SELECT token_hash, user_id, device_name, attempts, created_at, expires_at, used_at FROM mfa_challenges WHERE token_hash = $1 FOR UPDATE", tokenHash).Scan(
+		&challenge.TokenHash, &challenge.UserID, &challenge.DeviceName, &challenge.Attempts, &challenge.CreatedAt, &challenge.ExpiresAt, &usedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		challenge.UsedAt = &usedAt.Time
+	}
+	return &challenge, nil
+}
+
+// RecordChallengeFailure implements MFARepository.
+func (r *PostgresMFARepository) RecordChallengeFailure(ctx context.Context, tokenHash string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE mfa_challenges SET attempts = attempts + 1 WHERE token_hash = $1", tokenHash)
+	return err
+}
+
+// UseChallenge implements MFARepository.
+func (r *PostgresMFARepository) UseChallenge(ctx context.Context, tokenHash string) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE mfa_challenges SET used_at = NOW() WHERE token_hash = $1", tokenHash)
+	return err
+}
+
+// WithTx implements MFARepository.
+func (r *PostgresMFARepository) WithTx(tx *sql.Tx) MFARepository {
+	return &PostgresMFARepository{DB: tx}
+}
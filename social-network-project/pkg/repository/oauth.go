@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrClientNotFound is returned when no active OAuth client has the ID.
+var ErrClientNotFound = errors.New("repository: oauth client not found")
+
+// ErrConsentNotFound is returned when the user hasn't authorized the client.
+var ErrConsentNotFound = errors.New("repository: oauth consent not found")
+
+// ErrCodeNotFound is returned by GetCode when no authorization code has the hash.
+var ErrCodeNotFound = errors.New("repository: authorization code not found")
+
+// AuthorizationCode is a single-use code issued to a client at the end of the authorization
+// request. RedirectURI is the redirect_uri sent with the request, empty if the client left it out.
+// SessionID is the session it was redeemed for, once it has been.
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	Scope         string
+	CodeChallenge string
+	SessionID     *uuid.UUID
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+}
+
+// OAuthRepository stores the third-party clients registered with auth-service, the consent users
+// gave them and the authorization codes issued to them.
+type OAuthRepository interface {
+	// GetClient returns an active client and the hash of its secret, empty for public clients, or
+	// ErrClientNotFound for unknown and deleted clients.
+	GetClient(ctx context.Context, clientID string) (*models.OAuthClient, string, error)
+	// ListClients returns the active clients registered by the user, oldest first.
+	ListClients(ctx context.Context, ownerID uuid.UUID) ([]models.OAuthClient, error)
+	// CreateClient registers a client. secretHash is empty for public clients.
+	CreateClient(ctx context.Context, client *models.OAuthClient, secretHash string, ownerID uuid.UUID) error
+	// ClientOwner returns the user who registered an active client, or ErrClientNotFound.
+	ClientOwner(ctx context.Context, clientID string) (uuid.UUID, error)
+	// DeleteClient deletes one of the user's clients, or returns ErrClientNotFound.
+	DeleteClient(ctx context.Context, clientID string, ownerID uuid.UUID) error
+
+	// GetConsent returns the scopes the user granted the client, or ErrConsentNotFound.
+	GetConsent(ctx context.Context, userID uuid.UUID, clientID string) ([]string, error)
+	// ListConsents returns the active clients the user authorized, most recently authorized first.
+	ListConsents(ctx context.Context, userID uuid.UUID) ([]models.OAuthConsent, error)
+	// AddConsent adds scopes to those the user granted the client.
+	AddConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string, now time.Time) error
+	// DeleteConsents forgets the consent given to the client by one user when userID is set, or
+	// by every user otherwise.
+	DeleteConsents(ctx context.Context, clientID string, userID *uuid.UUID) error
+
+	// CreateCode stores a new authorization code.
+	CreateCode(ctx context.Context, code *AuthorizationCode) error
+	// GetCode returns the authorization code, used or expired alike, or ErrCodeNotFound. In a
+	// transaction the Postgres implementation locks it until commit.
+	GetCode(ctx context.Context, codeHash string) (*AuthorizationCode, error)
+	// UseCode marks the code redeemed for the session.
+	UseCode(ctx context.Context, codeHash string, sessionID uuid.UUID) error
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) OAuthRepository
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// memoryOAuthClient is a client kept by MemoryOAuthRepository.
+type memoryOAuthClient struct {
+	client     models.OAuthClient
+	secretHash string
+	ownerID    uuid.UUID
+	deleted    bool
+}
+
+// memoryConsentKey identifies a consent kept by MemoryOAuthRepository.
+type memoryConsentKey struct {
+	userID   uuid.UUID
+	clientID string
+}
+
+// MemoryOAuthRepository keeps OAuth clients, consents and codes in process memory, for tests.
+type MemoryOAuthRepository struct {
+	mu       sync.Mutex
+	clients  map[string]*memoryOAuthClient
+	consents map[memoryConsentKey]models.OAuthConsent
+	codes    map[string]AuthorizationCode
+}
+
+// NewMemoryOAuthRepository creates an empty MemoryOAuthRepository.
+func NewMemoryOAuthRepository() *MemoryOAuthRepository {
+	return &MemoryOAuthRepository{
+		clients:  make(map[string]*memoryOAuthClient),
+		consents: make(map[memoryConsentKey]models.OAuthConsent),
+		codes:    make(map[string]AuthorizationCode),
+	}
+}
+
+// GetClient implements OAuthRepository.
+func (r *MemoryOAuthRepository) GetClient(ctx context.Context, clientID string) (*models.OAuthClient, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.clients[clientID]
+	if !ok || stored.deleted {
+		return nil, "", ErrClientNotFound
+	}
+	client := stored.client
+	return &client, stored.secretHash, nil
+}
+
+// ListClients implements OAuthRepository.
+func (r *MemoryOAuthRepository) ListClients(ctx context.Context, ownerID uuid.UUID) ([]models.OAuthClient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clients := []models.OAuthClient{}
+	for _, stored := range r.clients {
+		if stored.ownerID == ownerID && !stored.deleted {
+			clients = append(clients, stored.client)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].CreatedAt.Before(clients[j].CreatedAt) })
+	return clients, nil
+}
+
+// CreateClient implements OAuthRepository.
+func (r *MemoryOAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient, secretHash string, ownerID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.clients[client.ID]; exists {
+		return ErrConflict
+	}
+	stored := *client
+	stored.Confidential = secretHash != ""
+	r.clients[client.ID] = &memoryOAuthClient{client: stored, secretHash: secretHash, ownerID: ownerID}
+	return nil
+}
+
+// ClientOwner implements OAuthRepository.
+func (r *MemoryOAuthRepository) ClientOwner(ctx context.Context, clientID string) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.clients[clientID]
+	if !ok || stored.deleted {
+		return uuid.Nil, ErrClientNotFound
+	}
+	return stored.ownerID, nil
+}
+
+// DeleteClient implements OAuthRepository.
+func (r *MemoryOAuthRepository) DeleteClient(ctx context.Context, clientID string, ownerID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.clients[clientID]
+	if !ok || stored.deleted || stored.ownerID != ownerID {
+		return ErrClientNotFound
+	}
+	stored.deleted = true
+	return nil
+}
+
+// GetConsent implements OAuthRepository.
+func (r *MemoryOAuthRepository) GetConsent(ctx context.Context, userID uuid.UUID, clientID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	consent, ok := r.consents[memoryConsentKey{userID, clientID}]
+	if !ok {
+		return nil, ErrConsentNotFound
+	}
+	return slices.Clone(consent.Scopes), nil
+}
+
+// ListConsents implements OAuthRepository.
+func (r *MemoryOAuthRepository) ListConsents(ctx context.Context, userID uuid.UUID) ([]models.OAuthConsent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	consents := []models.OAuthConsent{}
+	for key, consent := range r.consents {
+		if stored, ok := r.clients[key.clientID]; key.userID == userID && ok && !stored.deleted {
+			consent.ClientName = stored.client.Name
+			consents = append(consents, consent)
+		}
+	}
+	sort.Slice(consents, func(i, j int) bool { return consents[i].UpdatedAt.After(consents[j].UpdatedAt) })
+	return consents, nil
+}
+
+// AddConsent implements OAuthRepository.
+func (r *MemoryOAuthRepository) AddConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := memoryConsentKey{userID, clientID}
+	consent, ok := r.consents[key]
+	if !ok {
+		consent = models.OAuthConsent{ClientID: clientID, CreatedAt: now}
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(consent.Scopes, scope) {
+			consent.Scopes = append(consent.Scopes, scope)
+		}
+	}
+	consent.UpdatedAt = now
+	r.consents[key] = consent
+	return nil
+}
+
+// DeleteConsents implements OAuthRepository.
+func (r *MemoryOAuthRepository) DeleteConsents(ctx context.Context, clientID string, userID *uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.consents {
+		if key.clientID == clientID && (userID == nil || key.userID == *userID) {
+			delete(r.consents, key)
+		}
+	}
+	return nil
+}
+
+// CreateCode implements OAuthRepository.
+func (r *MemoryOAuthRepository) CreateCode(ctx context.Context, code *AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.codes[code.CodeHash]; exists {
+		return ErrConflict
+	}
+	r.codes[code.CodeHash] = *code
+	return nil
+}
+
+// GetCode implements OAuthRepository.
+func (r *MemoryOAuthRepository) GetCode(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return nil, ErrCodeNotFound
+	}
+	return &code, nil
+}
+
+// UseCode implements OAuthRepository.
+func (r *MemoryOAuthRepository) UseCode(ctx context.Context, codeHash string, sessionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if code, ok := r.codes[codeHash]; ok {
+		now := time.Now().UTC()
+		code.UsedAt, code.SessionID = &now, &sessionID
+		r.codes[codeHash] = code
+	}
+	return nil
+}
+
+// WithTx implements OAuthRepository.
+func (r *MemoryOAuthRepository) WithTx(tx *sql.Tx) OAuthRepository {
+	return r
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresOAuthRepository keeps clients, consents and codes in the oauth_clients, oauth_consents
+// and oauth_authorization_codes tables.
+type PostgresOAuthRepository struct {
+	DB DB
+}
+
+// NewPostgresOAuthRepository creates a PostgresOAuthRepository.
+func NewPostgresOAuthRepository(db DB) *PostgresOAuthRepository {
+	return &PostgresOAuthRepository{DB: db}
+}
+
+// GetClient implements OAuthRepository.
+func (r *PostgresOAuthRepository) GetClient(ctx context.Context, clientID string) (*models.OAuthClient, string, error) {
+	var (
+		client     models.OAuthClient
+		secretHash sql.NullString
+	)
+	err := r.DB.QueryRowContext(ctx, "SELECT id, name, secret_hash, redirect_uris, scopes, grant_types, created_at FROM oauth_clients WHERE id = $1 AND revoked_at IS NULL", clientID).Scan(
+		&client.ID, &client.Name, &secretHash, pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes), pq.Array(&client.GrantTypes), &client.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, "", ErrClientNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	client.Confidential = secretHash.Valid
+	return &client, secretHash.String, nil
+}
+
+// ListClients implements OAuthRepository.
+func (r *PostgresOAuthRepository) ListClients(ctx context.Context, ownerID uuid.UUID) ([]models.OAuthClient, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT id, name, secret_hash IS NOT NULL, redirect_uris, scopes, grant_types, created_at
+		FROM oauth_clients WHERE owner_user_id = $1 AND revoked_at IS NULL ORDER BY created_at`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []models.OAuthClient{}
+	for rows.Next() {
+		var client models.OAuthClient
+		if err := rows.Scan(&client.ID, &client.Name, &client.Confidential, pq.Array(&client.RedirectURIs), pq.Array(&client.Scopes), pq.Array(&client.GrantTypes), &client.CreatedAt); err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+// CreateClient implements OAuthRepository.
+func (r *PostgresOAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient, secretHash string, ownerID uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes, grant_types, owner_user_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		client.ID, client.Name, sql.NullString{String: secretHash, Valid: secretHash != ""}, pq.Array(client.RedirectURIs), pq.Array(client.Scopes), pq.Array(client.GrantTypes),
+		ownerID, client.CreatedAt)
+	return err
+}
+
+// ClientOwner implements OAuthRepository.
+func (r *PostgresOAuthRepository) ClientOwner(ctx context.Context, clientID string) (uuid.UUID, error) {
+	var ownerID uuid.UUID
+	err := r.DB.QueryRowContext(ctx, "SELECT owner_user_id FROM oauth_clients WHERE id = $1 AND revoked_at IS NULL", clientID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrClientNotFound
+	}
+	return ownerID, err
+}
+
+// DeleteClient implements OAuthRepository.
+func (r *PostgresOAuthRepository) DeleteClient(ctx context.Context, clientID string, ownerID uuid.UUID) error {
+	result, err := r.DB.ExecContext(ctx, "UPDATE oauth_clients SET revoked_at = NOW() WHERE id = $1 AND owner_user_id = $2 AND revoked_at IS NULL", clientID, ownerID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrClientNotFound
+	}
+	return nil
+}
+
+// GetConsent implements OAuthRepository.
+func (r *PostgresOAuthRepository) GetConsent(ctx context.Context, userID uuid.UUID, clientID string) ([]string, error) {
+	var scopes []string
+	err := r.DB.QueryRowContext(ctx, "SELECT scopes FROM oauth_consents WHERE user_id = $1 AND client_id = $2", userID, clientID).Scan(pq.Array(&scopes))
+	if err == sql.ErrNoRows {
+		return nil, ErrConsentNotFound
+	}
+	return scopes, err
+}
+
+// ListConsents implements OAuthRepository.
+func (r *PostgresOAuthRepository) ListConsents(ctx context.Context, userID uuid.UUID) ([]models.OAuthConsent, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT oc.client_id, c.name, oc.scopes, oc.created_at, oc.updated_at
+		FROM oauth_consents oc JOIN oauth_clients c ON c.id = oc.client_id
+		WHERE oc.user_id = $1 AND c.revoked_at IS NULL ORDER BY oc.updated_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consents := []models.OAuthConsent{}
+	for rows.Next() {
+		var consent models.OAuthConsent
+		if err := rows.Scan(&consent.ClientID, &consent.ClientName, pq.Array(&consent.Scopes), &consent.CreatedAt, &consent.UpdatedAt); err != nil {
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+	return consents, rows.Err()
+}
+
+// AddConsent implements OAuthRepository.
+func (r *PostgresOAuthRepository) AddConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string, now time.Time) error {
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO oauth_consents (user_id, client_id, scopes, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id, client_id) DO UPDATE
+		SET scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes)), updated_at = EXCLUDED.updated_at`,
+		userID, clientID, pq.Array(scopes), now)
+	return err
+}
+
+// DeleteConsents implements OAuthRepository.
+func (r *PostgresOAuthRepository) DeleteConsents(ctx context.Context, clientID string, userID *uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM oauth_consents WHERE client_id = $1 AND ($2::uuid IS NULL OR user_id = $2)", clientID, userID)
+	return err
+}
+
+// CreateCode implements OAuthRepository.
+func (r *PostgresOAuthRepository) CreateCode(ctx context.Context, code *AuthorizationCode) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scope, code_challenge, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scope, code.CodeChallenge, code.CreatedAt, code.ExpiresAt)
+	return err
+}
+
+// GetCode implements OAuthRepository.
+func (r *PostgresOAuthRepository) GetCode(ctx context.Context, codeHash string) (*AuthorizationCode, error) {
+	var (
+		code      AuthorizationCode
+		sessionID uuid.NullUUID
+		usedAt    sql.NullTime
+	)
+	err := r.DB.QueryRowContext(ctx, `SELECT code_hash, client_id, user_id, redirect_uri, scope, code_challenge, session_id, created_at, expires_at, used_at
+		FROM oauth_authorization_codes WHERE code_hash = $1 FOR UPDATE`, codeHash).Scan(
+		&code.CodeHash, &code.ClientID, &code.UserID, &code.RedirectURI, &code.Scope, &code.CodeChallenge, &sessionID, &code.CreatedAt, &code.ExpiresAt, &usedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sessionID.Valid {
+		code.SessionID = &sessionID.UUID
+	}
+	if usedAt.Valid {
+		code.UsedAt = &usedAt.Time
+	}
+	return &code, nil
+}
+
+// UseCode implements OAuthRepository.
+func (r *PostgresOAuthRepository) UseCode(ctx context.Context, codeHash string, sessionID uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE oauth_authorization_codes SET used_at = NOW(), session_id = $1 WHERE code_hash = $2", sessionID, codeHash)
+	return err
+}
+
+// WithTx implements OAuthRepository.
+func (r *PostgresOAuthRepository) WithTx(tx *sql.Tx) OAuthRepository {
+	return &PostgresOAuthRepository{DB: tx}
+}
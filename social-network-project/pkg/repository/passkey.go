@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrPasskeyNotFound is returned when the user has no passkey with the ID.
+var ErrPasskeyNotFound = errors.New("repository: passkey not found")
+
+// ErrPasskeyExists is returned by Create when the credential ID is already registered.
+var ErrPasskeyExists = errors.New("repository: passkey already registered")
+
+// ErrCeremonyNotFound is returned by TakeCeremony when no unexpired ceremony of the kind has the ID.
+var ErrCeremonyNotFound = errors.New("repository: webauthn ceremony not found")
+
+// PasskeyCredential is a stored passkey together with the WebAuthn credential data needed to
+// verify assertions made with it.
+type PasskeyCredential struct {
+	models.Passkey
+	UserID          uuid.UUID
+	CredentialID    []byte
+	PublicKey       []byte
+	AttestationType string
+	Transports      []string
+	Flags           uint8 // Authenticator data flags at registration
+	AAGUID          []byte
+	SignCount       uint32
+	CloneWarning    bool
+}
+
+// WebAuthnCeremony is the server-side state of a begun passkey registration or login. UserID is
+// nil for logins, which start before the user is known.
+type WebAuthnCeremony struct {
+	ID          uuid.UUID
+	UserID      *uuid.UUID
+	Kind        string
+	SessionData []byte // JSON-encoded webauthn.SessionData
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// PasskeyRepository stores passkeys and the WebAuthn ceremonies that register and use them.
+type PasskeyRepository interface {
+	// List returns the user's passkeys, oldest first.
+	List(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error)
+	// Credentials returns the user's passkeys with their credential data, oldest first.
+	Credentials(ctx context.Context, userID uuid.UUID) ([]PasskeyCredential, error)
+	// Create stores a new passkey, or returns ErrPasskeyExists.
+	Create(ctx context.Context, credential *PasskeyCredential) error
+	// RecordUse stores the sign counter of a successful assertion. cloneWarning is sticky: once
+	// set it stays set.
+	RecordUse(ctx context.Context, userID uuid.UUID, credentialID []byte, signCount uint32, cloneWarning bool) error
+	// Delete removes one of the user's passkeys, or returns ErrPasskeyNotFound.
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+
+	// SaveCeremony stores a begun ceremony and deletes expired ones.
+	SaveCeremony(ctx context.Context, ceremony *WebAuthnCeremony) error
+	// TakeCeremony deletes and returns an unexpired ceremony of the kind, so it can only be
+	// finished once, or returns ErrCeremonyNotFound.
+	TakeCeremony(ctx context.Context, id uuid.UUID, kind string) (*WebAuthnCeremony, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) PasskeyRepository
+}
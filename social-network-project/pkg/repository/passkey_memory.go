@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// MemoryPasskeyRepository keeps passkeys and WebAuthn ceremonies in process memory, for tests.
+type MemoryPasskeyRepository struct {
+	mu          sync.Mutex
+	credentials map[uuid.UUID]PasskeyCredential
+	ceremonies  map[uuid.UUID]WebAuthnCeremony
+}
+
+// NewMemoryPasskeyRepository creates an empty MemoryPasskeyRepository.
+func NewMemoryPasskeyRepository() *MemoryPasskeyRepository {
+	return &MemoryPasskeyRepository{
+		credentials: make(map[uuid.UUID]PasskeyCredential),
+		ceremonies:  make(map[uuid.UUID]WebAuthnCeremony),
+	}
+}
+
+// List implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error) {
+	credentials, _ := r.Credentials(ctx, userID)
+	passkeys := make([]models.Passkey, len(credentials))
+	for i, cred := range credentials {
+		passkeys[i] = cred.Passkey
+	}
+	return passkeys, nil
+}
+
+// Credentials implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) Credentials(ctx context.Context, userID uuid.UUID) ([]PasskeyCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var credentials []PasskeyCredential
+	for _, cred := range r.credentials {
+		if cred.UserID == userID {
+			credentials = append(credentials, cred)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].CreatedAt.Before(credentials[j].CreatedAt) })
+	return credentials, nil
+}
+
+// Create implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) Create(ctx context.Context, cred *PasskeyCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.credentials {
+		if bytes.Equal(existing.CredentialID, cred.CredentialID) {
+			return ErrPasskeyExists
+		}
+	}
+	r.credentials[cred.ID] = *cred
+	return nil
+}
+
+// RecordUse implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) RecordUse(ctx context.Context, userID uuid.UUID, credentialID []byte, signCount uint32, cloneWarning bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	for id, cred := range r.credentials {
+		if cred.UserID == userID && bytes.Equal(cred.CredentialID, credentialID) {
+			cred.SignCount, cred.CloneWarning, cred.LastUsedAt = signCount, cred.CloneWarning || cloneWarning, &now
+			r.credentials[id] = cred
+		}
+	}
+	return nil
+}
+
+// Delete implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cred, ok := r.credentials[id]; !ok || cred.UserID != userID {
+		return ErrPasskeyNotFound
+	}
+	delete(r.credentials, id)
+	return nil
+}
+
+// SaveCeremony implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) SaveCeremony(ctx context.Context, ceremony *WebAuthnCeremony) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for id, c := range r.ceremonies {
+		if c.ExpiresAt.Before(now) {
+			delete(r.ceremonies, id)
+		}
+	}
+	r.ceremonies[ceremony.ID] = *ceremony
+	return nil
+}
+
+// TakeCeremony implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) TakeCeremony(ctx context.Context, id uuid.UUID, kind string) (*WebAuthnCeremony, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ceremony, ok := r.ceremonies[id]
+	if !ok || ceremony.Kind != kind || !time.Now().Before(ceremony.ExpiresAt) {
+		return nil, ErrCeremonyNotFound
+	}
+	delete(r.ceremonies, id)
+	return &ceremony, nil
+}
+
+// WithTx implements PasskeyRepository.
+func (r *MemoryPasskeyRepository) WithTx(tx *sql.Tx) PasskeyRepository {
+	return r
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresPasskeyRepository keeps passkeys in the webauthn_credentials table and ceremonies in
+// webauthn_ceremonies.
+type PostgresPasskeyRepository struct {
+	DB DB
+}
+
+// NewPostgresPasskeyRepository creates a PostgresPasskeyRepository.
+func NewPostgresPasskeyRepository(db DB) *PostgresPasskeyRepository {
+	return &PostgresPasskeyRepository{DB: db}
+}
+
+// List implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Passkey, error) {
+	rows, err := r.DB.QueryContext(ctx, "SELECT id, name, backup_eligible, created_at, last_used_at FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passkeys := []models.Passkey{}
+	for rows.Next() {
+		var (
+			p          models.Passkey
+			lastUsedAt sql.NullTime
+		)
+		if err := rows.Scan(&p.ID, &p.Name, &p.BackupEligible, &p.CreatedAt, &lastUsedAt); err != nil {
+			return nil, err
+		}
+		if lastUsedAt.Valid {
+			p.LastUsedAt = &lastUsedAt.Time
+		}
+		passkeys = append(passkeys, p)
+	}
+	return passkeys, rows.Err()
+}
+
+// Credentials implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) Credentials(ctx context.Context, userID uuid.UUID) ([]PasskeyCredential, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT id, user_id, name, backup_eligible, created_at, last_used_at,
+		credential_id, public_key, attestation_type, transports, flags, aaguid, sign_count, clone_warning
+		FROM webauthn_credentials WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []PasskeyCredential
+	for rows.Next() {
+		var (
+			cred       PasskeyCredential
+			lastUsedAt sql.NullTime
+			flags      int16
+			signCount  int64
+		)
+		if err := rows.Scan(&cred.ID, &cred.UserID, &cred.Name, &cred.BackupEligible, &cred.CreatedAt, &lastUsedAt,
+			&cred.CredentialID, &cred.PublicKey, &cred.AttestationType, pq.Array(&cred.Transports), &flags, &cred.AAGUID, &signCount, &cred.CloneWarning); err != nil {
+			return nil, err
+		}
+		if lastUsedAt.Valid {
+			cred.LastUsedAt = &lastUsedAt.Time
+		}
+		cred.Flags, cred.SignCount = uint8(flags), uint32(signCount)
+		credentials = append(credentials, cred)
+	}
+	return credentials, rows.Err()
+}
+
+// Create implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) Create(ctx context.Context, cred *PasskeyCredential) error {
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO webauthn_credentials
+		(id, user_id, name, credential_id, public_key, attestation_type, transports, flags, backup_eligible, aaguid, sign_count, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		cred.ID, cred.UserID, cred.Name, cred.CredentialID, cred.PublicKey, cred.AttestationType, pq.Array(cred.Transports),
+		int16(cred.Flags), cred.BackupEligible, cred.AAGUID, int64(cred.SignCount), cred.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrPasskeyExists
+	}
+	return err
+}
+
+// RecordUse implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) RecordUse(ctx context.Context, userID uuid.UUID, credentialID []byte, signCount uint32, cloneWarning bool) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE webauthn_credentials SET sign_count = $1, clone_warning = clone_warning OR $2, last_used_at = NOW() WHERE credential_id = $3 AND user_id = $4",
+		int64(signCount), cloneWarning, credentialID, userID)
+	return err
+}
+
+// Delete implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// SaveCeremony implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) SaveCeremony(ctx context.Context, ceremony *WebAuthnCeremony) error {
+	// Login ceremonies can be started anonymously, so drop stale ones as new ones come in.
+	if _, err := r.DB.ExecContext(ctx, "DELETE FROM webauthn_ceremonies WHERE expires_at < NOW()"); err != nil {
+		log.Printf("Error deleting expired webauthn ceremonies: %v", err)
+	}
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO webauthn_ceremonies (id, user_id, kind, session_data, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		ceremony.ID, ceremony.UserID, ceremony.Kind, ceremony.SessionData, ceremony.CreatedAt, ceremony.ExpiresAt)
+	return err
+}
+
+// TakeCeremony implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) TakeCeremony(ctx context.Context, id uuid.UUID, kind string) (*WebAuthnCeremony, error) {
+	var (
+		ceremony WebAuthnCeremony
+		userID   uuid.NullUUID
+	)
+	err := r.DB.QueryRowContext(ctx, `DELETE FROM webauthn_ceremonies WHERE id = $1 AND kind = $2 AND expires_at > NOW()
+		RETURNING id, user_id, kind, session_data, created_at, expires_at`, id, kind).Scan(
+		&ceremony.ID, &userID, &ceremony.Kind, &ceremony.SessionData, &ceremony.CreatedAt, &ceremony.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrCeremonyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userID.Valid {
+		ceremony.UserID = &userID.UUID
+	}
+	return &ceremony, nil
+}
+
+// WithTx implements PasskeyRepository.
+func (r *PostgresPasskeyRepository) WithTx(tx *sql.Tx) PasskeyRepository {
+	return &PostgresPasskeyRepository{DB: tx}
+}
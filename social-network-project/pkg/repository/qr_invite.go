@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrInviteNotFound is returned when no unused, unexpired QR invite has the token.
+var ErrInviteNotFound = errors.New("repository: QR invite not found")
+
+// QRInviteRepository reads and writes one-time QR invites, by the hash of their token.
+type QRInviteRepository interface {
+	// Create stores a new invite.
+	Create(ctx context.Context, invite *models.QRInvite) error
+	// Get returns the invite if it is unused and unexpired, or ErrInviteNotFound.
+	Get(ctx context.Context, tokenHash string) (*models.QRInvite, error)
+	// Use marks the invite used by userID and returns it, or ErrInviteNotFound if it was already
+	// used or expired. Of two concurrent calls only one succeeds.
+	Use(ctx context.Context, tokenHash string, userID uuid.UUID) (*models.QRInvite, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) QRInviteRepository
+}
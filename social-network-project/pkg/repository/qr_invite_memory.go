@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// MemoryQRInviteRepository keeps QR invites in process memory, for tests.
+type MemoryQRInviteRepository struct {
+	mu      sync.Mutex
+	invites map[string]models.QRInvite
+}
+
+// NewMemoryQRInviteRepository creates an empty MemoryQRInviteRepository.
+func NewMemoryQRInviteRepository() *MemoryQRInviteRepository {
+	return &MemoryQRInviteRepository{invites: make(map[string]models.QRInvite)}
+}
+
+// Create implements QRInviteRepository.
+func (r *MemoryQRInviteRepository) Create(ctx context.Context, invite *models.QRInvite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.invites[invite.TokenHash]; exists {
+		return ErrConflict
+	}
+	r.invites[invite.TokenHash] = *invite
+	return nil
+}
+
+// Get implements QRInviteRepository.
+func (r *MemoryQRInviteRepository) Get(ctx context.Context, tokenHash string) (*models.QRInvite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invite, ok := r.invites[tokenHash]
+	if !ok || invite.UsedAt != nil || !time.Now().Before(invite.ExpiresAt) {
+		return nil, ErrInviteNotFound
+	}
+	return &invite, nil
+}
+
+// Use implements QRInviteRepository.
+func (r *MemoryQRInviteRepository) Use(ctx context.Context, tokenHash string, userID uuid.UUID) (*models.QRInvite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invite, ok := r.invites[tokenHash]
+	if !ok || invite.UsedAt != nil || !time.Now().Before(invite.ExpiresAt) {
+		return nil, ErrInviteNotFound
+	}
+	now := time.Now().UTC()
+	invite.UsedAt, invite.UsedBy = &now, &userID
+	r.invites[tokenHash] = invite
+	return &invite, nil
+}
+
+// WithTx implements QRInviteRepository.
+func (r *MemoryQRInviteRepository) WithTx(tx *sql.Tx) QRInviteRepository {
+	return r
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresQRInviteRepository keeps QR invites in the qr_invites table.
+type PostgresQRInviteRepository struct {
+	DB DB
+}
+
+// NewPostgresQRInviteRepository creates a PostgresQRInviteRepository.
+func NewPostgresQRInviteRepository(db DB) *PostgresQRInviteRepository {
+	return &PostgresQRInviteRepository{DB: db}
+}
+
+// Create implements QRInviteRepository.
+func (r *PostgresQRInviteRepository) Create(ctx context.Context, invite *models.QRInvite) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO qr_invites (token_hash, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		invite.TokenHash, invite.UserID, invite.CreatedAt, invite.ExpiresAt)
+	return err
+}
+
+// Get implements QRInviteRepository.
+func (r *PostgresQRInviteRepository) Get(ctx context.Context, tokenHash string) (*models.QRInvite, error) {
+	return scanQRInvite(r.DB.QueryRowContext(ctx, `SELECT token_hash, user_id, created_at, expires_at, used_at, used_by
+		FROM qr_invites WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()`, tokenHash))
+}
+
+// Use implements QRInviteRepository.
+func (r *PostgresQRInviteRepository) Use(ctx context.Context, tokenHash string, userID uuid.UUID) (*models.QRInvite, error) {
+	return scanQRInvite(r.DB.QueryRowContext(ctx, `UPDATE qr_invites SET used_at = NOW(), used_by = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING token_hash, user_id, created_at, expires_at, used_at, used_by`, tokenHash, userID))
+}
+
+func scanQRInvite(row rowScanner) (*models.QRInvite, error) {
+	var (
+		invite models.QRInvite
+		usedAt sql.NullTime
+		usedBy uuid.NullUUID
+	)
+	err := row.Scan(&invite.TokenHash, &invite.UserID, &invite.CreatedAt, &invite.ExpiresAt, &usedAt, &usedBy)
+	if err == sql.ErrNoRows {
+		return nil, ErrInviteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		invite.UsedAt = &usedAt.Time
+	}
+	if usedBy.Valid {
+		invite.UsedBy = &usedBy.UUID
+	}
+	return &invite, nil
+}
+
+// WithTx implements QRInviteRepository.
+func (r *PostgresQRInviteRepository) WithTx(tx *sql.Tx) QRInviteRepository {
+	return &PostgresQRInviteRepository{DB: tx}
+}
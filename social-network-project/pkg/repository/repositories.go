@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/yourusername/social-network/pkg/throttle"
+)
+
+// Repositories bundles every repository the services use, so handlers can be built from one value.
+type Repositories struct {
+	Tx Transactor
+
+	Users         UserRepository
+	Sessions      SessionRepository
+	Roles         RoleRepository
+	Audit         AuditRepository
+	MFA           MFARepository
+	Passkeys      PasskeyRepository
+	Identities    IdentityRepository
+	OAuth         OAuthRepository
+	AccountTokens AccountTokenRepository
+	Follows       FollowRepository
+	Blocks        BlockRepository
+	QRInvites     QRInviteRepository
+	Search        SearchRepository
+	LoginThrottle throttle.Store
+}
+
+// NewPostgresRepositories creates the Postgres repositories for db.
+func NewPostgresRepositories(db *sql.DB) *Repositories {
+	return &Repositories{
+		Tx:            NewPostgresTransactor(db),
+		Users:         NewPostgresUserRepository(db),
+		Sessions:      NewPostgresSessionRepository(db),
+		Roles:         NewPostgresRoleRepository(db),
+		Audit:         NewPostgresAuditRepository(db),
+		MFA:           NewPostgresMFARepository(db),
+		Passkeys:      NewPostgresPasskeyRepository(db),
+		Identities:    NewPostgresIdentityRepository(db),
+		OAuth:         NewPostgresOAuthRepository(db),
+		AccountTokens: NewPostgresAccountTokenRepository(db),
+		Follows:       NewPostgresFollowRepository(db),
+		Blocks:        NewPostgresBlockRepository(db),
+		QRInvites:     NewPostgresQRInviteRepository(db),
+		Search:        NewPostgresSearchRepository(db),
+		LoginThrottle: throttle.NewPostgresStore(db),
+	}
+}
+
+// NewMemoryRepositories creates empty memory repositories, for tests. The built-in roles exist,
+// and Sessions and the social graph repositories see the users added to Users.
+func NewMemoryRepositories() *Repositories {
+	users := NewMemoryUserRepository()
+	follows := NewMemoryFollowRepository(users)
+	blocks := NewMemoryBlockRepository(users, follows)
+	return &Repositories{
+		Tx:            MemoryTransactor{},
+		Users:         users,
+		Sessions:      NewMemorySessionRepository(users),
+		Roles:         NewMemoryRoleRepository(BuiltinRoles),
+		Audit:         NewMemoryAuditRepository(),
+		MFA:           NewMemoryMFARepository(),
+		Passkeys:      NewMemoryPasskeyRepository(),
+		Identities:    NewMemoryIdentityRepository(),
+		OAuth:         NewMemoryOAuthRepository(),
+		AccountTokens: NewMemoryAccountTokenRepository(),
+		Follows:       follows,
+		Blocks:        blocks,
+		QRInvites:     NewMemoryQRInviteRepository(),
+		Search:        NewMemorySearchRepository(users, follows, blocks),
+		LoginThrottle: throttle.NewMemoryStore(),
+	}
+}
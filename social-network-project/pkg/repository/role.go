@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// RoleRepository reads the roles and permissions seeded by the migrations and the roles assigned
+// to users. RoleUser, which everyone has, is never stored.
+type RoleRepository interface {
+	// UserRoles returns the roles assigned to the user, by name.
+	UserRoles(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// RolesByUser returns the roles assigned to each of the users, by name. Users without roles
+	// are missing from the map.
+	RolesByUser(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]string, error)
+	// HasPermission reports whether any of the user's roles grants the permission.
+	HasPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error)
+	// List returns every role with its permissions, by name.
+	List(ctx context.Context) ([]models.Role, error)
+	// Unknown returns the names in roles that aren't roles.
+	Unknown(ctx context.Context, roles []string) ([]string, error)
+	// SetUserRoles replaces the roles assigned to the user.
+	SetUserRoles(ctx context.Context, userID uuid.UUID, roles []string, grantedBy uuid.UUID) error
+	// Grant assigns one more role to the user and reports whether they didn't have it yet.
+	Grant(ctx context.Context, userID uuid.UUID, role string) (bool, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) RoleRepository
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// BuiltinRoles are the roles the migrations seed, for memory repositories.
+var BuiltinRoles = []models.Role{
+	{Name: models.RoleAdmin, Description: "Full access to the admin API", Permissions: []string{
+		models.PermAuditRead, models.PermRolesAssign, models.PermUsersDeactivate, models.PermUsersRead, models.PermUsersResetPassword, models.PermUsersUnlock,
+	}},
+	{Name: models.RoleSupport, Description: "Support staff: view, deactivate, unlock and force password resets", Permissions: []string{
+		models.PermUsersDeactivate, models.PermUsersRead, models.PermUsersResetPassword, models.PermUsersUnlock,
+	}},
+}
+
+// MemoryRoleRepository keeps role assignments in process memory, for tests.
+type MemoryRoleRepository struct {
+	roles     []models.Role
+	mu        sync.RWMutex
+	userRoles map[uuid.UUID][]string
+}
+
+// NewMemoryRoleRepository creates a MemoryRoleRepository defining roles, without assignments.
+func NewMemoryRoleRepository(roles []models.Role) *MemoryRoleRepository {
+	return &MemoryRoleRepository{roles: roles, userRoles: make(map[uuid.UUID][]string)}
+}
+
+// role returns the role with the name, or nil.
+func (r *MemoryRoleRepository) role(name string) *models.Role {
+	for i := range r.roles {
+		if r.roles[i].Name == name {
+			return &r.roles[i]
+		}
+	}
+	return nil
+}
+
+// UserRoles implements RoleRepository.
+func (r *MemoryRoleRepository) UserRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.userRoles[userID]), nil
+}
+
+// RolesByUser implements RoleRepository.
+func (r *MemoryRoleRepository) RolesByUser(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	roles := make(map[uuid.UUID][]string)
+	for _, id := range userIDs {
+		if assigned := r.userRoles[id]; len(assigned) > 0 {
+			roles[id] = slices.Clone(assigned)
+		}
+	}
+	return roles, nil
+}
+
+// HasPermission implements RoleRepository.
+func (r *MemoryRoleRepository) HasPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, name := range r.userRoles[userID] {
+		if role := r.role(name); role != nil && slices.Contains(role.Permissions, permission) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// List implements RoleRepository.
+func (r *MemoryRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	roles := slices.Clone(r.roles)
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// Unknown implements RoleRepository.
+func (r *MemoryRoleRepository) Unknown(ctx context.Context, roles []string) ([]string, error) {
+	unknown := []string{}
+	for _, name := range roles {
+		if r.role(name) == nil {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown, nil
+}
+
+// SetUserRoles implements RoleRepository.
+func (r *MemoryRoleRepository) SetUserRoles(ctx context.Context, userID uuid.UUID, roles []string, grantedBy uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	assigned := []string{}
+	for _, name := range roles {
+		if !slices.Contains(assigned, name) {
+			assigned = append(assigned, name)
+		}
+	}
+	sort.Strings(assigned)
+	r.userRoles[userID] = assigned
+	return nil
+}
+
+// Grant implements RoleRepository.
+func (r *MemoryRoleRepository) Grant(ctx context.Context, userID uuid.UUID, role string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Contains(r.userRoles[userID], role) {
+		return false, nil
+	}
+	r.userRoles[userID] = append(r.userRoles[userID], role)
+	sort.Strings(r.userRoles[userID])
+	return true, nil
+}
+
+// WithTx implements RoleRepository.
+func (r *MemoryRoleRepository) WithTx(tx *sql.Tx) RoleRepository {
+	return r
+}
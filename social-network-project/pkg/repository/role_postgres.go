@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresRoleRepository reads the roles, role_permissions and user_roles tables.
+type PostgresRoleRepository struct {
+	DB DB
+}
+
+// NewPostgresRoleRepository creates a PostgresRoleRepository.
+func NewPostgresRoleRepository(db DB) *PostgresRoleRepository {
+	return &PostgresRoleRepository{DB: db}
+}
+
+// UserRoles implements RoleRepository.
+func (r *PostgresRoleRepository) UserRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	roles, err := r.RolesByUser(ctx, []uuid.UUID{userID})
+	if err != nil {
+		return nil, err
+	}
+	return roles[userID], nil
+}
+
+// RolesByUser implements RoleRepository.
+func (r *PostgresRoleRepository) RolesByUser(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]string, error) {
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+	rows, err := r.DB.QueryContext(ctx, "SELECT user_id, role FROM user_roles WHERE user_id = ANY($1::uuid[]) ORDER BY role", pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[uuid.UUID][]string)
+	for rows.Next() {
+		var (
+			userID uuid.UUID
+			role   string
+		)
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = append(roles[userID], role)
+	}
+	return roles, rows.Err()
+}
+
+// HasPermission implements RoleRepository.
+func (r *PostgresRoleRepository) HasPermission(ctx context.Context, userID uuid.UUID, permission string) (bool, error) {
+	var allowed bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM user_roles ur JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.user_id = $1 AND rp.permission = $2)`, userID, permission).Scan(&allowed)
+	return allowed, err
+}
+
+// List implements RoleRepository.
+func (r *PostgresRoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT r.name, r.description, COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r LEFT JOIN role_permissions rp ON rp.role = r.name GROUP BY r.name, r.description ORDER BY r.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.Name, &role.Description, pq.Array(&role.Permissions)); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// Unknown implements RoleRepository.
+func (r *PostgresRoleRepository) Unknown(ctx context.Context, roles []string) ([]string, error) {
+	var unknown []string
+	err := r.DB.QueryRowContext(ctx, "SELECT COALESCE(array_agg(r), '{}') FROM unnest($1::text[]) AS r WHERE r NOT IN (SELECT name FROM roles)",
+		pq.Array(roles)).Scan(pq.Array(&unknown))
+	return unknown, err
+}
+
+// SetUserRoles implements RoleRepository.
+func (r *PostgresRoleRepository) SetUserRoles(ctx context.Context, userID uuid.UUID, roles []string, grantedBy uuid.UUID) error {
+	if _, err := r.DB.ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO user_roles (user_id, role, granted_by) SELECT $1, r, $2 FROM unnest($3::text[]) AS r ON CONFLICT DO NOTHING",
+		userID, grantedBy, pq.Array(roles))
+	return err
+}
+
+// Grant implements RoleRepository.
+func (r *PostgresRoleRepository) Grant(ctx context.Context, userID uuid.UUID, role string) (bool, error) {
+	result, err := r.DB.ExecContext(ctx, "INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING", userID, role)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// WithTx implements RoleRepository.
+func (r *PostgresRoleRepository) WithTx(tx *sql.Tx) RoleRepository {
+	return &PostgresRoleRepository{DB: tx}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// Search scores: a match's trigram similarity (0 to 1) to the query, plus these bonuses. An exact
+// match always ranks first; among the rest a followed user outranks an unfollowed one matching
+// the same way.
+const (
+	scoreExact    = 2
+	scorePrefix   = 1
+	scoreFollowed = 0.5
+)
+
+// UserSearch is a query for SearchRepository.SearchUsers.
+type UserSearch struct {
+	Query    string    // Matched against username and display name, ignoring case
+	ViewerID uuid.UUID // Ranks users they follow higher and leaves out users blocked either way; uuid.Nil for neither
+	After    *SearchCursor
+	Limit    int
+}
+
+// SearchCursor is the position of the last result of a page; the next page starts after it.
+type SearchCursor struct {
+	Score float64
+	ID    uuid.UUID
+}
+
+// SearchResult is a user found by SearchUsers, with its position for the next page's cursor.
+type SearchResult struct {
+	models.UserSearchResult
+	Cursor SearchCursor
+}
+
+// SearchRepository finds users.
+type SearchRepository interface {
+	// SearchUsers returns active users whose username or display name starts with the query or is
+	// similar to it, best match first. Results are ordered by score, then ID, so a cursor stays
+	// stable while users are added.
+	SearchUsers(ctx context.Context, search UserSearch) ([]SearchResult, error)
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) SearchRepository
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// similarityThreshold is the default pg_trgm.similarity_threshold.
+const similarityThreshold = 0.3
+
+// MemorySearchRepository searches a MemoryUserRepository, for tests. Its similarity approximates
+// pg_trgm's, so rankings match PostgresSearchRepository for ordinary names.
+type MemorySearchRepository struct {
+	users   *MemoryUserRepository
+	follows *MemoryFollowRepository
+	blocks  *MemoryBlockRepository
+}
+
+// NewMemorySearchRepository creates a MemorySearchRepository over users, follows and blocks.
+func NewMemorySearchRepository(users *MemoryUserRepository, follows *MemoryFollowRepository, blocks *MemoryBlockRepository) *MemorySearchRepository {
+	return &MemorySearchRepository{users: users, follows: follows, blocks: blocks}
+}
+
+// SearchUsers implements SearchRepository. A zero limit means no limit.
+func (r *MemorySearchRepository) SearchUsers(ctx context.Context, search UserSearch) ([]SearchResult, error) {
+	query := strings.ToLower(strings.TrimSpace(search.Query))
+	candidates, err := r.users.List(ctx, UserFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	for _, u := range candidates {
+		if !u.IsActive {
+			continue
+		}
+		if blocked, _ := r.blocks.IsBlocked(ctx, search.ViewerID, u.ID); blocked {
+			continue
+		}
+		displayName := strings.ToLower(u.DisplayName)
+		similar := math.Max(similarity(u.Username, query), similarity(displayName, query))
+		score := similar
+		switch {
+		case u.Username == query || displayName == query:
+			score += scoreExact
+		case strings.HasPrefix(u.Username, query) || (displayName != "" && strings.HasPrefix(displayName, query)):
+			score += scorePrefix
+		case similar < similarityThreshold:
+			continue
+		}
+		following, _ := r.follows.IsFollowing(ctx, search.ViewerID, u.ID)
+		if following {
+			score += scoreFollowed
+		}
+
+		var result SearchResult
+		result.ID, result.Username, result.DisplayName, result.IsPrivate, result.IsFollowing = u.ID, u.Username, u.DisplayName, u.IsPrivate, following
+		result.Cursor = SearchCursor{Score: math.Round(score*1e4) / 1e4, ID: u.ID}
+		if search.After != nil && !cursorLess(result.Cursor, *search.After) {
+			continue
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return cursorLess(results[j].Cursor, results[i].Cursor) })
+	if search.Limit > 0 && search.Limit < len(results) {
+		results = results[:search.Limit]
+	}
+	return results, nil
+}
+
+// cursorLess reports whether a is less than b in (score, ID) order. Results are in descending order.
+func cursorLess(a, b SearchCursor) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return strings.Compare(a.ID.String(), b.ID.String()) < 0
+}
+
+// similarity is pg_trgm's similarity: the share of trigrams two strings have in common.
+func similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the trigrams of s as pg_trgm extracts them: each word, lower-cased and padded
+// with two spaces in front and one behind, split into every three-character run.
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// WithTx implements SearchRepository.
+func (r *MemorySearchRepository) WithTx(tx *sql.Tx) SearchRepository {
+	return r
+}
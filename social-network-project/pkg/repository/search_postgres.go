@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// PostgresSearchRepository searches the users table with pg_trgm.
+type PostgresSearchRepository struct {
+	DB DB
+}
+
+// NewPostgresSearchRepository creates a PostgresSearchRepository.
+func NewPostgresSearchRepository(db DB) *PostgresSearchRepository {
+	return &PostgresSearchRepository{DB: db}
+}
+
+// likeEscaper escapes LIKE wildcards; underscores are common in usernames.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers implements SearchRepository. Fuzzy matches use the % operator, so
+// pg_trgm.similarity_threshold (0.3 by default) decides how similar they must be. Scores are
+// rounded so cursors compare exactly.
+func (r *PostgresSearchRepository) SearchUsers(ctx context.Context, search UserSearch) ([]SearchResult, error) {
+	query := strings.ToLower(strings.TrimSpace(search.Query))
+	var afterScore, afterID interface{}
+	if search.After != nil {
+		afterScore, afterID = strconv.FormatFloat(search.After.Score, 'f', -1, 64), search.After.ID
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `SELECT id, username, display_name, is_private, is_following, score FROM (
+			SELECT u.id, u.username, COALESCE(u.display_name, '') AS display_name, u.is_private,
+				f.follower_id IS NOT NULL AS is_following,
+				round((greatest(similarity(u.username, $1), similarity(lower(COALESCE(u.display_name, '')), $1))
+					+ CASE WHEN u.username = $1 OR lower(u.display_name) = $1 THEN $6::float8
+						WHEN u.username LIKE $2 OR lower(u.display_name) LIKE $2 THEN $7::float8 ELSE 0 END
+					+ CASE WHEN f.follower_id IS NOT NULL THEN $8::float8 ELSE 0 END)::numeric, 4) AS score
+			FROM users u
+			LEFT JOIN follows f ON f.follower_id = $3 AND f.followee_id = u.id
+			WHERE u.is_active
+				AND (u.username LIKE $2 OR lower(u.display_name) LIKE $2 OR u.username % $1 OR lower(u.display_name) % $1)
+				AND NOT EXISTS (SELECT 1 FROM user_blocks b
+					WHERE (b.blocker_id = $3 AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = $3))
+		) matches
+		WHERE $4::numeric IS NULL OR (score, id) < ($4::numeric, $5::uuid)
+		ORDER BY score DESC, id DESC
+		LIMIT $9`,
+		query, likeEscaper.Replace(query)+"%", search.ViewerID, afterScore, afterID,
+		scoreExact, scorePrefix, scoreFollowed, search.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		if err := rows.Scan(&result.ID, &result.Username, &result.DisplayName, &result.IsPrivate, &result.IsFollowing, &result.Cursor.Score); err != nil {
+			return nil, err
+		}
+		result.Cursor.ID = result.ID
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// WithTx implements SearchRepository.
+func (r *PostgresSearchRepository) WithTx(tx *sql.Tx) SearchRepository {
+	return &PostgresSearchRepository{DB: tx}
+}
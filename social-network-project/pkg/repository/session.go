@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrSessionNotFound is returned when no active session matches.
+var ErrSessionNotFound = errors.New("repository: session not found")
+
+// ErrTokenNotFound is returned when no stored token has the hash or jti.
+var ErrTokenNotFound = errors.New("repository: token not found")
+
+// RefreshToken is a single-use refresh token. Rotating it creates the next one in the same session,
+// so the tokens of a session form a family.
+type RefreshToken struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+
+	// Read from the session by GetRefreshToken.
+	ClientID       string
+	Scope          string
+	SessionRevoked bool
+}
+
+// SessionRepository is the session registry: sessions, the jti of every access token issued for
+// them, refresh tokens, and the jti of client_credentials tokens, which have no session. Both
+// services check access tokens against it, so revoking a session works everywhere at once.
+type SessionRepository interface {
+	// Create stores a new session.
+	Create(ctx context.Context, session *models.Session) error
+	// ListActive returns the user's sessions that are neither revoked nor expired, most recently used first.
+	ListActive(ctx context.Context, userID uuid.UUID) ([]models.Session, error)
+	// SetExpiry changes when the session expires.
+	SetExpiry(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) error
+	// Revoke revokes one active session of the user together with its refresh tokens, or returns
+	// ErrSessionNotFound.
+	Revoke(ctx context.Context, userID, sessionID uuid.UUID) error
+	// RevokeAll revokes every session of the user together with their refresh tokens.
+	RevokeAll(ctx context.Context, userID uuid.UUID) error
+	// RevokeClient revokes the sessions and refresh tokens issued to an OAuth client for one user
+	// when userID is set. Otherwise it revokes them for every user, and the client's
+	// client_credentials tokens too.
+	RevokeClient(ctx context.Context, clientID string, userID *uuid.UUID) error
+
+	// AddAccessToken records the jti of an access token issued for the session.
+	AddAccessToken(ctx context.Context, jti, sessionID uuid.UUID, expiresAt time.Time) error
+	// CheckAccessToken returns the session of the user's access token, or ErrSessionNotFound if the
+	// session was revoked or the token was issued before the user's last password change.
+	CheckAccessToken(ctx context.Context, jti, userID uuid.UUID, issuedAt time.Time) (uuid.UUID, error)
+	// Touch bumps the session's last-seen time, at most once a minute to keep writes down.
+	Touch(ctx context.Context, sessionID uuid.UUID) error
+
+	// AddRefreshToken stores a new refresh token and keeps its session alive as long as the token.
+	AddRefreshToken(ctx context.Context, token *RefreshToken) error
+	// GetRefreshToken returns the refresh token, used, revoked or expired alike, or
+	// ErrTokenNotFound. In a transaction the Postgres implementation locks it until commit.
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// UseRefreshToken marks a rotated refresh token used and records its successor.
+	UseRefreshToken(ctx context.Context, id, replacedBy uuid.UUID) error
+
+	// AddClientToken records the jti of a client_credentials token.
+	AddClientToken(ctx context.Context, jti uuid.UUID, clientID string, createdAt, expiresAt time.Time) error
+	// CheckClientToken returns ErrTokenNotFound unless the client's token is unrevoked and the
+	// client still exists.
+	CheckClientToken(ctx context.Context, jti uuid.UUID, clientID string) error
+	// RevokeClientToken revokes a client_credentials token.
+	RevokeClientToken(ctx context.Context, jti uuid.UUID) error
+
+	// WithTx returns a repository whose statements run in tx. Implementations without
+	// transactions return themselves.
+	WithTx(tx *sql.Tx) SessionRepository
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// memoryClientToken is a client_credentials token kept by MemorySessionRepository.
+type memoryClientToken struct {
+	clientID  string
+	expiresAt time.Time
+	revoked   bool
+}
+
+// MemorySessionRepository keeps the session registry in process memory, for tests. It reads password
+// changes from users, as the Postgres implementation joins the users table.
+type MemorySessionRepository struct {
+	mu            sync.Mutex
+	users         *MemoryUserRepository
+	sessions      map[uuid.UUID]models.Session
+	accessTokens  map[uuid.UUID]uuid.UUID // jti to session ID
+	refreshTokens map[string]RefreshToken // By hash
+	clientTokens  map[uuid.UUID]memoryClientToken
+}
+
+// NewMemorySessionRepository creates an empty MemorySessionRepository for the users in users.
+func NewMemorySessionRepository(users *MemoryUserRepository) *MemorySessionRepository {
+	return &MemorySessionRepository{
+		users:         users,
+		sessions:      make(map[uuid.UUID]models.Session),
+		accessTokens:  make(map[uuid.UUID]uuid.UUID),
+		refreshTokens: make(map[string]RefreshToken),
+		clientTokens:  make(map[uuid.UUID]memoryClientToken),
+	}
+}
+
+// Create implements SessionRepository.
+func (r *MemorySessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.sessions[session.ID]; exists {
+		return ErrConflict
+	}
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+// ListActive implements SessionRepository.
+func (r *MemorySessionRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	sessions := []models.Session{}
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// SetExpiry implements SessionRepository.
+func (r *MemorySessionRepository) SetExpiry(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		s.ExpiresAt = expiresAt
+		r.sessions[sessionID] = s
+	}
+	return nil
+}
+
+// Revoke implements SessionRepository.
+func (r *MemorySessionRepository) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sessionID]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	r.revoke(func(s *models.Session) bool { return s.ID == sessionID })
+	return nil
+}
+
+// RevokeAll implements SessionRepository.
+func (r *MemorySessionRepository) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoke(func(s *models.Session) bool { return s.UserID == userID })
+	return nil
+}
+
+// RevokeClient implements SessionRepository.
+func (r *MemorySessionRepository) RevokeClient(ctx context.Context, clientID string, userID *uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoke(func(s *models.Session) bool {
+		return s.ClientID == clientID && (userID == nil || s.UserID == *userID)
+	})
+	if userID == nil {
+		for jti, t := range r.clientTokens {
+			if t.clientID == clientID {
+				t.revoked = true
+				r.clientTokens[jti] = t
+			}
+		}
+	}
+	return nil
+}
+
+// revoke revokes the unrevoked sessions matching and their refresh tokens. The caller must hold mu.
+func (r *MemorySessionRepository) revoke(match func(s *models.Session) bool) {
+	now := time.Now().UTC()
+	for id, s := range r.sessions {
+		if s.RevokedAt == nil && match(&s) {
+			s.RevokedAt = &now
+			r.sessions[id] = s
+		}
+	}
+	for hash, t := range r.refreshTokens {
+		if s := r.sessions[t.SessionID]; t.RevokedAt == nil && s.RevokedAt != nil {
+			t.RevokedAt = &now
+			r.refreshTokens[hash] = t
+		}
+	}
+}
+
+// AddAccessToken implements SessionRepository.
+func (r *MemorySessionRepository) AddAccessToken(ctx context.Context, jti, sessionID uuid.UUID, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accessTokens[jti] = sessionID
+	return nil
+}
+
+// CheckAccessToken implements SessionRepository, with the same second-precision rule as
+// PostgresSessionRepository.
+func (r *MemorySessionRepository) CheckAccessToken(ctx context.Context, jti, userID uuid.UUID, issuedAt time.Time) (uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessionID, ok := r.accessTokens[jti]
+	if !ok {
+		return uuid.Nil, ErrSessionNotFound
+	}
+	s := r.sessions[sessionID]
+	if s.UserID != userID || s.RevokedAt != nil {
+		return uuid.Nil, ErrSessionNotFound
+	}
+	if changedAt, ok := r.users.passwordChanged(userID); ok &&
+		!changedAt.Truncate(time.Second).Before(issuedAt) && !s.CreatedAt.After(changedAt) {
+		return uuid.Nil, ErrSessionNotFound
+	}
+	return sessionID, nil
+}
+
+// Touch implements SessionRepository.
+func (r *MemorySessionRepository) Touch(ctx context.Context, sessionID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	if s, ok := r.sessions[sessionID]; ok && now.Sub(s.LastSeenAt) > time.Minute {
+		s.LastSeenAt = now
+		r.sessions[sessionID] = s
+	}
+	return nil
+}
+
+// AddRefreshToken implements SessionRepository.
+func (r *MemorySessionRepository) AddRefreshToken(ctx context.Context, token *RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.refreshTokens[token.TokenHash]; exists {
+		return ErrConflict
+	}
+	r.refreshTokens[token.TokenHash] = *token
+	if s, ok := r.sessions[token.SessionID]; ok {
+		s.ExpiresAt, s.LastSeenAt = token.ExpiresAt, token.CreatedAt
+		r.sessions[token.SessionID] = s
+	}
+	return nil
+}
+
+// GetRefreshToken implements SessionRepository.
+func (r *MemorySessionRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	s := r.sessions[token.SessionID]
+	token.ClientID, token.Scope, token.SessionRevoked = s.ClientID, s.Scope, s.RevokedAt != nil
+	return &token, nil
+}
+
+// UseRefreshToken implements SessionRepository.
+func (r *MemorySessionRepository) UseRefreshToken(ctx context.Context, id, replacedBy uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now().UTC()
+	for hash, t := range r.refreshTokens {
+		if t.ID == id {
+			t.UsedAt = &now
+			r.refreshTokens[hash] = t
+		}
+	}
+	return nil
+}
+
+// AddClientToken implements SessionRepository.
+func (r *MemorySessionRepository) AddClientToken(ctx context.Context, jti uuid.UUID, clientID string, createdAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clientTokens[jti] = memoryClientToken{clientID: clientID, expiresAt: expiresAt}
+	return nil
+}
+
+// CheckClientToken implements SessionRepository. Deleting a client revokes its tokens through
+// RevokeClient, which the Postgres implementation gets from joining oauth_clients.
+func (r *MemorySessionRepository) CheckClientToken(ctx context.Context, jti uuid.UUID, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.clientTokens[jti]; !ok || t.clientID != clientID || t.revoked {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// RevokeClientToken implements SessionRepository.
+func (r *MemorySessionRepository) RevokeClientToken(ctx context.Context, jti uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.clientTokens[jti]; ok {
+		t.revoked = true
+		r.clientTokens[jti] = t
+	}
+	return nil
+}
+
+// WithTx implements SessionRepository.
+func (r *MemorySessionRepository) WithTx(tx *sql.Tx) SessionRepository {
+	return r
+}
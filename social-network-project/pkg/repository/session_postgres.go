@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// PostgresSessionRepository keeps the session registry in the sessions, session_tokens,
+// refresh_tokens and oauth_client_tokens tables.
+type PostgresSessionRepository struct {
+	DB DB
+}
+
+// NewPostgresSessionRepository creates a PostgresSessionRepository.
+func NewPostgresSessionRepository(db DB) *PostgresSessionRepository {
+	return &PostgresSessionRepository{DB: db}
+}
+
+// Create implements SessionRepository.
+func (r *PostgresSessionRepository) Create(ctx context.Context, session *models.Session) error {
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO sessions (id, user_id, device_name, user_agent, ip_address, created_at, last_seen_at, expires_at, client_id, scope)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		session.ID, session.UserID, session.DeviceName, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastSeenAt, session.ExpiresAt,
+		sql.NullString{String: session.ClientID, Valid: session.ClientID != ""}, sql.NullString{String: session.Scope, Valid: session.ClientID != ""})
+	return err
+}
+
+// ListActive implements SessionRepository.
+func (r *PostgresSessionRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT id, user_id, device_name, user_agent, ip_address, COALESCE(client_id, ''), COALESCE(scope, ''), created_at, last_seen_at, expires_at
+		FROM sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.DeviceName, &s.UserAgent, &s.IPAddress, &s.ClientID, &s.Scope, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// SetExpiry implements SessionRepository.
+func (r *PostgresSessionRepository) SetExpiry(ctx context.Context, sessionID uuid.UUID, expiresAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE sessions SET expires_at = $1 WHERE id = $2", expiresAt.UTC(), sessionID)
+	return err
+}
+
+// Revoke implements SessionRepository.
+func (r *PostgresSessionRepository) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	result, err := r.DB.ExecContext(ctx, "UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrSessionNotFound
+	}
+	_, err = r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL", sessionID, userID)
+	return err
+}
+
+// RevokeAll implements SessionRepository.
+func (r *PostgresSessionRepository) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.DB.ExecContext(ctx, "UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID); err != nil {
+		return err
+	}
+	_, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return err
+}
+
+// RevokeClient implements SessionRepository.
+func (r *PostgresSessionRepository) RevokeClient(ctx context.Context, clientID string, userID *uuid.UUID) error {
+	if _, err := r.DB.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE revoked_at IS NULL
+		AND family_id IN (SELECT id FROM sessions WHERE client_id = $1 AND ($2::uuid IS NULL OR user_id = $2))`, clientID, userID); err != nil {
+		return err
+	}
+	if _, err := r.DB.ExecContext(ctx, "UPDATE sessions SET revoked_at = NOW() WHERE client_id = $1 AND ($2::uuid IS NULL OR user_id = $2) AND revoked_at IS NULL", clientID, userID); err != nil {
+		return err
+	}
+	if userID != nil {
+		return nil
+	}
+	_, err := r.DB.ExecContext(ctx, "UPDATE oauth_client_tokens SET revoked_at = NOW() WHERE client_id = $1 AND revoked_at IS NULL", clientID)
+	return err
+}
+
+// AddAccessToken implements SessionRepository.
+func (r *PostgresSessionRepository) AddAccessToken(ctx context.Context, jti, sessionID uuid.UUID, expiresAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO session_tokens (jti, session_id, expires_at) VALUES ($1, $2, $3)", jti, sessionID, expiresAt.UTC())
+	return err
+}
+
+// CheckAccessToken implements SessionRepository. "iat" only has second precision, so a token issued
+// in the same second as the password change is rejected, unless its session was created after the
+// change like the fresh session ChangePassword hands out.
+func (r *PostgresSessionRepository) CheckAccessToken(ctx context.Context, jti, userID uuid.UUID, issuedAt time.Time) (uuid.UUID, error) {
+	var sessionID uuid.UUID
+	err := r.DB.QueryRowContext(ctx, `SELECT s.id FROM session_tokens st
+		JOIN sessions s ON s.id = st.session_id
+		JOIN users u ON u.id = s.user_id
+		WHERE st.jti = $1 AND s.user_id = $2 AND s.revoked_at IS NULL
+		AND (u.password_changed_at IS NULL OR date_trunc('second', u.password_changed_at) < $3 OR s.created_at > u.password_changed_at)`,
+		jti, userID, issuedAt.UTC()).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrSessionNotFound
+	}
+	return sessionID, err
+}
+
+// Touch implements SessionRepository.
+func (r *PostgresSessionRepository) Touch(ctx context.Context, sessionID uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE sessions SET last_seen_at = NOW() WHERE id = $1 AND last_seen_at < NOW() - INTERVAL '1 minute'", sessionID)
+	return err
+}
+
+// AddRefreshToken implements SessionRepository.
+func (r *PostgresSessionRepository) AddRefreshToken(ctx context.Context, token *RefreshToken) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		token.ID, token.UserID, token.SessionID, token.TokenHash, token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.ExecContext(ctx, "UPDATE sessions SET expires_at = $1, last_seen_at = $2 WHERE id = $3", token.ExpiresAt, token.CreatedAt, token.SessionID)
+	return err
+}
+
+// GetRefreshToken implements SessionRepository.
+func (r *PostgresSessionRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var (
+		token     RefreshToken
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+	err := r.DB.QueryRowContext(ctx, `SELECT rt.id, rt.user_id, rt.family_id, rt.token_hash, rt.created_at, rt.expires_at, rt.used_at, rt.revoked_at,
+			COALESCE(s.client_id, ''), COALESCE(s.scope, ''), s.revoked_at IS NOT NULL
+		FROM refresh_tokens rt JOIN sessions s ON s.id = rt.family_id
+		WHERE rt.token_hash = $1 FOR UPDATE OF rt`, tokenHash).Scan(
+		&token.ID, &token.UserID, &token.SessionID, &token.TokenHash, &token.CreatedAt, &token.ExpiresAt, &usedAt, &revokedAt,
+		&token.ClientID, &token.Scope, &token.SessionRevoked,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
+
+// UseRefreshToken implements SessionRepository.
+func (r *PostgresSessionRepository) UseRefreshToken(ctx context.Context, id, replacedBy uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE refresh_tokens SET used_at = NOW(), replaced_by = $1 WHERE id = $2", replacedBy, id)
+	return err
+}
+
+// AddClientToken implements SessionRepository.
+func (r *PostgresSessionRepository) AddClientToken(ctx context.Context, jti uuid.UUID, clientID string, createdAt, expiresAt time.Time) error {
+	_, err := r.DB.ExecContext(ctx, "INSERT INTO oauth_client_tokens (jti, client_id, created_at, expires_at) VALUES ($1, $2, $3, $4)",
+		jti, clientID, createdAt.UTC(), expiresAt.UTC())
+	return err
+}
+
+// CheckClientToken implements SessionRepository.
+func (r *PostgresSessionRepository) CheckClientToken(ctx context.Context, jti uuid.UUID, clientID string) error {
+	var active bool
+	err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM oauth_client_tokens t
+		JOIN oauth_clients c ON c.id = t.client_id
+		WHERE t.jti = $1 AND t.client_id = $2 AND t.revoked_at IS NULL AND c.revoked_at IS NULL)`,
+		jti, clientID).Scan(&active)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+// RevokeClientToken implements SessionRepository.
+func (r *PostgresSessionRepository) RevokeClientToken(ctx context.Context, jti uuid.UUID) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE oauth_client_tokens SET revoked_at = NOW() WHERE jti = $1 AND revoked_at IS NULL", jti)
+	return err
+}
+
+// WithTx implements SessionRepository.
+func (r *PostgresSessionRepository) WithTx(tx *sql.Tx) SessionRepository {
+	return &PostgresSessionRepository{DB: tx}
+}
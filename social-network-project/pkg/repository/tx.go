@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Tx is a transaction begun by a Transactor. Handlers pass SQL() to the repositories' WithTx so
+// their statements commit or roll back together.
+type Tx interface {
+	// SQL returns the database transaction, or nil for memory repositories, whose WithTx ignores it.
+	SQL() *sql.Tx
+	Commit() error
+	// Rollback aborts the transaction. It may be deferred: after Commit it does nothing.
+	Rollback() error
+}
+
+// Transactor begins transactions spanning several repositories.
+type Transactor interface {
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// PostgresTransactor begins transactions on the database.
+type PostgresTransactor struct {
+	DB *sql.DB
+}
+
+// NewPostgresTransactor creates a PostgresTransactor.
+func NewPostgresTransactor(db *sql.DB) *PostgresTransactor {
+	return &PostgresTransactor{DB: db}
+}
+
+// Begin implements Transactor.
+func (t *PostgresTransactor) Begin(ctx context.Context) (Tx, error) {
+	tx, err := t.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return postgresTx{tx}, nil
+}
+
+type postgresTx struct {
+	*sql.Tx
+}
+
+func (t postgresTx) SQL() *sql.Tx {
+	return t.Tx
+}
+
+// MemoryTransactor begins transactions for memory repositories, for tests. They are not
+// isolated and can't be rolled back: writes take effect immediately.
+type MemoryTransactor struct{}
+
+// Begin implements Transactor.
+func (MemoryTransactor) Begin(ctx context.Context) (Tx, error) {
+	return memoryTx{}, nil
+}
+
+type memoryTx struct{}
+
+func (memoryTx) SQL() *sql.Tx    { return nil }
+func (memoryTx) Commit() error   { return nil }
+func (memoryTx) Rollback() error { return nil }
@@ -0,0 +1,98 @@
+// Package repository is the data access layer of both services: the users table and session
+// registry they share, auth-service's credentials and OAuth state, and user-service's social graph
+// and admin tables. Handlers depend on the repository interfaces, bundled in Repositories:
+// Postgres in the services, memory in tests. Writes that must commit together run in a Tx from
+// a Transactor, passed to each repository's WithTx.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// ErrNotFound is returned when no user matches.
+var ErrNotFound = errors.New("repository: user not found")
+
+// ErrUsernameTaken is returned when a write would duplicate another user's username, ignoring case.
+var ErrUsernameTaken = errors.New("repository: username already in use")
+
+// ErrEmailTaken is returned when a write would duplicate another user's email address, ignoring case.
+var ErrEmailTaken = errors.New("repository: email address already in use")
+
+// ErrConflict is returned when a write would duplicate any other unique value, such as the ID.
+var ErrConflict = errors.New("repository: user already exists")
+
+// ErrModified is returned by UpdateProfile when the user changed after the version the update
+// was based on.
+var ErrModified = errors.New("repository: user was modified concurrently")
+
+// ProfileUpdate lists the profile fields to change; nil fields are left alone and empty strings
+// clear the field.
+type ProfileUpdate struct {
+	DisplayName *string
+	Bio         *string
+	IsPrivate   *bool
+
+	// IfUpdatedAt, when set, makes the update apply only if the user's UpdatedAt still equals it.
+	IfUpdatedAt *time.Time
+}
+
+// UserFilter selects users for List. Zero values don't filter.
+type UserFilter struct {
+	Query  string // Case-insensitive substring of username, display name or email
+	Active *bool
+	Limit  int // Zero means no limit
+	Offset int
+}
+
+// UserRepository reads and writes users. Lookups return users whether or not they are active;
+// callers decide what an inactive or reset-pending account may still do.
+type UserRepository interface {
+	// GetByID returns the user with the ID, or ErrNotFound.
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetByUsername returns the user with the username, ignoring case, or ErrNotFound.
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetByEmail returns the user with this email address, ignoring case, or ErrNotFound.
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetByQRCodeIdentifier returns the user whose QR code carries the identifier, or ErrNotFound.
+	GetByQRCodeIdentifier(ctx context.Context, identifier string) (*models.User, error)
+	// UsernameTaken reports whether any user has the username, ignoring case.
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+	// EmailTaken reports whether a user other than exceptUserID has the email address, ignoring case.
+	EmailTaken(ctx context.Context, email string, exceptUserID uuid.UUID) (bool, error)
+	// List returns users matching the filter, newest first.
+	List(ctx context.Context, filter UserFilter) ([]models.User, error)
+
+	// Create inserts the user, whose username must be normalized with models.NormalizeUsername.
+	// It relies on the unique constraints rather than checking first, so of two concurrent
+	// registrations for a name one gets ErrUsernameTaken; likewise ErrEmailTaken for the email.
+	// A failed Create leaves the transaction usable, so the caller can retry with another name.
+	Create(ctx context.Context, user *models.User) error
+	// UpdateProfile applies the update and returns the updated user, or ErrNotFound, or ErrModified
+	// if update.IfUpdatedAt no longer matches.
+	UpdateProfile(ctx context.Context, id uuid.UUID, update ProfileUpdate) (*models.User, error)
+	// SetQRCodeIdentifier replaces the identifier in the user's QR code, so codes showing the old
+	// one stop resolving, or returns ErrNotFound.
+	SetQRCodeIdentifier(ctx context.Context, id uuid.UUID, identifier string) error
+	// SetEmail replaces the email address and marks it unverified, or returns ErrEmailTaken.
+	SetEmail(ctx context.Context, id uuid.UUID, email string) error
+	// ConfirmEmail marks the email address verified if it is still the user's address, or returns ErrNotFound.
+	ConfirmEmail(ctx context.Context, id uuid.UUID, email string) error
+	// UpdatePassword stores a new password hash for an active user and clears a required
+	// password reset, or returns ErrNotFound.
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	// SetActive activates or deactivates the user, or returns ErrNotFound.
+	SetActive(ctx context.Context, id uuid.UUID, active bool) error
+	// RequirePasswordReset blocks logins until the password is reset, or returns ErrNotFound.
+	RequirePasswordReset(ctx context.Context, id uuid.UUID) error
+
+	// WithTx returns a repository whose statements run in tx, so they commit or roll back with the
+	// caller's other changes. Implementations without transactions return themselves.
+	WithTx(tx *sql.Tx) UserRepository
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// MemoryUserRepository keeps users in process memory, for tests. It has no transactions:
+// WithTx returns the repository itself and writes take effect immediately.
+type MemoryUserRepository struct {
+	mu                sync.RWMutex
+	users             map[uuid.UUID]models.User
+	passwordChangedAt map[uuid.UUID]time.Time // Kept out of models.User like the password_changed_at column
+}
+
+// NewMemoryUserRepository creates an empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:             make(map[uuid.UUID]models.User),
+		passwordChangedAt: make(map[uuid.UUID]time.Time),
+	}
+}
+
+// find returns a copy of the first user matching, or ErrNotFound. The caller must hold mu.
+func (r *MemoryUserRepository) find(match func(u *models.User) bool) (*models.User, error) {
+	for _, u := range r.users {
+		if match(&u) {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// GetByID implements UserRepository.
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+// GetByUsername implements UserRepository.
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *models.User) bool { return strings.EqualFold(u.Username, username) })
+}
+
+// GetByEmail implements UserRepository.
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *models.User) bool { return u.Email != "" && strings.EqualFold(u.Email, email) })
+}
+
+// GetByQRCodeIdentifier implements UserRepository.
+func (r *MemoryUserRepository) GetByQRCodeIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.find(func(u *models.User) bool { return identifier != "" && u.QRCodeIdentifier == identifier })
+}
+
+// UsernameTaken implements UserRepository.
+func (r *MemoryUserRepository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, err := r.find(func(u *models.User) bool { return strings.EqualFold(u.Username, username) })
+	return err == nil, nil
+}
+
+// EmailTaken implements UserRepository.
+func (r *MemoryUserRepository) EmailTaken(ctx context.Context, email string, exceptUserID uuid.UUID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, err := r.find(func(u *models.User) bool {
+		return u.ID != exceptUserID && u.Email != "" && strings.EqualFold(u.Email, email)
+	})
+	return err == nil, nil
+}
+
+// List implements UserRepository.
+func (r *MemoryUserRepository) List(ctx context.Context, filter UserFilter) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query := strings.ToLower(filter.Query)
+	users := []models.User{}
+	for _, u := range r.users {
+		if query != "" && !strings.Contains(strings.ToLower(u.Username), query) &&
+			!strings.Contains(strings.ToLower(u.DisplayName), query) && !strings.Contains(strings.ToLower(u.Email), query) {
+			continue
+		}
+		if filter.Active != nil && u.IsActive != *filter.Active {
+			continue
+		}
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+
+	if filter.Offset >= len(users) {
+		return []models.User{}, nil
+	}
+	users = users[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(users) {
+		users = users[:filter.Limit]
+	}
+	return users, nil
+}
+
+// Create implements UserRepository. Like the users table it enforces unique IDs and, ignoring
+// case, unique usernames and email addresses.
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.users[user.ID]; exists {
+		return ErrConflict
+	}
+	if _, err := r.find(func(u *models.User) bool { return strings.EqualFold(u.Username, user.Username) }); err == nil {
+		return ErrUsernameTaken
+	}
+	if _, err := r.find(func(u *models.User) bool { return user.Email != "" && strings.EqualFold(u.Email, user.Email) }); err == nil {
+		return ErrEmailTaken
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+// UpdateProfile implements UserRepository.
+func (r *MemoryUserRepository) UpdateProfile(ctx context.Context, id uuid.UUID, update ProfileUpdate) (*models.User, error) {
+	return r.update(id, func(u *models.User) error {
+		if update.IfUpdatedAt != nil && !u.UpdatedAt.Equal(*update.IfUpdatedAt) {
+			return ErrModified
+		}
+		if update.DisplayName != nil {
+			u.DisplayName = *update.DisplayName
+		}
+		if update.Bio != nil {
+			u.Bio = *update.Bio
+		}
+		if update.IsPrivate != nil {
+			u.IsPrivate = *update.IsPrivate
+		}
+		return nil
+	})
+}
+
+// SetQRCodeIdentifier implements UserRepository.
+func (r *MemoryUserRepository) SetQRCodeIdentifier(ctx context.Context, id uuid.UUID, identifier string) error {
+	_, err := r.update(id, func(u *models.User) error {
+		u.QRCodeIdentifier = identifier
+		return nil
+	})
+	return err
+}
+
+// SetEmail implements UserRepository.
+func (r *MemoryUserRepository) SetEmail(ctx context.Context, id uuid.UUID, email string) error {
+	_, err := r.update(id, func(u *models.User) error {
+		if _, err := r.find(func(other *models.User) bool { return other.ID != id && strings.EqualFold(other.Email, email) }); err == nil {
+			return ErrEmailTaken
+		}
+		u.Email, u.EmailVerified = email, false
+		return nil
+	})
+	return err
+}
+
+// ConfirmEmail implements UserRepository.
+func (r *MemoryUserRepository) ConfirmEmail(ctx context.Context, id uuid.UUID, email string) error {
+	_, err := r.update(id, func(u *models.User) error {
+		if !strings.EqualFold(u.Email, email) {
+			return ErrNotFound
+		}
+		u.EmailVerified = true
+		return nil
+	})
+	return err
+}
+
+// UpdatePassword implements UserRepository.
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	_, err := r.update(id, func(u *models.User) error {
+		if !u.IsActive {
+			return ErrNotFound
+		}
+		u.PasswordHash, u.PasswordResetRequired = passwordHash, false
+		r.passwordChangedAt[id] = time.Now().UTC()
+		return nil
+	})
+	return err
+}
+
+// passwordChanged returns when the user's password was last changed, if it ever was.
+func (r *MemoryUserRepository) passwordChanged(id uuid.UUID) (time.Time, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	changedAt, ok := r.passwordChangedAt[id]
+	return changedAt, ok
+}
+
+// SetActive implements UserRepository.
+func (r *MemoryUserRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	_, err := r.update(id, func(u *models.User) error {
+		u.IsActive = active
+		return nil
+	})
+	return err
+}
+
+// RequirePasswordReset implements UserRepository.
+func (r *MemoryUserRepository) RequirePasswordReset(ctx context.Context, id uuid.UUID) error {
+	_, err := r.update(id, func(u *models.User) error {
+		u.PasswordResetRequired = true
+		return nil
+	})
+	return err
+}
+
+// update applies change to the stored user and bumps UpdatedAt, unless change fails, and returns
+// a copy of the updated user.
+func (r *MemoryUserRepository) update(id uuid.UUID, change func(u *models.User) error) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if err := change(&u); err != nil {
+		return nil, err
+	}
+	u.UpdatedAt = time.Now().UTC()
+	r.users[id] = u
+	return &u, nil
+}
+
+// WithTx implements UserRepository.
+func (r *MemoryUserRepository) WithTx(tx *sql.Tx) UserRepository {
+	return r
+}
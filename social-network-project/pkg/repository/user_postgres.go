@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+// DB is the subset of *sql.DB and *sql.Tx used by PostgresUserRepository.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// userColumns are the users columns read into models.User, in scanUser's order.
+const userColumns = "id, username, COALESCE(email, ''), email_verified, password_hash, COALESCE(display_name, ''), COALESCE(bio, ''), qr_code_identifier, created_at, updated_at, is_active, password_reset_required, is_private"
+
+// PostgresUserRepository keeps users in the users table.
+type PostgresUserRepository struct {
+	DB DB
+}
+
+// NewPostgresUserRepository creates a PostgresUserRepository.
+func NewPostgresUserRepository(db DB) *PostgresUserRepository {
+	return &PostgresUserRepository{DB: db}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.EmailVerified, &u.PasswordHash, &u.DisplayName, &u.Bio, &u.QRCodeIdentifier,
+		&u.CreatedAt, &u.UpdatedAt, &u.IsActive, &u.PasswordResetRequired, &u.IsPrivate)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *PostgresUserRepository) getOne(ctx context.Context, where string, args ...interface{}) (*models.User, error) {
+	return scanUser(r.DB.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE "+where, args...))
+}
+
+// GetByID implements UserRepository.
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.getOne(ctx, "id = $1", id)
+}
+
+// GetByUsername implements UserRepository.
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.getOne(ctx, "username = $1", models.NormalizeUsername(username))
+}
+
+// GetByEmail implements UserRepository.
+func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.getOne(ctx, "lower(email) = lower($1)", email)
+}
+
+// GetByQRCodeIdentifier implements UserRepository.
+func (r *PostgresUserRepository) GetByQRCodeIdentifier(ctx context.Context, identifier string) (*models.User, error) {
+	return r.getOne(ctx, "qr_code_identifier = $1", identifier)
+}
+
+// UsernameTaken implements UserRepository.
+func (r *PostgresUserRepository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	var taken bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)", models.NormalizeUsername(username)).Scan(&taken)
+	return taken, err
+}
+
+// EmailTaken implements UserRepository.
+func (r *PostgresUserRepository) EmailTaken(ctx context.Context, email string, exceptUserID uuid.UUID) (bool, error) {
+	var taken bool
+	err := r.DB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = lower($1) AND id <> $2)", email, exceptUserID).Scan(&taken)
+	return taken, err
+}
+
+// List implements UserRepository.
+func (r *PostgresUserRepository) List(ctx context.Context, filter UserFilter) ([]models.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE TRUE"
+	args := []interface{}{}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		query += fmt.Sprintf(" AND (username ILIKE $%d OR display_name ILIKE $%d OR email ILIKE $%d)", len(args), len(args), len(args))
+	}
+	if filter.Active != nil {
+		args = append(args, *filter.Active)
+		query += fmt.Sprintf(" AND is_active = $%d", len(args))
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// Create implements UserRepository.
+func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	// A failed statement aborts the whole transaction, so inside one the insert gets a savepoint
+	// to roll back to: callers retry with another username after ErrUsernameTaken.
+	if _, inTx := r.DB.(*sql.Tx); inTx {
+		if _, err := r.DB.ExecContext(ctx, "SAVEPOINT create_user"); err != nil {
+			return err
+		}
+		if err := r.insert(ctx, user); err != nil {
+			if _, rbErr := r.DB.ExecContext(ctx, "ROLLBACK TO SAVEPOINT create_user"); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+		_, err := r.DB.ExecContext(ctx, "RELEASE SAVEPOINT create_user")
+		return err
+	}
+	return r.insert(ctx, user)
+}
+
+func (r *PostgresUserRepository) insert(ctx context.Context, user *models.User) error {
+	_, err := r.DB.ExecContext(ctx, `INSERT INTO users (id, username, email, email_verified, password_hash, display_name, bio, qr_code_identifier, created_at, updated_at, is_active, is_private)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		user.ID, user.Username, sql.NullString{String: user.Email, Valid: user.Email != ""}, user.EmailVerified, user.PasswordHash,
+		user.DisplayName, user.Bio, user.QRCodeIdentifier, user.CreatedAt, user.UpdatedAt, user.IsActive, user.IsPrivate)
+	return conflictError(err)
+}
+
+// UpdateProfile implements UserRepository.
+func (r *PostgresUserRepository) UpdateProfile(ctx context.Context, id uuid.UUID, update ProfileUpdate) (*models.User, error) {
+	query := "UPDATE users SET updated_at = $1"
+	args := []interface{}{time.Now().UTC()}
+	if update.DisplayName != nil {
+		args = append(args, *update.DisplayName)
+		query += fmt.Sprintf(", display_name = NULLIF($%d, '')", len(args))
+	}
+	if update.Bio != nil {
+		args = append(args, *update.Bio)
+		query += fmt.Sprintf(", bio = NULLIF($%d, '')", len(args))
+	}
+	if update.IsPrivate != nil {
+		args = append(args, *update.IsPrivate)
+		query += fmt.Sprintf(", is_private = $%d", len(args))
+	}
+	args = append(args, id)
+	query += fmt.Sprintf(" WHERE id = $%d", len(args))
+	if update.IfUpdatedAt != nil {
+		args = append(args, *update.IfUpdatedAt)
+		query += fmt.Sprintf(" AND updated_at = $%d", len(args))
+	}
+
+	user, err := scanUser(r.DB.QueryRowContext(ctx, query+" RETURNING "+userColumns, args...))
+	if err == ErrNotFound && update.IfUpdatedAt != nil {
+		// Tell a stale version apart from a missing user.
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrModified
+	}
+	return user, err
+}
+
+// SetQRCodeIdentifier implements UserRepository.
+func (r *PostgresUserRepository) SetQRCodeIdentifier(ctx context.Context, id uuid.UUID, identifier string) error {
+	return r.updateOne(ctx, "UPDATE users SET qr_code_identifier = $1, updated_at = NOW() WHERE id = $2", identifier, id)
+}
+
+// SetEmail implements UserRepository.
+func (r *PostgresUserRepository) SetEmail(ctx context.Context, id uuid.UUID, email string) error {
+	return r.updateOne(ctx, "UPDATE users SET email = $1, email_verified = FALSE, updated_at = NOW() WHERE id = $2", email, id)
+}
+
+// ConfirmEmail implements UserRepository.
+func (r *PostgresUserRepository) ConfirmEmail(ctx context.Context, id uuid.UUID, email string) error {
+	return r.updateOne(ctx, "UPDATE users SET email_verified = TRUE, updated_at = NOW() WHERE id = $1 AND lower(email) = lower($2)", id, email)
+}
+
+// UpdatePassword implements UserRepository.
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	return r.updateOne(ctx, "UPDATE users SET password_hash = $1, password_reset_required = FALSE, password_changed_at = NOW(), updated_at = NOW() WHERE id = $2 AND is_active = TRUE",
+		passwordHash, id)
+}
+
+// SetActive implements UserRepository.
+func (r *PostgresUserRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	return r.updateOne(ctx, "UPDATE users SET is_active = $1, updated_at = NOW() WHERE id = $2", active, id)
+}
+
+// RequirePasswordReset implements UserRepository.
+func (r *PostgresUserRepository) RequirePasswordReset(ctx context.Context, id uuid.UUID) error {
+	return r.updateOne(ctx, "UPDATE users SET password_reset_required = TRUE, updated_at = NOW() WHERE id = $1", id)
+}
+
+// conflictError maps a unique_violation on the users table to ErrUsernameTaken, ErrEmailTaken or
+// ErrConflict, and returns any other error unchanged.
+func conflictError(err error) error {
+	pqErr, ok := err.(*pq.Error)
+	if !ok || pqErr.Code != "23505" { // unique_violation
+		return err
+	}
+	switch pqErr.Constraint {
+	case "users_username_key":
+		return ErrUsernameTaken
+	case "idx_users_email_lower":
+		return ErrEmailTaken
+	}
+	return ErrConflict
+}
+
+// updateOne runs an UPDATE expected to change exactly one user, returning ErrNotFound if it changed none.
+func (r *PostgresUserRepository) updateOne(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return conflictError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// WithTx implements UserRepository.
+func (r *PostgresUserRepository) WithTx(tx *sql.Tx) UserRepository {
+	return &PostgresUserRepository{DB: tx}
+}
@@ -0,0 +1,171 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// DB is the subset of *sql.DB and *sql.Tx used by PostgresStore.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// PostgresStore keeps counters in the login_throttle table (see pkg/migrations), so all
+// instances of the service share them.
+type PostgresStore struct {
+	DB DB
+}
+
+// NewPostgresStore creates a PostgresStore.
+func NewPostgresStore(db DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+// Get implements Store.
+func (s *PostgresStore) Get(ctx context.Context, key string) (State, error) {
+	var (
+		state       State
+		lockedUntil sql.NullTime
+	)
+	err := s.DB.QueryRowContext(ctx, "SELECT failures, last_failure_at, locked_until FROM login_throttle WHERE key = $1", key).Scan(
+		&state.Failures, &state.LastFailure, &lockedUntil,
+	)
+	if err == sql.ErrNoRows {
+		return State{}, nil
+	}
+	if err != nil {
+		return State{}, err
+	}
+	state.LockedUntil = lockedUntil.Time
+	return state, nil
+}
+
+// RecordFailure implements Store. The first failure of a key inserts its row; later ones update it
+// only while it still holds seen, so of two concurrent attempts that read the same state one loses.
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, seen State, now time.Time, window time.Duration) (State, bool, error) {
+	var (
+		state       State
+		lockedUntil sql.NullTime
+		row         *sql.Row
+	)
+	if seen.LastFailure.IsZero() { // Nothing recorded yet
+		row = s.DB.QueryRowContext(ctx, `INSERT INTO login_throttle (key, failures, last_failure_at) VALUES ($1, 1, $2)
+			ON CONFLICT (key) DO NOTHING
+			RETURNING failures, last_failure_at, locked_until`,
+			key, now.UTC())
+	} else {
+		row = s.DB.QueryRowContext(ctx, `UPDATE login_throttle SET
+				failures = CASE WHEN last_failure_at < $3 THEN 1 ELSE failures + 1 END,
+				last_failure_at = $2
+			WHERE key = $1 AND failures = $4 AND last_failure_at = $5 AND locked_until IS NOT DISTINCT FROM $6
+			RETURNING failures, last_failure_at, locked_until`,
+			key, now.UTC(), now.Add(-window).UTC(), seen.Failures, seen.LastFailure.UTC(),
+			sql.NullTime{Time: seen.LockedUntil.UTC(), Valid: !seen.LockedUntil.IsZero()})
+	}
+	err := row.Scan(&state.Failures, &state.LastFailure, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return State{}, false, nil
+	}
+	if err != nil {
+		return State{}, false, err
+	}
+	state.LockedUntil = lockedUntil.Time
+	return state, true, nil
+}
+
+// Release implements Store.
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx, "UPDATE login_throttle SET failures = failures - 1 WHERE key = $1 AND failures > 0", key)
+	return err
+}
+
+// Lock implements Store.
+func (s *PostgresStore) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := s.DB.ExecContext(ctx, "UPDATE login_throttle SET locked_until = $1 WHERE key = $2", until.UTC(), key)
+	return err
+}
+
+// Reset implements Store.
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM login_throttle WHERE key = $1", key)
+	return err
+}
+
+// WithTx implements Store.
+func (s *PostgresStore) WithTx(tx *sql.Tx) Store {
+	return &PostgresStore{DB: tx}
+}
+
+// MemoryStore keeps counters in process memory. It suits tests and single-instance setups;
+// counters are lost on restart and not shared between instances.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]State)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states[key], nil
+}
+
+// RecordFailure implements Store.
+func (s *MemoryStore) RecordFailure(ctx context.Context, key string, seen State, now time.Time, window time.Duration) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[key]
+	if state.Failures != seen.Failures || !state.LastFailure.Equal(seen.LastFailure) || !state.LockedUntil.Equal(seen.LockedUntil) {
+		return State{}, false, nil
+	}
+	if state.Failures == 0 || now.Sub(state.LastFailure) > window {
+		state.Failures = 1
+	} else {
+		state.Failures++
+	}
+	state.LastFailure = now
+	s.states[key] = state
+	return state, true, nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.states[key]; ok && state.Failures > 0 {
+		state.Failures--
+		s.states[key] = state
+	}
+	return nil
+}
+
+// Lock implements Store.
+func (s *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.states[key]
+	state.LockedUntil = until
+	s.states[key] = state
+	return nil
+}
+
+// Reset implements Store.
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+	return nil
+}
+
+// WithTx implements Store.
+func (s *MemoryStore) WithTx(tx *sql.Tx) Store {
+	return s
+}
@@ -0,0 +1,248 @@
+// Package throttle slows down password guessing. Failed logins are counted per username and per
+// client IP; past a few free attempts every further try has to wait exponentially longer, and after
+// too many failures the key is locked out for a while. Counters live in a Store: Postgres in the
+// services, memory in tests. A Limiter with its own Scope throttles other requests the same way,
+// such as password reset emails.
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// State is what a Store keeps for one key.
+type State struct {
+	Failures    int       // Consecutive failures within the policy window
+	LastFailure time.Time // Time of the most recent failure
+	LockedUntil time.Time // Zero unless the key is locked out
+}
+
+// Store persists failure counters. Implementations must make RecordFailure atomic.
+type Store interface {
+	// Get returns the state for key, or the zero State if nothing is recorded.
+	Get(ctx context.Context, key string) (State, error)
+	// RecordFailure counts a failure at now if the key still has the state seen, as returned by
+	// Get, and returns the new state. Otherwise another attempt got there first: nothing changes
+	// and ok is false. Failures are counted from one again when the previous failure is older than window.
+	RecordFailure(ctx context.Context, key string, seen State, now time.Time, window time.Duration) (state State, ok bool, err error)
+	// Release takes back one failure, for an attempt that was counted up front but didn't fail.
+	Release(ctx context.Context, key string) error
+	// Lock locks the key out until the given time.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset forgets everything recorded for key.
+	Reset(ctx context.Context, key string) error
+
+	// WithTx returns a store whose statements run in tx, so a reset can commit together with
+	// other writes. Stores without transactions return themselves.
+	WithTx(tx *sql.Tx) Store
+}
+
+// Policy configures backoff and lockout for one kind of key.
+type Policy struct {
+	FreeAttempts     int           // Failures allowed before any delay applies
+	BaseDelay        time.Duration // Delay after the first failure past FreeAttempts; doubles with every further failure
+	MaxDelay         time.Duration // Upper bound of the backoff delay
+	LockoutThreshold int           // Failures that lock the key out; zero disables lockout. Every further failure within Window locks it again
+	LockoutDuration  time.Duration // How long a lockout lasts
+	Window           time.Duration // Failures are forgotten after this long without a new one
+}
+
+// Default policies. IPs get more room because many users can share one (NAT, offices, mobile carriers).
+var (
+	DefaultUsernamePolicy = Policy{
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		Window:           time.Hour,
+	}
+	DefaultIPPolicy = Policy{
+		FreeAttempts:     20,
+		BaseDelay:        time.Second,
+		MaxDelay:         5 * time.Minute,
+		LockoutThreshold: 100,
+		LockoutDuration:  time.Hour,
+		Window:           time.Hour,
+	}
+)
+
+// delay returns how long to wait after the given number of failures.
+func (p Policy) delay(failures int) time.Duration {
+	excess := failures - p.FreeAttempts
+	if excess <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < excess && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+// retryAfter returns how long the key has to wait before its next attempt.
+func (p Policy) retryAfter(s State, now time.Time) (time.Duration, bool) {
+	if now.Before(s.LockedUntil) {
+		return s.LockedUntil.Sub(now), true
+	}
+	if s.Failures == 0 || now.Sub(s.LastFailure) > p.Window {
+		return 0, false
+	}
+	if wait := s.LastFailure.Add(p.delay(s.Failures)).Sub(now); wait > 0 {
+		return wait, false
+	}
+	return 0, false
+}
+
+// UsernameKey is the store key for failures against an account. Usernames are compared case-insensitively.
+func UsernameKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+// IPKey is the store key for failures from a client address.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Decision is the outcome of Limiter.Reserve.
+type Decision struct {
+	RetryAfter time.Duration // How long the client has to wait; zero when the attempt may go ahead
+	Locked     bool          // The username or IP is locked out, rather than just backing off
+}
+
+// Allowed reports whether the attempt may go ahead.
+func (d Decision) Allowed() bool {
+	return d.RetryAfter <= 0
+}
+
+// Attempt is a login attempt admitted by Limiter.Reserve. It is counted as a failure before the
+// password is checked, so concurrent guesses can't all get through before the first one fails.
+// Report how it ended with Limiter.Failure, Success or Release.
+type Attempt struct {
+	username  string
+	ip        string
+	userState State // States right after the attempt was counted
+	ipState   State
+}
+
+// Limiter applies the username and IP policies to a Store.
+type Limiter struct {
+	Store    Store
+	Scope    string // Prefixed to the keys so limiters sharing a Store count apart; empty for logins
+	Username Policy
+	IP       Policy
+	Now      func() time.Time // Overridable clock for tests
+}
+
+func (l *Limiter) userKey(username string) string {
+	return l.Scope + UsernameKey(username)
+}
+
+func (l *Limiter) ipKey(ip string) string {
+	return l.Scope + IPKey(ip)
+}
+
+// NewLimiter creates a Limiter with the default policies.
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		Store:    store,
+		Username: DefaultUsernamePolicy,
+		IP:       DefaultIPPolicy,
+		Now:      time.Now,
+	}
+}
+
+// maxReserveTries bounds how often Reserve re-reads a key that concurrent attempts keep changing.
+const maxReserveTries = 10
+
+// Reserve counts a login attempt for username from ip if both may make one now. Otherwise nothing
+// is counted, the Attempt is nil and the Decision says how long to wait.
+func (l *Limiter) Reserve(ctx context.Context, username, ip string) (*Attempt, Decision, error) {
+	now := l.Now()
+	userKey := l.userKey(username)
+	userState, d, err := l.reserve(ctx, userKey, l.Username, now)
+	if err != nil || !d.Allowed() {
+		return nil, d, err
+	}
+	ipState, d, err := l.reserve(ctx, l.ipKey(ip), l.IP, now)
+	if err != nil || !d.Allowed() {
+		if releaseErr := l.Store.Release(ctx, userKey); err == nil {
+			err = releaseErr
+		}
+		return nil, d, err
+	}
+	return &Attempt{username: username, ip: ip, userState: userState, ipState: ipState}, Decision{}, nil
+}
+
+// reserve counts a failure for key unless it has to wait, retrying when a concurrent attempt
+// changed the key between reading and counting.
+func (l *Limiter) reserve(ctx context.Context, key string, p Policy, now time.Time) (State, Decision, error) {
+	for i := 0; i < maxReserveTries; i++ {
+		seen, err := l.Store.Get(ctx, key)
+		if err != nil {
+			return State{}, Decision{}, err
+		}
+		if wait, locked := p.retryAfter(seen, now); wait > 0 {
+			return State{}, Decision{RetryAfter: wait, Locked: locked}, nil
+		}
+		state, ok, err := l.Store.RecordFailure(ctx, key, seen, now, p.Window)
+		if err != nil {
+			return State{}, Decision{}, err
+		}
+		if ok {
+			return state, Decision{}, nil
+		}
+	}
+	// Too much contention on the key; let the client back off briefly.
+	return State{}, Decision{RetryAfter: time.Second}, nil
+}
+
+// Failure records that the attempt failed and locks out keys that reached their threshold. It
+// reports whether the username was locked, so the account owner can be told.
+func (l *Limiter) Failure(ctx context.Context, a *Attempt) (bool, error) {
+	now := l.Now()
+	userLocked, err := l.lockIfExceeded(ctx, l.userKey(a.username), l.Username, a.userState, now)
+	if err != nil {
+		return false, err
+	}
+	if _, err := l.lockIfExceeded(ctx, l.ipKey(a.ip), l.IP, a.ipState, now); err != nil {
+		return false, err
+	}
+	return userLocked, nil
+}
+
+func (l *Limiter) lockIfExceeded(ctx context.Context, key string, p Policy, state State, now time.Time) (bool, error) {
+	if p.LockoutThreshold <= 0 || state.Failures < p.LockoutThreshold {
+		return false, nil
+	}
+	return true, l.Store.Lock(ctx, key, now.Add(p.LockoutDuration))
+}
+
+// Success records that the login fully succeeded: the username's failures are cleared and the
+// attempt is taken back from the IP. The rest of the IP counter is left alone, otherwise an
+// attacker could reset it by logging into an account of their own.
+func (l *Limiter) Success(ctx context.Context, a *Attempt) error {
+	if err := l.Store.Release(ctx, l.ipKey(a.ip)); err != nil {
+		return err
+	}
+	return l.Store.Reset(ctx, l.userKey(a.username))
+}
+
+// Release takes the attempt back without clearing anything, for a step that succeeded when the
+// login isn't complete yet, such as a correct password followed by a second-factor challenge.
+func (l *Limiter) Release(ctx context.Context, a *Attempt) error {
+	if err := l.Store.Release(ctx, l.userKey(a.username)); err != nil {
+		return err
+	}
+	return l.Store.Release(ctx, l.ipKey(a.ip))
+}
+
+// Unlock lifts a lockout on the username and clears its failures.
+func (l *Limiter) Unlock(ctx context.Context, username string) error {
+	return l.Store.Reset(ctx, l.userKey(username))
+}
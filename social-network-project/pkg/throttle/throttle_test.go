@@ -0,0 +1,214 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testClock is a settable clock for Limiter.Now.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter() (*Limiter, *testClock) {
+	clock := &testClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	l := NewLimiter(NewMemoryStore())
+	l.Now = clock.Now
+	return l, clock
+}
+
+// fail reserves an attempt and reports it failed, failing the test if it isn't admitted.
+func fail(t *testing.T, l *Limiter, username, ip string) bool {
+	t.Helper()
+	attempt, d, err := l.Reserve(context.Background(), username, ip)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if !d.Allowed() {
+		t.Fatalf("Reserve: attempt refused, retry after %s", d.RetryAfter)
+	}
+	locked, err := l.Failure(context.Background(), attempt)
+	if err != nil {
+		t.Fatalf("Failure: %v", err)
+	}
+	return locked
+}
+
+func TestPolicyDelay(t *testing.T) {
+	p := DefaultUsernamePolicy
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{8, 16 * time.Second},
+		{12, 256 * time.Second},
+		{13, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := p.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestReserveBacksOff(t *testing.T) {
+	ctx := context.Background()
+	l, clock := newTestLimiter()
+
+	for i := 0; i < DefaultUsernamePolicy.FreeAttempts+1; i++ {
+		fail(t, l, "alice", "192.0.2.1")
+	}
+
+	_, d, err := l.Reserve(ctx, "Alice", "192.0.2.2")
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if d.Allowed() || d.RetryAfter != time.Second || d.Locked {
+		t.Fatalf("decision = %+v, want a 1s backoff without lockout", d)
+	}
+
+	clock.Advance(time.Second)
+	fail(t, l, "alice", "192.0.2.1")
+	_, d, err = l.Reserve(ctx, "alice", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if d.RetryAfter != 2*time.Second {
+		t.Errorf("retry after = %s, want 2s", d.RetryAfter)
+	}
+
+	// Failures are forgotten once the window has passed without a new one.
+	clock.Advance(DefaultUsernamePolicy.Window + time.Second)
+	if _, d, _ := l.Reserve(ctx, "alice", "192.0.2.1"); !d.Allowed() {
+		t.Errorf("attempt after the window refused: %+v", d)
+	}
+}
+
+func TestReserveCountsConcurrentAttempts(t *testing.T) {
+	l, _ := newTestLimiter()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		admitted int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempt, _, err := l.Reserve(context.Background(), "alice", "192.0.2.1")
+			if err != nil {
+				t.Errorf("Reserve: %v", err)
+				return
+			}
+			if attempt != nil {
+				mu.Lock()
+				admitted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// None of the attempts has finished, yet only the free ones and the first delayed one may run.
+	if want := DefaultUsernamePolicy.FreeAttempts + 1; admitted != want {
+		t.Errorf("admitted %d concurrent attempts, want %d", admitted, want)
+	}
+}
+
+func TestLockoutThreshold(t *testing.T) {
+	ctx := context.Background()
+	l, clock := newTestLimiter()
+	l.Username = Policy{LockoutThreshold: 3, LockoutDuration: 15 * time.Minute, Window: time.Hour}
+
+	for i := 1; i <= 3; i++ {
+		if locked := fail(t, l, "alice", "192.0.2.1"); locked != (i == 3) {
+			t.Fatalf("failure %d: locked = %v, want %v", i, locked, i == 3)
+		}
+	}
+
+	_, d, err := l.Reserve(ctx, "alice", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if !d.Locked || d.RetryAfter != 15*time.Minute {
+		t.Fatalf("decision = %+v, want locked for 15m", d)
+	}
+	if _, d, _ := l.Reserve(ctx, "bob", "192.0.2.1"); !d.Allowed() {
+		t.Errorf("other username refused: %+v", d)
+	}
+
+	// After the lockout every further failure within the window locks again.
+	clock.Advance(15 * time.Minute)
+	if locked := fail(t, l, "alice", "192.0.2.1"); !locked {
+		t.Error("failure after lockout didn't lock again")
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter()
+	l.Username = Policy{LockoutThreshold: 1, LockoutDuration: time.Hour, Window: time.Hour}
+	fail(t, l, "alice", "192.0.2.1")
+
+	if err := l.Unlock(ctx, "ALICE"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if _, d, _ := l.Reserve(ctx, "alice", "192.0.2.1"); !d.Allowed() {
+		t.Errorf("attempt after unlock refused: %+v", d)
+	}
+}
+
+func TestSuccessAndRelease(t *testing.T) {
+	ctx := context.Background()
+	l, _ := newTestLimiter()
+	fail(t, l, "alice", "192.0.2.1")
+	fail(t, l, "alice", "192.0.2.1")
+
+	attempt, _, err := l.Reserve(ctx, "alice", "192.0.2.1")
+	if err != nil || attempt == nil {
+		t.Fatalf("Reserve: %v, %v", attempt, err)
+	}
+	if err := l.Release(ctx, attempt); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if state, _ := l.Store.Get(ctx, UsernameKey("alice")); state.Failures != 2 {
+		t.Errorf("after Release: username failures = %d, want 2", state.Failures)
+	}
+
+	attempt, _, err = l.Reserve(ctx, "alice", "192.0.2.1")
+	if err != nil || attempt == nil {
+		t.Fatalf("Reserve: %v, %v", attempt, err)
+	}
+	if err := l.Success(ctx, attempt); err != nil {
+		t.Fatalf("Success: %v", err)
+	}
+	if state, _ := l.Store.Get(ctx, UsernameKey("alice")); state.Failures != 0 {
+		t.Errorf("after Success: username failures = %d, want 0", state.Failures)
+	}
+	if state, _ := l.Store.Get(ctx, IPKey("192.0.2.1")); state.Failures != 2 {
+		t.Errorf("after Success: IP failures = %d, want 2", state.Failures)
+	}
+}
@@ -0,0 +1,258 @@
+// Package validation enforces the validate (and binding) struct tags on request models and
+// reports failures the same way in every service: a list of invalid fields, each with a stable
+// code and a readable message.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/yourusername/social-network/pkg/apierror"
+	"github.com/yourusername/social-network/pkg/models"
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ValidUsername reports whether s only uses the characters allowed in usernames: ASCII letters,
+// digits and underscores. Length is checked separately by the min/max tags.
+func ValidUsername(s string) bool {
+	return usernamePattern.MatchString(s)
+}
+
+// One validator per tag name: "validate" is what pkg/models documents, "binding" is what Gin
+// enforced before this package existed and is kept working.
+var (
+	validate        = newValidator("validate")
+	bindingValidate = newValidator("binding")
+)
+
+func newValidator(tagName string) *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName(tagName)
+	// Report fields by their JSON names, which is what clients sent.
+	// Embedded structs get an empty name: their fields are flattened into the JSON object.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+			return "-"
+		case name == "" && f.Anonymous:
+			return ""
+		case name == "":
+			return f.Name
+		}
+		return name
+	})
+	if err := v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return ValidUsername(fl.Field().String())
+	}); err != nil {
+		panic(err)
+	}
+	// Merge patch members are validated by their value; absent and null members count as empty,
+	// so "omitempty,max=100" only limits values that are actually set.
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(models.PatchMember).ValidationValue()
+	}, models.PatchString{}, models.PatchBool{})
+	return v
+}
+
+// FieldError describes one invalid field. Code is the rule that failed (e.g. "required", "max").
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is returned by Struct when validation fails.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Struct validates v against its binding and validate tags. It returns Errors, or nil if v is valid.
+func Struct(v interface{}) error {
+	fieldErrors := patchTypeErrors(v)
+	for _, engine := range []*validator.Validate{bindingValidate, validate} {
+		err := engine.Struct(v)
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			for _, fe := range validationErrors {
+				fieldErrors = appendUnique(fieldErrors, FieldError{Field: fieldName(fe), Code: fe.Tag(), Message: message(fe)})
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+	return nil
+}
+
+// patchTypeErrors reports the top-level merge patch members of v that had the wrong type.
+func patchTypeErrors(v interface{}) Errors {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs Errors
+	for i := 0; i < rv.NumField(); i++ {
+		if !rv.Type().Field(i).IsExported() {
+			continue
+		}
+		if p, ok := rv.Field(i).Interface().(models.PatchMember); ok && p.Invalid() {
+			name, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("json"), ",")
+			errs = append(errs, FieldError{
+				Field:   name,
+				Code:    "type",
+				Message: "must be a " + jsonTypeName(reflect.TypeOf(p.ValidationValue())) + " or null",
+			})
+		}
+	}
+	return errs
+}
+
+// appendUnique skips a field error already reported, e.g. a field tagged required in both tags.
+func appendUnique(errs Errors, fe FieldError) Errors {
+	for _, existing := range errs {
+		if existing.Field == fe.Field && existing.Code == fe.Code {
+			return errs
+		}
+	}
+	return append(errs, fe)
+}
+
+// fieldName is the field's path without the top-level struct name or embedded structs, e.g. "address.city".
+func fieldName(fe validator.FieldError) string {
+	parts := strings.Split(fe.Namespace(), ".")
+	var path []string
+	for _, part := range parts[1:] {
+		if part != "" {
+			path = append(path, part)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+func message(fe validator.FieldError) string {
+	kind := fe.Kind()
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if kind == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		if kind == reflect.Slice || kind == reflect.Map {
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if kind == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		if kind == reflect.Slice || kind == reflect.Map {
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "username":
+		return "may only contain letters, digits and underscores"
+	default:
+		return "is invalid"
+	}
+}
+
+// BindJSON decodes the request body into obj and validates it. On failure it answers 400 with a
+// validation_failed problem whose "fields" member lists the invalid fields,
+//
+//	{"code": "validation_failed", "fields": [{"field": "username", "code": "min", "message": "..."}], ...}
+//
+// (or a malformed_body problem for a body that isn't valid JSON) and returns false.
+func BindJSON(c *gin.Context, obj interface{}) bool {
+	if c.Request.Body == nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeMalformedBody, "Request body must be a JSON object"))
+		return false
+	}
+	err := json.NewDecoder(c.Request.Body).Decode(obj)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return respondInvalid(c, Errors{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: "must be a " + jsonTypeName(typeErr.Type),
+		}})
+	case err == io.EOF:
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeMalformedBody, "Request body must be a JSON object"))
+		return false
+	case err != nil:
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeMalformedBody, "Malformed JSON body"))
+		return false
+	}
+
+	return respondInvalid(c, Struct(obj))
+}
+
+// BindQuery is BindJSON for query parameters, mapped with the form struct tags.
+func BindQuery(c *gin.Context, obj interface{}) bool {
+	if err := binding.MapFormWithTag(obj, c.Request.URL.Query(), "form"); err != nil {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeBadRequest, "Invalid query parameters"))
+		return false
+	}
+	return respondInvalid(c, Struct(obj))
+}
+
+// respondInvalid answers 400 with the field errors from Struct and returns false, or returns true if there are none.
+func respondInvalid(c *gin.Context, err error) bool {
+	var fieldErrors Errors
+	if errors.As(err, &fieldErrors) {
+		apierror.Abort(c, apierror.BadRequest(apierror.CodeValidationFailed, "Validation failed").With("fields", fieldErrors))
+		return false
+	}
+	if err != nil {
+		// Only happens for a programming error, e.g. obj not being a struct pointer.
+		log.Printf("Error validating request: %v", err)
+		apierror.Abort(c, apierror.Internal("Failed to validate request"))
+		return false
+	}
+	return true
+}
+
+// jsonTypeName names Go types the way a JSON client thinks of them.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
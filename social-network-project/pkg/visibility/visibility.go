@@ -0,0 +1,136 @@
+// Package visibility decides what one user may see of another, so every service enforces blocks,
+// private accounts and mutes the same way. user-service uses it for profiles and follow lists;
+// services showing user content should filter it through Checker.Filter.
+package visibility
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/social-network/pkg/models"
+	"github.com/yourusername/social-network/pkg/repository"
+)
+
+// Level is how much of a user a viewer may see.
+type Level int
+
+const (
+	// Hidden users don't exist as far as the viewer is concerned: they are inactive, or one of
+	// the two blocked the other. Answer 404, as for a user that doesn't exist.
+	Hidden Level = iota
+	// Limited users are private accounts the viewer doesn't follow: their models.LimitedProfile
+	// only, and none of their content.
+	Limited
+	// Full users show everything to the viewer.
+	Full
+)
+
+// Checker answers visibility questions from the social graph.
+type Checker struct {
+	Users   repository.UserRepository
+	Follows repository.FollowRepository
+	Blocks  repository.BlockRepository
+}
+
+// NewChecker creates a Checker reading from the database.
+func NewChecker(db repository.DB) *Checker {
+	return &Checker{
+		Users:   repository.NewPostgresUserRepository(db),
+		Follows: repository.NewPostgresFollowRepository(db),
+		Blocks:  repository.NewPostgresBlockRepository(db),
+	}
+}
+
+// Level returns how much of user viewerID may see. viewerID is uuid.Nil for tokens that don't
+// act for a user, which see public accounts only and aren't subject to anyone's blocks; services
+// should have client tokens read as the client's owner instead (see authmw.Config.ClientOwner).
+func (c *Checker) Level(ctx context.Context, viewerID uuid.UUID, user *models.User) (Level, error) {
+	if !user.IsActive {
+		return Hidden, nil
+	}
+	if viewerID == user.ID {
+		return Full, nil
+	}
+	if viewerID != uuid.Nil {
+		blocked, err := c.Blocks.IsBlocked(ctx, viewerID, user.ID)
+		if err != nil {
+			return Hidden, err
+		}
+		if blocked {
+			return Hidden, nil
+		}
+	}
+	if !user.IsPrivate {
+		return Full, nil
+	}
+	if viewerID == uuid.Nil {
+		return Limited, nil
+	}
+	following, err := c.Follows.IsFollowing(ctx, viewerID, user.ID)
+	if err != nil {
+		return Hidden, err
+	}
+	if following {
+		return Full, nil
+	}
+	return Limited, nil
+}
+
+// LevelByID is Level for a user ID. Users that don't exist are Hidden.
+func (c *Checker) LevelByID(ctx context.Context, viewerID, userID uuid.UUID) (Level, error) {
+	user, err := c.Users.GetByID(ctx, userID)
+	if err == repository.ErrNotFound {
+		return Hidden, nil
+	}
+	if err != nil {
+		return Hidden, err
+	}
+	return c.Level(ctx, viewerID, user)
+}
+
+// Filter returns the users among ids whose content viewerID should be shown, in the same order:
+// it drops users blocked either way and users viewerID muted. Unlike Level it doesn't check
+// private accounts or inactive users, which the caller's query should already exclude. Tokens
+// without a user filter nothing.
+func (c *Checker) Filter(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return c.filter(ctx, viewerID, ids, true)
+}
+
+// FilterBlocked is Filter without mutes, for places where a muted user should still appear,
+// such as follower lists.
+func (c *Checker) FilterBlocked(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	return c.filter(ctx, viewerID, ids, false)
+}
+
+func (c *Checker) filter(ctx context.Context, viewerID uuid.UUID, ids []uuid.UUID, mutes bool) ([]uuid.UUID, error) {
+	if viewerID == uuid.Nil || len(ids) == 0 {
+		return ids, nil
+	}
+	hidden, err := c.Blocks.BlockedAmong(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if mutes {
+		muted, err := c.Blocks.MutedAmong(ctx, viewerID, ids)
+		if err != nil {
+			return nil, err
+		}
+		hidden = append(hidden, muted...)
+	}
+	if len(hidden) == 0 {
+		return ids, nil
+	}
+
+	skip := make(map[uuid.UUID]bool, len(hidden))
+	for _, id := range hidden {
+		skip[id] = true
+	}
+	visible := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !skip[id] {
+			visible = append(visible, id)
+		}
+	}
+	return visible, nil
+}